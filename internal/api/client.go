@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to a store's /api/v1 with an api key made at
+// /admin/apikeys.
+type Client struct {
+	addr string
+	key  string
+	cli  *http.Client
+}
+
+// NewClient returns a client for the store at addr, e.g.
+// https://example.com.
+func NewClient(addr, key string) *Client {
+	return &Client{
+		addr: strings.TrimSuffix(addr, "/"),
+		key:  key,
+		cli:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Categories returns the top level categories.
+func (c *Client) Categories() ([]Category, error) {
+	var cats []Category
+	return cats, c.do("GET", "/categories", nil, &cats)
+}
+
+// Category returns the category at path along with the names of its
+// sub-categories and products.
+func (c *Client) Category(path ...string) (Category, error) {
+	var cat Category
+	return cat, c.do("GET", c.path("categories", path...), nil, &cat)
+}
+
+// CreateCategory adds a top level category.
+func (c *Client) CreateCategory(cat Category) (Category, error) {
+	var out Category
+	return out, c.do("POST", "/categories", cat, &out)
+}
+
+// CreateSubcategory adds a category called name to the one at path.
+func (c *Client) CreateSubcategory(path []string, name string) (Category, error) {
+	var out Category
+	return out, c.do("POST", c.path("categories", path...), Category{Name: name}, &out)
+}
+
+func (c *Client) RenameCategory(path []string, name string) error {
+	return c.do("PUT", c.path("categories", path...), Category{Name: name}, nil)
+}
+
+func (c *Client) DeleteCategory(path []string) error {
+	return c.do("DELETE", c.path("categories", path...), nil, nil)
+}
+
+// Price is the price of the products in the category at path, which
+// might come from one of its parents.
+func (c *Client) Price(path ...string) (Price, error) {
+	var p Price
+	return p, c.do("GET", c.path("prices", path...), nil, &p)
+}
+
+func (c *Client) SetPrice(path []string, p Price) (Price, error) {
+	var out Price
+	return out, c.do("PUT", c.path("prices", path...), p, &out)
+}
+
+// Products returns the products directly in the category at path.
+func (c *Client) Products(path ...string) ([]Product, error) {
+	var products []Product
+	q := url.Values{"category": {strings.Join(path, "/")}}
+	return products, c.do("GET", "/products?"+q.Encode(), nil, &products)
+}
+
+func (c *Client) Product(path []string, title string) (Product, error) {
+	var p Product
+	return p, c.do("GET", c.path("products", append(path[:len(path):len(path)], title)...), nil, &p)
+}
+
+// CreateProduct adds a product to the category at p.Path.  It needs an
+// image.
+func (c *Client) CreateProduct(p Product) (Product, error) {
+	var out Product
+	return out, c.do("POST", c.path("products", p.Path...), p, &out)
+}
+
+// UpdateProduct saves p, which is in the category at path now and is
+// moved to p.Path if that is different.
+func (c *Client) UpdateProduct(path []string, p Product) (Product, error) {
+	var out Product
+	return out, c.do("PUT", c.path("products", append(path[:len(path):len(path)], p.Title)...), p, &out)
+}
+
+func (c *Client) DeleteProduct(path []string, title string) error {
+	return c.do("DELETE", c.path("products", append(path[:len(path):len(path)], title)...), nil, nil)
+}
+
+func (c *Client) Blogs() ([]Blog, error) {
+	var blogs []Blog
+	return blogs, c.do("GET", "/blogs", nil, &blogs)
+}
+
+func (c *Client) Blog(id string) (Blog, error) {
+	var b Blog
+	return b, c.do("GET", c.path("blogs", id), nil, &b)
+}
+
+func (c *Client) CreateBlog(b Blog) (Blog, error) {
+	var out Blog
+	return out, c.do("POST", "/blogs", b, &out)
+}
+
+// UpdateBlog saves b over the blog with the given id.  The returned blog
+// has a new id if the title or date changed.
+func (c *Client) UpdateBlog(id string, b Blog) (Blog, error) {
+	var out Blog
+	return out, c.do("PUT", c.path("blogs", id), b, &out)
+}
+
+func (c *Client) DeleteBlog(id string) error {
+	return c.do("DELETE", c.path("blogs", id), nil, nil)
+}
+
+// path escapes each part since category and product names can have
+// spaces in them.
+func (c *Client) path(resource string, parts ...string) string {
+	out := []string{resource}
+	for _, p := range parts {
+		out = append(out, url.PathEscape(p))
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+func (c *Client) do(method, pth string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		d, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(d)
+	}
+
+	req, err := http.NewRequest(method, c.addr+"/api/v1"+pth, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.key)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		e := &Error{Status: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(e); err != nil || e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		return e
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("couldn't read %s %s: %s", method, pth, err)
+	}
+	return nil
+}
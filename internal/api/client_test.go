@@ -0,0 +1,74 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cswank/store/internal/api"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+
+	var (
+		ts     *httptest.Server
+		cli    *api.Client
+		status int
+		resp   interface{}
+		req    *http.Request
+		body   map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		body = nil
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req = r
+			json.NewDecoder(r.Body).Decode(&body)
+			w.WriteHeader(status)
+			if resp != nil {
+				json.NewEncoder(w).Encode(resp)
+			}
+		}))
+		cli = api.NewClient(ts.URL+"/", "abc.123")
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	It("sends the api key and escapes names", func() {
+		resp = api.Category{Name: "Happy Birthday", Path: []string{"Cards", "Happy Birthday"}, Products: []string{"Cake"}}
+		c, err := cli.Category("Cards", "Happy Birthday")
+		Expect(err).To(BeNil())
+		Expect(c.Products).To(Equal([]string{"Cake"}))
+		Expect(req.Header.Get("Authorization")).To(Equal("Bearer abc.123"))
+		Expect(req.URL.EscapedPath()).To(Equal("/api/v1/categories/Cards/Happy%20Birthday"))
+	})
+
+	It("sends a body", func() {
+		status = http.StatusNoContent
+		resp = nil
+		Expect(cli.RenameCategory([]string{"Cards"}, "Notes")).To(BeNil())
+		Expect(req.Method).To(Equal("PUT"))
+		Expect(body).To(HaveKeyWithValue("name", "Notes"))
+	})
+
+	It("asks for the products in a nested category", func() {
+		resp = []api.Product{{Title: "Cake", Path: []string{"Cards", "Happy Birthday"}}}
+		products, err := cli.Products("Cards", "Happy Birthday")
+		Expect(err).To(BeNil())
+		Expect(products).To(HaveLen(1))
+		Expect(req.URL.Path).To(Equal("/api/v1/products"))
+		Expect(req.URL.Query().Get("category")).To(Equal("Cards/Happy Birthday"))
+	})
+
+	It("returns the api's error", func() {
+		status = http.StatusForbidden
+		resp = api.Error{Message: "api key doesn't have the scope for this"}
+		err := cli.DeleteBlog("2018-01-02:Hi")
+		Expect(err).To(Equal(&api.Error{Status: http.StatusForbidden, Message: "api key doesn't have the scope for this"}))
+	})
+})
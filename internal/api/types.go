@@ -0,0 +1,61 @@
+// Package api has the types sent to and from /api/v1 and a client for
+// it.
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+type Price struct {
+	Price          string `json:"price"`
+	WholesalePrice string `json:"wholesale_price"`
+	Currency       string `json:"currency,omitempty"`
+}
+
+// Category is a node in the category tree.  Path is the names of its
+// parents and its own name.  Children and Products are only filled in
+// when asking for one category.
+type Category struct {
+	Name     string   `json:"name"`
+	Path     []string `json:"path,omitempty"`
+	Price    Price    `json:"price"`
+	Children []string `json:"children,omitempty"`
+	Products []string `json:"products,omitempty"`
+}
+
+// Product is a product along with the fields that are normally only part
+// of its key.  Path is the category it is in.  Image is a base64 encoded png and is only read, never
+// written.
+type Product struct {
+	Title       string   `json:"title"`
+	Path        []string `json:"path"`
+	Description string   `json:"description"`
+	Weight      int      `json:"weight"`
+	ShopifyID   string   `json:"shopify_id,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	SoldOut     bool     `json:"sold_out,omitempty"`
+	Image       string   `json:"image,omitempty"`
+}
+
+// Blog is a blog along with its key.  Image is a base64 encoded png and
+// is only read, never written.
+type Blog struct {
+	ID    string    `json:"id"`
+	Title string    `json:"title"`
+	Date  time.Time `json:"date"`
+	Body  string    `json:"body"`
+	HTML  string    `json:"html,omitempty"` //the body rendered from markdown, ignored when sent
+	Tags  []string  `json:"tags,omitempty"`
+	Image string    `json:"image,omitempty"`
+}
+
+// Error is what the api sends back when a request fails.
+type Error struct {
+	Status  int    `json:"-"`
+	Message string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.Status, e.Message)
+}
@@ -1,24 +1,34 @@
 package config
 
+import "time"
+
 type Config struct {
-	DataDir           string   `env:"STORE_DATADIR" envDefault:"/var/log/store"`
-	DefaultPrice      string   `env:"STORE_DEFAULT_PRICE" envDefault:"0.00"`
-	DiscountCode      string   `env:"STORE_DISCOUNT_CODE" envDefault:""`
-	Domains           []string `env:"STORE_DOMAINS" envDefault:"127.0.0.1"`
-	Email             string   `env:"STORE_EMAIL" envDefault:""`
-	EmailPassword     string   `env:"STORE_EMAIL_PASSWORD" envDefault:""`
-	HashKey           string   `env:"STORE_HASH_KEY" envDefault:"we all live in a"`
-	Iface             string   `env:"STORE_IFACE" envDefault:"127.0.0.1"`
-	LetsEncrypt       bool     `env:"STORE_LETS_ENCRYPT" envDefault:"false"`
-	LogOutput         string   `env:"STORE_LOGOUTPUT" envDefault:"stdout"`
-	Name              string   `env:"STORE_NAME" envDefault:"store"`
-	Port              int      `env:"STORE_PORT" envDefault:"8080"`
-	ShoppingMenu      string   `env:"STORE_SHOPPING_MENU" envDefault:"menu.js"`
-	TLS               bool     `env:"STORE_TLS" envDefault:"false"`
-	TLSCerts          string   `env:"STORE_TLS_CERTS" envDefault:"$HOME/.store/certs"`
-	UnderConstruction bool     `env:"STORE_UNDER_CONSTRUCTION" envDefault:"false"`
-	WholesalePrice    string   `env:"STORE_WHOLESALE_PRICE" envDefault:"0.00"`
-	BlockKey          string   `env:"STORE_BLOCK_KEY" envDefault:"yellow submarine"`
+	AdminTwoFactor     bool          `env:"STORE_ADMIN_2FA" envDefault:"false"`
+	Currency           string        `env:"STORE_CURRENCY" envDefault:"USD"`
+	CurrencySymbol     string        `env:"STORE_CURRENCY_SYMBOL" envDefault:"$"`
+	DataDir            string        `env:"STORE_DATADIR" envDefault:"/var/log/store"`
+	DefaultPrice       string        `env:"STORE_DEFAULT_PRICE" envDefault:"0.00"`
+	DefaultWeight      int           `env:"STORE_DEFAULT_WEIGHT" envDefault:"0"`
+	DiscountCode       string        `env:"STORE_DISCOUNT_CODE" envDefault:""`
+	Domains            []string      `env:"STORE_DOMAINS" envDefault:"127.0.0.1"`
+	Email              string        `env:"STORE_EMAIL" envDefault:""`
+	EmailPassword      string        `env:"STORE_EMAIL_PASSWORD" envDefault:""`
+	HashKey            string        `env:"STORE_HASH_KEY" envDefault:"we all live in a"`
+	Iface              string        `env:"STORE_IFACE" envDefault:"127.0.0.1"`
+	LetsEncrypt        bool          `env:"STORE_LETS_ENCRYPT" envDefault:"false"`
+	LoginBackoff       time.Duration `env:"STORE_LOGIN_BACKOFF" envDefault:"1s"`
+	LoginLockout       time.Duration `env:"STORE_LOGIN_LOCKOUT" envDefault:"1h"`
+	LoginMaxAttempts   int           `env:"STORE_LOGIN_MAX_ATTEMPTS" envDefault:"10"`
+	LogOutput          string        `env:"STORE_LOGOUTPUT" envDefault:"stdout"`
+	Name               string        `env:"STORE_NAME" envDefault:"store"`
+	Port               int           `env:"STORE_PORT" envDefault:"8080"`
+	SessionIdleTimeout time.Duration `env:"STORE_SESSION_IDLE_TIMEOUT" envDefault:"72h"`
+	SessionMaxAge      time.Duration `env:"STORE_SESSION_MAX_AGE" envDefault:"720h"`
+	TLS                bool          `env:"STORE_TLS" envDefault:"false"`
+	TLSCerts           string        `env:"STORE_TLS_CERTS" envDefault:"$HOME/.store/certs"`
+	UnderConstruction  bool          `env:"STORE_UNDER_CONSTRUCTION" envDefault:"false"`
+	WholesalePrice     string        `env:"STORE_WHOLESALE_PRICE" envDefault:"0.00"`
+	BlockKey           string        `env:"STORE_BLOCK_KEY" envDefault:"yellow submarine"`
 
 	RecaptchaSiteKey   string `env:"RECAPTCHA_SITE_KEY" envDefault:"yellow submarine"`
 	RecaptchaURL       string `env:"RECAPTCHA_URL" envDefault:"yellow submarine"`
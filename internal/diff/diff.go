@@ -0,0 +1,90 @@
+// Package diff compares two versions of a text line by line, for the
+// revision history pages.
+package diff
+
+import "strings"
+
+// Op is what happened to a line.
+type Op string
+
+const (
+	Same    Op = "same"
+	Added   Op = "added"
+	Removed Op = "removed"
+)
+
+// Line is one line of a diff.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns the lines it takes to turn a into b: the ones they share,
+// the ones only in a (removed) and the ones only in b (added), in order.
+// It finds the longest common subsequence, which is fine for texts the
+// size of a product description or a blog.
+func Lines(a, b string) []Line {
+	x, y := split(a), split(b)
+
+	//lcs[i][j] is the length of the longest common subsequence of
+	//x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []Line
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			out = append(out, Line{Op: Same, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, Line{Op: Removed, Text: x[i]})
+			i++
+		default:
+			out = append(out, Line{Op: Added, Text: y[j]})
+			j++
+		}
+	}
+
+	for ; i < len(x); i++ {
+		out = append(out, Line{Op: Removed, Text: x[i]})
+	}
+
+	for ; j < len(y); j++ {
+		out = append(out, Line{Op: Added, Text: y[j]})
+	}
+	return out
+}
+
+// Changed is true if any of the lines were added or removed.
+func Changed(lines []Line) bool {
+	for _, l := range lines {
+		if l.Op != Same {
+			return true
+		}
+	}
+	return false
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+}
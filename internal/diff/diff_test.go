@@ -0,0 +1,51 @@
+package diff_test
+
+import (
+	"github.com/cswank/store/internal/diff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("diff", func() {
+
+	It("keeps the lines that are the same", func() {
+		lines := diff.Lines("a\nb", "a\nb")
+		Expect(lines).To(Equal([]diff.Line{
+			{Op: diff.Same, Text: "a"},
+			{Op: diff.Same, Text: "b"},
+		}))
+		Expect(diff.Changed(lines)).To(BeFalse())
+	})
+
+	It("finds changed lines", func() {
+		lines := diff.Lines("title: cake\nweight: 10\ntags: a", "title: cake\nweight: 12\ntags: a")
+		Expect(lines).To(Equal([]diff.Line{
+			{Op: diff.Same, Text: "title: cake"},
+			{Op: diff.Removed, Text: "weight: 10"},
+			{Op: diff.Added, Text: "weight: 12"},
+			{Op: diff.Same, Text: "tags: a"},
+		}))
+		Expect(diff.Changed(lines)).To(BeTrue())
+	})
+
+	It("handles a text that was wiped", func() {
+		Expect(diff.Lines("one\ntwo", "")).To(Equal([]diff.Line{
+			{Op: diff.Removed, Text: "one"},
+			{Op: diff.Removed, Text: "two"},
+		}))
+	})
+
+	It("handles a text that is new", func() {
+		Expect(diff.Lines("", "one")).To(Equal([]diff.Line{
+			{Op: diff.Added, Text: "one"},
+		}))
+	})
+
+	It("lines up inserted lines", func() {
+		Expect(diff.Lines("a\nc", "a\nb\nc")).To(Equal([]diff.Line{
+			{Op: diff.Same, Text: "a"},
+			{Op: diff.Added, Text: "b"},
+			{Op: diff.Same, Text: "c"},
+		}))
+	})
+})
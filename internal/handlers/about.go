@@ -4,6 +4,7 @@ import (
 	"html/template"
 	"net/http"
 
+	"github.com/cswank/store/internal/store"
 	"github.com/cswank/store/internal/templates"
 )
 
@@ -13,13 +14,19 @@ type aboutPage struct {
 }
 
 func About(w http.ResponseWriter, req *http.Request) error {
+	about, err := getPageHTML(store.AboutPage)
+	if err != nil {
+		return err
+	}
+
 	p := aboutPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Name:  cfg.Name,
 			Head:  html["head"],
 		},
-		Body: html["about"],
+		Body: about,
 	}
 
 	return templates.Get("about.html").ExecuteTemplate(w, "base", p)
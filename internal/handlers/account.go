@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cswank/store/internal/email"
+	"github.com/cswank/store/internal/shopify"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+type accountPage struct {
+	page
+	Customer *store.User
+	Orders   []shopify.Order
+	Error    string
+}
+
+// Account shows a retail customer their addresses and the orders they
+// have placed through the Shopify checkout.
+func Account(w http.ResponseWriter, req *http.Request) error {
+	//logging in takes customers to their account
+	if !Customer(req) {
+		w.Header().Set("Location", "/login")
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	u := getUser(req)
+	p := accountPage{
+		page: page{
+			CSRF:    csrfToken(req),
+			Links:   getNavbarLinks(req),
+			Shopify: shopifyKey,
+			Name:    name,
+			Head:    html["head"],
+			Message: req.URL.Query().Get("message"),
+		},
+		Customer: u,
+	}
+
+	orders, err := shopify.Orders(u.Email)
+	if err != nil {
+		lg.Println("couldn't get orders for", u.Email, err)
+		p.Error = "Your order history is unavailable right now."
+	}
+	p.Orders = orders
+
+	return templates.Get("account/account.html").ExecuteTemplate(w, "base", p)
+}
+
+// accountForm is the part of a store.User a customer is allowed to change.
+type accountForm struct {
+	FirstName       string        `schema:"first_name"`
+	LastName        string        `schema:"last_name"`
+	Address         store.Address `schema:"address"`
+	ShippingAddress store.Address `schema:"shipping_address"`
+}
+
+func AccountUpdate(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	var f accountForm
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	if err := dec.Decode(&f, req.PostForm); err != nil {
+		return err
+	}
+
+	u := getUser(req)
+	if err := u.Fetch(); err != nil {
+		return err
+	}
+
+	u.FirstName = f.FirstName
+	u.LastName = f.LastName
+	u.Address = f.Address
+	u.ShippingAddress = f.ShippingAddress
+	if err := u.Save(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/account?message=Your account has been updated.")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func AccountRegistration(w http.ResponseWriter, req *http.Request) error {
+	p := loginPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Captcha:        true,
+		CaptchaSiteKey: cfg.RecaptchaSiteKey,
+		Error:          req.URL.Query().Get("error"),
+	}
+
+	return templates.Get("account/register.html").ExecuteTemplate(w, "base", p)
+}
+
+// registrationForm is everything a new customer gets to fill in.
+type registrationForm struct {
+	Email     string `schema:"email"`
+	FirstName string `schema:"first_name"`
+	LastName  string `schema:"last_name"`
+	Password  string `schema:"password"`
+	Password2 string `schema:"confirm-password"`
+}
+
+func AccountRegister(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	var f registrationForm
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	if err := dec.Decode(&f, req.PostForm); err != nil {
+		return err
+	}
+
+	if f.Email == "" {
+		return registrationError(w, "An email address is required.")
+	}
+
+	u := store.User{
+		Email:      f.Email,
+		FirstName:  f.FirstName,
+		LastName:   f.LastName,
+		Password:   f.Password,
+		Password2:  f.Password2,
+		Permission: store.Customer,
+	}
+
+	//someone who never verified can register again
+	existing := store.User{Email: u.Email}
+	err := existing.Fetch()
+	if err == nil && (existing.Permission != store.Customer || existing.Verified) {
+		return registrationError(w, "An account with that email address already exists.")
+	} else if err != nil && err != store.ErrNotFound {
+		return err
+	}
+
+	token, row, err := u.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	if err := u.Save(row); err != nil {
+		return registrationError(w, err.Error())
+	}
+
+	msg := email.Msg{
+		To:      u.Email,
+		From:    cfg.Email,
+		Subject: fmt.Sprintf("Please verify your %s account", cfg.Domains[0]),
+		Body:    getAccountVerificationBody(u, token),
+	}
+
+	if err := email.Send(msg); err != nil {
+		return err
+	}
+
+	p := page{
+		CSRF:    csrfToken(req),
+		Links:   getNavbarLinks(req),
+		Name:    name,
+		Head:    html["head"],
+		Message: fmt.Sprintf("Thank you.  We sent an email to %s, please click on the link in it to finish creating your account.", u.Email),
+	}
+
+	return templates.Get("wholesale/pending.html").ExecuteTemplate(w, "base", p)
+}
+
+func registrationError(w http.ResponseWriter, msg string) error {
+	w.Header().Set("Location", fmt.Sprintf("/account/register?error=%s", url.QueryEscape(msg)))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func getAccountVerificationBody(u store.User, token string) string {
+	tmpl := `Hello %s,
+Thank you for creating an account at %s.  Please click on this link in
+order to verify your email address.
+
+https://%s/account/verify/%s
+
+Thanks!
+
+%s`
+
+	return fmt.Sprintf(tmpl, u.FirstName, cfg.Domains[0], cfg.Domains[0], token, cfg.Email)
+}
+
+func AccountVerify(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+
+	p := page{
+		CSRF:  csrfToken(req),
+		Links: getNavbarLinks(req),
+		Name:  name,
+		Head:  html["head"],
+	}
+
+	u, err := store.VerifyUser(vars["token"])
+	if err != nil {
+		lg.Printf("failed to verify customer %s with token %s, err: %v\n", u.Email, vars["token"], err)
+		p.Message = "We were unable to confirm your email address.  If you registered more than 7 days ago the link has expired and you will have to register again."
+	} else {
+		p.Message = "Thank you.  Your email address has been confirmed and you can now log in."
+	}
+
+	return templates.Get("wholesale/pending.html").ExecuteTemplate(w, "base", p)
+}
@@ -44,23 +44,78 @@ func And(acls ...ACL) ACL {
 	}
 }
 
+// Admin is an admin that has set up two factor login, if
+// STORE_ADMIN_2FA says they have to.
 func Admin(req *http.Request) bool {
 	user := getUser(req)
-	return isAdmin(user)
+	return isAdmin(user) && twoFactorDone(user)
+}
+
+// Can is for routes that need capability c.  Admins can do everything,
+// anyone else needs a role that includes it.  Like admins, they have to
+// have set up two factor login if STORE_ADMIN_2FA says so.
+func Can(c store.Capability) ACL {
+	return func(req *http.Request) bool {
+		if Admin(req) {
+			return true
+		}
+
+		user := getUser(req)
+		return user != nil && !isAdmin(user) && user.Can(c) && twoFactorDone(user)
+	}
+}
+
+// Staff is an admin or anyone with a role, i.e. someone that gets to see
+// the admin pages.
+func Staff(req *http.Request) bool {
+	user := getUser(req)
+	return isStaff(user) && twoFactorDone(user)
+}
+
+// StaffAccount is anyone that Staff would be, even one that still has
+// to set up two factor login.
+func StaffAccount(req *http.Request) bool {
+	return isStaff(getUser(req))
 }
 
 func isAdmin(user *store.User) bool {
 	return user != nil && user.Permission == store.Admin
 }
 
+func isStaff(user *store.User) bool {
+	return isAdmin(user) || (user != nil && len(user.Roles) > 0)
+}
+
+// twoFactorDone is false for staff that STORE_ADMIN_2FA says have to set
+// up two factor login and haven't yet.
+func twoFactorDone(user *store.User) bool {
+	return !cfg.AdminTwoFactor || user.TwoFactor()
+}
+
 func Wholesaler(req *http.Request) bool {
 	user := getUser(req)
-	return user != nil && user.Permission >= store.Wholesaler && user.Confirmed && user.Verified
+	return isWholesaler(user) && user.Confirmed && user.Verified
 }
 
 func NewWholesaler(req *http.Request) bool {
 	user := getUser(req)
-	return user != nil && user.Permission >= store.Wholesaler
+	return isWholesaler(user)
+}
+
+// isWholesaler can't compare with >= since store.Customer sorts after
+// store.Admin.
+func isWholesaler(user *store.User) bool {
+	return user != nil && (user.Permission == store.Wholesaler || user.Permission == store.Admin)
+}
+
+// Customer is a retail shopper that has verified their email address.
+func Customer(req *http.Request) bool {
+	user := getUser(req)
+	return isCustomer(user) && user.Verified
+}
+
+func isCustomer(user *store.User) bool {
+	return user != nil && user.Permission == store.Customer
 }
 
 func Read(req *http.Request) bool {
@@ -88,21 +143,7 @@ func Perm(f ACL) alice.Constructor {
 // CheckIPWhitelist makes sure the provided remote address (of the form IP:port) falls within the provided IP range
 // (in CIDR form or a single IP address).
 func IPWhitelist(req *http.Request) bool {
-	// Extract IP address from remote address.
-	ip := req.RemoteAddr
-
-	if strings.LastIndex(ip, ":") != -1 {
-		ip = ip[0:strings.LastIndex(ip, ":")]
-	}
-
-	ip = strings.TrimSpace(ip)
-
-	// IPv6 addresses will likely be surrounded by [], so don't forget to remove those.
-	if strings.HasPrefix(ip, "[") && strings.HasSuffix(ip, "]") {
-		ip = ip[1 : len(ip)-1]
-	}
-
-	parsedIP := net.ParseIP(strings.TrimSpace(ip))
+	parsedIP := net.ParseIP(remoteIP(req))
 
 	if parsedIP == nil {
 		return false
@@ -123,6 +164,24 @@ func IPWhitelist(req *http.Request) bool {
 	return cidr.Contains(parsedIP)
 }
 
+// remoteIP strips the port from the request's remote address.
+func remoteIP(req *http.Request) string {
+	ip := req.RemoteAddr
+
+	if strings.LastIndex(ip, ":") != -1 {
+		ip = ip[0:strings.LastIndex(ip, ":")]
+	}
+
+	ip = strings.TrimSpace(ip)
+
+	// IPv6 addresses will likely be surrounded by [], so don't forget to remove those.
+	if strings.HasPrefix(ip, "[") && strings.HasSuffix(ip, "]") {
+		ip = ip[1 : len(ip)-1]
+	}
+
+	return strings.TrimSpace(ip)
+}
+
 func Human(req *http.Request) bool {
 	//need to re-use the body further down the middleware chain
 	d, _ := ioutil.ReadAll(req.Body)
@@ -3,9 +3,12 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cswank/store/internal/email"
+	"github.com/cswank/store/internal/money"
 	"github.com/cswank/store/internal/store"
 	"github.com/cswank/store/internal/templates"
 	"github.com/gorilla/mux"
@@ -27,8 +30,12 @@ type adminPage struct {
 	ProductTitle     string
 	BackgroundImages []string
 	Items            []string
+	Products         []string
+	ProductURI       string
+	PriceURI         string
+	OrderURI         string
+	Category         string
 	AdminLinks       []link
-	Subcategories    []string
 	Price            store.Price
 }
 
@@ -44,6 +51,7 @@ func AdminPage(w http.ResponseWriter, req *http.Request) error {
 
 	p := adminPage{
 		page: page{
+			CSRF:    csrfToken(req),
 			Admin:   Admin(req),
 			Links:   getNavbarLinks(req),
 			Shopify: shopifyKey,
@@ -52,6 +60,7 @@ func AdminPage(w http.ResponseWriter, req *http.Request) error {
 		},
 		Items:       categories,
 		URI:         "/admin/categories",
+		OrderURI:    "/admin/order",
 		From:        "/admin",
 		Placeholder: "new category",
 		AdminLinks:  []link{{Name: "Categories", Link: "/admin"}},
@@ -66,8 +75,10 @@ type category struct {
 	WholesalePrice string `schema:"WholesalePrice"`
 }
 
+// AddCategory adds a top level category, or a category to the one at
+// the path.
 func AddCategory(w http.ResponseWriter, req *http.Request) error {
-	cat, _, _ := getVars(req)
+	pth := getPath(req)
 
 	if err := req.ParseMultipartForm(32 << 20); err != nil {
 		return err
@@ -80,20 +91,31 @@ func AddCategory(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
-	if strings.Contains(c.Name, "/") {
-		return fmt.Errorf("illegal character (/)")
+	if err := checkCategoryName(pth, c.Name); err != nil {
+		return err
 	}
 
-	if cat == "" {
-		if err := store.AddCategory(c.Name, c.Price, c.WholesalePrice); err != nil {
+	if len(pth) == 0 {
+		price, err := getCategoryPrice(c.Price, c.WholesalePrice, cfg.Currency)
+		if err != nil {
+			return err
+		}
+
+		err = audited(req, "category.create", c.Name, nil, price, func() error {
+			return store.AddCategory(c.Name, price)
+		})
+		if err != nil {
 			return err
 		}
 	} else {
-		if err := store.AddSubcategory(cat, c.Name); err != nil {
+		err := audited(req, "category.create", pathName(append(pth, c.Name)), nil, nil, func() error {
+			return store.AddSubcategory(pth, c.Name)
+		})
+		if err != nil {
 			return err
 		}
-		makeNavbarLinks()
 	}
+	makeNavbarLinks()
 
 	from := req.URL.Query().Get("from")
 	if from == "" {
@@ -105,22 +127,55 @@ func AddCategory(w http.ResponseWriter, req *http.Request) error {
 	return nil
 }
 
+// adminLink is the admin page for the category or product at pth.
+func adminLink(pth []string) string {
+	return "/admin/categories/" + pathName(pth)
+}
+
+// getAdminLinks links to the admin pages of each category on the way to
+// the one at pth.
+func getAdminLinks(pth []string) []link {
+	l := []link{{Name: "Categories", Link: "/admin"}}
+	for i, n := range pth {
+		l = append(l, link{Name: n, Link: adminLink(pth[:i+1])})
+	}
+	return l
+}
+
+// AdminCategoryPage shows the category at the path, or the product if
+// the path ends with a product's title.
 func AdminCategoryPage(w http.ResponseWriter, req *http.Request) error {
-	cat, _, _ := getVars(req)
+	pth := getPath(req)
+	if ok, err := store.IsCategory(pth); err != nil {
+		return err
+	} else if !ok {
+		return AdminProductPage(w, req)
+	}
 
-	price, err := store.GetPrice(cat)
+	price, err := store.GetPrice(pth)
 	if err != nil {
 		return err
 	}
 
-	subcats, err := store.GetSubCategories(cat)
+	subcats, err := store.GetSubCategories(pth...)
 	if err != nil {
 		return err
 	}
 
-	from := fmt.Sprintf("/admin/categories/%s", cat)
+	prods, err := store.GetProducts(pth)
+	if err != nil {
+		return err
+	}
+
+	titles := make([]string, len(prods))
+	for i, p := range prods {
+		titles[i] = p.Title
+	}
+
+	from := adminLink(pth)
 	p := adminPage{
 		page: page{
+			CSRF:    csrfToken(req),
 			Admin:   Admin(req),
 			Links:   getNavbarLinks(req),
 			Shopify: shopifyKey,
@@ -128,100 +183,169 @@ func AdminCategoryPage(w http.ResponseWriter, req *http.Request) error {
 			Head:    html["head"],
 		},
 		Items:        subcats,
+		Products:     titles,
 		From:         from,
-		URI:          fmt.Sprintf("/admin/categories/%s/subcategories", cat),
-		Resource:     fmt.Sprintf("/admin/categories/%s", cat),
-		ResourceName: cat,
+		URI:          "/admin/subcategories/" + pathName(pth),
+		ProductURI:   "/admin/products/" + pathName(pth),
+		PriceURI:     "/admin/prices/" + pathName(pth),
+		OrderURI:     "/admin/order/" + pathName(pth),
+		Resource:     from,
+		ResourceName: pth[len(pth)-1],
 		Placeholder:  "new sub-category",
-		AdminLinks: []link{
-			{Name: "Categories", Link: "/admin"},
-			{Name: cat, Link: from},
-		},
-		Price: price,
+		AdminLinks:   getAdminLinks(pth),
+		Price:        price,
 	}
 	return templates.Get("admin/category.html").ExecuteTemplate(w, "base", p)
 }
 
-func RenameCategory(w http.ResponseWriter, req *http.Request) error {
-	cat, _, _ := getVars(req)
+// UpdateCategory renames the category at the path, or updates the
+// product if the path ends with a product's title.
+func UpdateCategory(w http.ResponseWriter, req *http.Request) error {
+	pth := getPath(req)
+	if ok, err := store.IsCategory(pth); err != nil {
+		return err
+	} else if !ok {
+		return UpdateProduct(w, req)
+	}
 
 	if err := req.ParseForm(); err != nil {
 		return err
 	}
 
 	newName := req.FormValue("Name")
-	err := store.RenameCategory(cat, newName)
+	if err := checkCategoryName(pth[:len(pth)-1], newName); err != nil {
+		return err
+	}
+
+	renamed := append(append([]string{}, pth[:len(pth)-1]...), newName)
+	err := audited(req, "category.rename", pathName(renamed), pathName(pth), pathName(renamed), func() error {
+		return store.RenameCategory(pth, newName)
+	})
 	if err != nil {
 		return err
 	}
 
 	makeNavbarLinks()
-	l := fmt.Sprintf("/admin/categories/%s", newName)
-	w.Header().Set("Location", l)
+
+	w.Header().Set("Location", adminLink(renamed))
 	w.WriteHeader(http.StatusFound)
 	return nil
 }
 
+// DeleteCategory deletes the category at the path, or the product if the
+// path ends with a product's title.
 func DeleteCategory(w http.ResponseWriter, req *http.Request) error {
-	cat, _, _ := getVars(req)
+	pth := getPath(req)
+	if ok, err := store.IsCategory(pth); err != nil {
+		return err
+	} else if !ok {
+		return DeleteProduct(w, req)
+	}
 
-	err := store.DeleteCategory(cat)
+	err := audited(req, "category.delete", pathName(pth), pathName(pth), nil, func() error {
+		return store.DeleteCategory(pth)
+	})
 	if err != nil {
 		return err
 	}
 
 	makeNavbarLinks()
-	w.Header().Set("Location", fmt.Sprintf("/admin/categories"))
+
+	l := "/admin"
+	if len(pth) > 1 {
+		l = adminLink(pth[:len(pth)-1])
+	}
+	w.Header().Set("Location", l)
 	w.WriteHeader(http.StatusFound)
 	return nil
 }
 
-func UpdatePrice(w http.ResponseWriter, req *http.Request) error {
-	cat, _, _ := getVars(req)
+// UpdateOrder sets the order of the sub-categories and products in the
+// category at the path (or of the top level categories when there is no
+// path).  The admin pages send the names, in order, as Name.
+func UpdateOrder(w http.ResponseWriter, req *http.Request) error {
+	pth := getPath(req)
 
 	if err := req.ParseForm(); err != nil {
 		return err
 	}
 
-	var p store.Price
-	dec := schema.NewDecoder()
-	dec.IgnoreUnknownKeys(true)
-	if err := dec.Decode(&p, req.PostForm); err != nil {
+	if _, err := store.GetSubCategories(pth...); err != nil {
 		return err
 	}
 
-	if err := store.SetPrice(cat, p); err != nil {
+	before, err := store.GetOrder(pth)
+	if err != nil {
 		return err
 	}
 
-	l := fmt.Sprintf("/admin/categories/%s", cat)
-	w.Header().Set("Location", l)
-	w.WriteHeader(http.StatusFound)
+	order := req.PostForm["Name"]
+	err = audited(req, "category.order", pathName(pth), before, order, func() error {
+		return store.SetOrder(pth, order)
+	})
+	if err != nil {
+		return err
+	}
+
+	makeNavbarLinks()
+
+	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
 
-func RenameSubcategory(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, _ := getVars(req)
+func UpdatePrice(w http.ResponseWriter, req *http.Request) error {
+	pth := getPath(req)
 
 	if err := req.ParseForm(); err != nil {
 		return err
 	}
 
-	newName := req.FormValue("Name")
-	err := store.RenameSubcategory(cat, subcat, newName)
+	cur := req.PostFormValue("Currency")
+	if cur == "" {
+		cur = cfg.Currency
+	}
+
+	p, err := getCategoryPrice(req.PostFormValue("Price"), req.PostFormValue("WholesalePrice"), cur)
 	if err != nil {
 		return err
 	}
 
-	makeNavbarLinks()
-	l := fmt.Sprintf("/admin/categories/%s/subcategories/%s", cat, newName)
-	w.Header().Set("Location", l)
+	if _, err := store.GetSubCategories(pth...); err != nil {
+		return err
+	}
+
+	before, err := store.GetPrice(pth)
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "category.price", pathName(pth), before, p, func() error {
+		return store.SetPrice(pth, p)
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", adminLink(pth))
 	w.WriteHeader(http.StatusFound)
 	return nil
 }
 
+// getCategoryPrice parses the retail and wholesale prices entered on the
+// category forms.
+func getCategoryPrice(price, wholesale, currency string) (store.Price, error) {
+	p, err := money.Parse(price, currency)
+	if err != nil {
+		return store.Price{}, err
+	}
+
+	w, err := money.Parse(wholesale, currency)
+	return store.Price{Price: p, WholesalePrice: w}, err
+}
+
+// AddProduct adds a product to the category at the path.
 func AddProduct(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, _ := getVars(req)
+	pth := getPath(req)
 
 	if err := req.ParseMultipartForm(32 << 20); err != nil {
 		return err
@@ -235,69 +359,43 @@ func AddProduct(w http.ResponseWriter, req *http.Request) error {
 	name := req.FormValue("Name")
 	description := strings.Replace(req.FormValue("Description"), "\n", "", -1)
 
-	p := store.NewProduct(name, cat, subcat, store.ProductDescription(description))
-	err = p.Add(ff)
+	weight, err := getWeight(req)
 	if err != nil {
 		return err
 	}
 
-	l := fmt.Sprintf("/admin/categories/%s/subcategories/%s", cat, subcat)
-	w.Header().Set("Location", l)
-	w.WriteHeader(http.StatusFound)
-	return nil
-}
-
-func AdminAddProductPage(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, _ := getVars(req)
+	tags := store.ParseTags(req.FormValue("Tags"))
+	soldOut := req.FormValue("SoldOut") == "on"
 
-	products, err := store.GetProductTitles(cat, subcat)
+	status, at, err := getPublishing(req)
 	if err != nil {
 		return err
 	}
 
-	from := fmt.Sprintf("/admin/categories/%s/subcategories/%s", cat, subcat)
-	p := adminPage{
-		page: page{
-			Admin:   Admin(req),
-			Links:   getNavbarLinks(req),
-			Shopify: shopifyKey,
-			Name:    name,
-			Head:    html["head"],
-		},
-		Items:        products,
-		From:         from,
-		URI:          fmt.Sprintf("/admin/categories/%s/subcategories/%s/products", cat, subcat),
-		Resource:     fmt.Sprintf("/admin/categories/%s/subcategories/%s", cat, subcat),
-		ResourceName: subcat,
-		Placeholder:  "new product",
-		AdminLinks: []link{
-			{Name: "Categories", Link: "/admin"},
-			{Name: cat, Link: fmt.Sprintf("/admin/categories/%s", cat)},
-			{Name: subcat, Link: from},
-		},
-		IsProduct: true,
+	p := store.NewProduct(name, pth, store.ProductDescription(description), store.ProductWeight(weight), store.ProductTags(tags), store.ProductSoldOut(soldOut), store.ProductStatus(status, at))
+	p.EditedBy = actor(req)
+	err = audited(req, "product.create", productName(p), nil, auditProduct(p), func() error {
+		return p.Add(ff)
+	})
+	if err != nil {
+		return err
 	}
 
-	return templates.Get("admin/subcategory.html").ExecuteTemplate(w, "base", p)
+	w.Header().Set("Location", adminLink(pth))
+	w.WriteHeader(http.StatusFound)
+	return nil
 }
 
 func AdminProductPage(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, vars := getVars(req)
-
-	p := store.NewProduct(vars["title"], cat, subcat)
-	err := p.Fetch()
-	if err != nil {
-		return err
-	}
-
-	subs, err := store.GetSubCategories(p.Cat)
+	p, err := getProduct(req)
 	if err != nil {
 		return err
 	}
 
-	from := fmt.Sprintf("/admin/categories/%s/subcategories/%s/products/%s", p.Cat, p.Subcat, p.Title)
+	from := adminLink(append(append([]string{}, p.Path...), p.Title))
 	page := adminPage{
 		page: page{
+			CSRF:    csrfToken(req),
 			Admin:   Admin(req),
 			Links:   getNavbarLinks(req),
 			Shopify: shopifyKey,
@@ -307,24 +405,18 @@ func AdminProductPage(w http.ResponseWriter, req *http.Request) error {
 		From:        from,
 		URI:         from,
 		Placeholder: "new product",
-		AdminLinks: []link{
-			{Name: "Categories", Link: "/admin"},
-			{Name: p.Cat, Link: fmt.Sprintf("/admin/categories/%s", p.Cat)},
-			{Name: p.Subcat, Link: fmt.Sprintf("/admin/categories/%s/subcategories/%s", p.Cat, p.Subcat)},
-			{Name: p.Title, Link: from},
-		},
-		Product:       p,
-		Subcategories: subs,
+		AdminLinks:  append(getAdminLinks(p.Path), link{Name: p.Title, Link: from}),
+		Product:     p,
+		Category:    pathName(p.Path),
 	}
 
 	return templates.Get("admin/product.html").ExecuteTemplate(w, "base", page)
 }
 
+// UpdateProduct saves the product at the path.  It is moved if the
+// Category field names a different category.
 func UpdateProduct(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, vars := getVars(req)
-
-	p := store.NewProduct(vars["title"], cat, subcat)
-	err := p.Fetch()
+	p, err := getProduct(req)
 	if err != nil {
 		return err
 	}
@@ -341,38 +433,94 @@ func UpdateProduct(w http.ResponseWriter, req *http.Request) error {
 	title := req.FormValue("Title")
 	desc := req.FormValue("Description")
 
-	p2 := store.NewProduct(title, p.Cat, p.Subcat, store.ProductDescription(desc), store.ProductImage(f))
+	weight, err := getWeight(req)
+	if err != nil {
+		return err
+	}
 
-	if err := p.Update(p2); err != nil {
+	tags := store.ParseTags(req.FormValue("Tags"))
+	soldOut := req.FormValue("SoldOut") == "on"
+
+	status, at, err := getPublishing(req)
+	if err != nil {
 		return err
 	}
 
-	clearEtag(p.Title)
+	dst := p.Path
+	if c := strings.Trim(req.FormValue("Category"), "/"); c != "" {
+		dst = strings.Split(c, "/")
+		if ok, err := store.IsCategory(dst); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("there is no category %s", c)
+		}
+	}
+
+	p2 := store.NewProduct(title, dst, store.ProductDescription(desc), store.ProductWeight(weight), store.ProductImage(f), store.ProductTags(tags), store.ProductSoldOut(soldOut), store.ProductStatus(status, at))
+	p2.EditedBy = actor(req)
+
+	after := p.Merge(p2)
+	err = audited(req, "product.update", productName(&after), auditProduct(p), auditProduct(&after), func() error {
+		return p.Update(p2)
+	})
+	if err != nil {
+		return err
+	}
 
+	clearEtag(p.Title)
 	makeNavbarLinks()
-	l := fmt.Sprintf("/admin/categories/%s/subcategories/%s", p.Cat, p2.Subcat)
-	w.Header().Set("Location", l)
+	w.Header().Set("Location", adminLink(p.Path))
 	w.WriteHeader(http.StatusFound)
 
 	return nil
 }
 
 func DeleteProduct(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, vars := getVars(req)
-
-	p := store.NewProduct(vars["title"], cat, subcat)
-	if err := p.Fetch(); err != nil {
+	p, err := getProduct(req)
+	if err != nil {
 		return err
 	}
 
-	if err := p.Delete(); err != nil {
+	err = audited(req, "product.delete", productName(p), auditProduct(p), nil, func() error {
+		return p.Delete()
+	})
+	if err != nil {
 		return err
 	}
 
-	clearEtag(vars["title"])
+	clearEtag(p.Title)
 	return nil
 }
 
+// getPublishing reads the Status and PublishAt fields that the product
+// and blog forms share.  A scheduled product or blog needs a time.
+func getPublishing(req *http.Request) (store.Status, *time.Time, error) {
+	s, err := store.ParseStatus(req.FormValue("Status"))
+	if err != nil {
+		return s, nil, err
+	}
+
+	at, err := store.ParsePublishAt(req.FormValue("PublishAt"))
+	if err != nil {
+		return s, at, err
+	}
+
+	if s == store.Scheduled && at == nil {
+		return s, at, fmt.Errorf("a scheduled item needs a time to publish it")
+	}
+	return s, at, nil
+}
+
+// getWeight reads the product weight (grams) from the form, falling
+// back to the configured default when it is left blank.
+func getWeight(req *http.Request) (int, error) {
+	w := strings.TrimSpace(req.FormValue("Weight"))
+	if w == "" {
+		return cfg.DefaultWeight, nil
+	}
+	return strconv.Atoi(w)
+}
+
 type confirmPage struct {
 	page
 	Name     string
@@ -384,6 +532,7 @@ func Confirm(w http.ResponseWriter, req *http.Request) error {
 	name := args.Get("name")
 	resource := args.Get("resource")
 	p := confirmPage{
+		page:     page{CSRF: csrfToken(req)},
 		Name:     name,
 		Resource: resource,
 	}
@@ -410,6 +559,7 @@ func AdminWholesalers(w http.ResponseWriter, req *http.Request) error {
 
 	p := wholesalersAdminPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Admin: Admin(req),
 			Links: getNavbarLinks(req),
 			Name:  name,
@@ -434,6 +584,7 @@ func AdminWholesaler(w http.ResponseWriter, req *http.Request) error {
 
 	p := wholesalerAdminPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Admin: Admin(req),
 			Links: getNavbarLinks(req),
 			Name:  name,
@@ -445,6 +596,22 @@ func AdminWholesaler(w http.ResponseWriter, req *http.Request) error {
 	return templates.Get("admin/wholesaler.html").ExecuteTemplate(w, "base", p)
 }
 
+// wholesalerForm is the part of a wholesaler's account that staff can
+// change.  The address fields are flat, the way the form sends them.
+type wholesalerForm struct {
+	StoreName         string `schema:"store_name"`
+	Website           string `schema:"website"`
+	FirstName         string `schema:"first_name"`
+	LastName          string `schema:"last_name"`
+	Address           string `schema:"address"`
+	Address2          string `schema:"address2"`
+	Zip               string `schema:"zip"`
+	City              string `schema:"city"`
+	State             string `schema:"state"`
+	Country           string `schema:"country"`
+	ResaleCertificate string `schema:"resale_certificate"`
+}
+
 func AdminWholesalerUpdate(w http.ResponseWriter, req *http.Request) error {
 	wholesaler, err := getWholesaler(req)
 	if err != nil {
@@ -455,13 +622,32 @@ func AdminWholesalerUpdate(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
+	var f wholesalerForm
 	dec := schema.NewDecoder()
 	dec.IgnoreUnknownKeys(true)
-	if err := dec.Decode(&wholesaler, req.PostForm); err != nil {
+	if err := dec.Decode(&f, req.PostForm); err != nil {
 		return err
 	}
 
-	if err := wholesaler.Save(); err != nil {
+	before := auditUser(wholesaler)
+	wholesaler.StoreName = f.StoreName
+	wholesaler.Website = f.Website
+	wholesaler.FirstName = f.FirstName
+	wholesaler.LastName = f.LastName
+	wholesaler.Address = store.Address{
+		Address:  f.Address,
+		Address2: f.Address2,
+		Zip:      f.Zip,
+		City:     f.City,
+		State:    f.State,
+		Country:  f.Country,
+	}
+	wholesaler.ResaleCertificate = f.ResaleCertificate
+
+	err = audited(req, "wholesaler.update", wholesaler.Email, before, auditUser(wholesaler), func() error {
+		return wholesaler.Save()
+	})
+	if err != nil {
 		return err
 	}
 
@@ -476,7 +662,10 @@ func AdminWholesalerDelete(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
-	if err := wholesaler.Delete(); err != nil {
+	err = audited(req, "wholesaler.delete", wholesaler.Email, auditUser(wholesaler), nil, func() error {
+		return wholesaler.Delete()
+	})
+	if err != nil {
 		return err
 	}
 
@@ -485,28 +674,38 @@ func AdminWholesalerDelete(w http.ResponseWriter, req *http.Request) error {
 	return nil
 }
 
+// getWholesaler fetches the {wholesaler}.  Staff and customer accounts
+// aren't found, so the wholesaler routes can't change them.
 func getWholesaler(req *http.Request) (store.User, error) {
 	vars := mux.Vars(req)
 	wholesaler := store.User{Email: vars["wholesaler"]}
+	if err := wholesaler.Fetch(); err != nil {
+		return wholesaler, err
+	}
 
-	return wholesaler, wholesaler.Fetch()
+	if wholesaler.Permission != store.Wholesaler {
+		return store.User{}, store.ErrNotFound
+	}
+	return wholesaler, nil
 }
 
 func AdminWholesalerConfirm(w http.ResponseWriter, req *http.Request) error {
-	vars := mux.Vars(req)
-	wholesaler := store.User{Email: vars["wholesaler"]}
-
-	if err := req.ParseForm(); err != nil {
+	wholesaler, err := getWholesaler(req)
+	if err != nil {
 		return err
 	}
 
-	if err := wholesaler.Fetch(); err != nil {
+	if err := req.ParseForm(); err != nil {
 		return err
 	}
 
+	before := wholesaler.Confirmed
 	wholesaler.Confirmed = req.FormValue("confirmation") == "true"
 
-	if err := wholesaler.Save(); err != nil {
+	err = audited(req, "wholesaler.confirm", wholesaler.Email, before, wholesaler.Confirmed, func() error {
+		return wholesaler.Save()
+	})
+	if err != nil {
 		return err
 	}
 
@@ -0,0 +1,629 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cswank/store/internal/api"
+	"github.com/cswank/store/internal/store"
+	"github.com/gorilla/mux"
+	"github.com/justinas/alice"
+)
+
+// apiError is an error the caller of the api can do something about.
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e apiError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return apiError{status: http.StatusBadRequest, msg: fmt.Sprintf(format, args...)}
+}
+
+func newAPIPrice(p store.Price) api.Price {
+	return api.Price{
+		Price:          p.Price.String(),
+		WholesalePrice: p.WholesalePrice.String(),
+		Currency:       p.Price.Currency,
+	}
+}
+
+func newAPIProduct(p store.Product) api.Product {
+	return api.Product{
+		Title:       p.Title,
+		Path:        p.Path,
+		Description: p.Description,
+		Weight:      p.Weight,
+		ShopifyID:   p.ID,
+		Tags:        p.Tags,
+		SoldOut:     p.SoldOut,
+	}
+}
+
+// APIAuthentication looks up the api key sent as a bearer token.  It
+// takes the place of Authentication for /api/v1.
+func APIAuthentication(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if token != "" {
+			k, err := store.CheckAPIKey(token)
+			if err == nil {
+				ctx := context.WithValue(req.Context(), "apikey", &k)
+				req = req.WithContext(ctx)
+			} else if err != store.ErrInvalidAPIKey {
+				lg.Println("couldn't check api key", err)
+			}
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+func getAPIKey(req *http.Request) *store.APIKey {
+	k := req.Context().Value("apikey")
+	if k == nil {
+		return nil
+	}
+	return k.(*store.APIKey)
+}
+
+// APIRead is any api key that hasn't been revoked.
+func APIRead(req *http.Request) bool {
+	return getAPIKey(req) != nil
+}
+
+// APIScope is an api key that was given capability c.
+func APIScope(c store.Capability) ACL {
+	return func(req *http.Request) bool {
+		k := getAPIKey(req)
+		return k != nil && k.Can(c)
+	}
+}
+
+// APIPerm is Perm for the api.  It tells a missing key apart from one that
+// doesn't have the scope it needs.
+func APIPerm(f ACL) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if getAPIKey(req) == nil {
+				writeJSON(w, http.StatusUnauthorized, api.Error{Message: "missing or invalid api key"})
+				return
+			}
+
+			if !f(req) {
+				writeJSON(w, http.StatusForbidden, api.Error{Message: "api key doesn't have the scope for this"})
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+// HandleAPIErr is HandleErr for the api, errors go back as json.
+func HandleAPIErr(f HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		err := f(w, req)
+		if err == nil {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		msg := "internal server error"
+		if e, ok := err.(apiError); ok {
+			status = e.status
+			msg = e.msg
+		} else if err == store.ErrNotFound {
+			status = http.StatusNotFound
+			msg = "not found"
+		} else if err == store.ErrExists {
+			status = http.StatusConflict
+			msg = err.Error()
+		} else {
+			lg.Println("internal server err", req.URL.Path, err)
+		}
+
+		writeJSON(w, status, api.Error{Message: msg})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v == nil {
+		return nil
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+func readJSON(req *http.Request, v interface{}) error {
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil {
+		return badRequest("invalid json: %s", err)
+	}
+	return nil
+}
+
+// decodeImage turns a base64 png into something the store can read.  It
+// returns a nil io.Reader, not a nil *bytes.Reader, when there isn't one.
+func decodeImage(s string) (io.Reader, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	d, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, badRequest("image must be base64 encoded: %s", err)
+	}
+	return bytes.NewReader(d), nil
+}
+
+func newAPICategory(path []string) (api.Category, error) {
+	p, err := store.GetPrice(path)
+	if err != nil {
+		return api.Category{}, err
+	}
+
+	return api.Category{Name: path[len(path)-1], Path: path, Price: newAPIPrice(p)}, nil
+}
+
+func APICategories(w http.ResponseWriter, req *http.Request) error {
+	names, err := store.GetCategories()
+	if err != nil && err != store.ErrNotFound {
+		return err
+	}
+
+	cats := []api.Category{}
+	for _, name := range names {
+		c, err := newAPICategory([]string{name})
+		if err != nil {
+			return err
+		}
+		cats = append(cats, c)
+	}
+
+	return writeJSON(w, http.StatusOK, cats)
+}
+
+func APICategory(w http.ResponseWriter, req *http.Request) error {
+	path := getPath(req)
+
+	children, err := store.GetSubCategories(path...)
+	if err != nil {
+		return err
+	}
+
+	products, err := store.GetProducts(path)
+	if err != nil {
+		return err
+	}
+
+	c, err := newAPICategory(path)
+	if err != nil {
+		return err
+	}
+
+	c.Children = children
+	for _, p := range products {
+		c.Products = append(c.Products, p.Title)
+	}
+
+	return writeJSON(w, http.StatusOK, c)
+}
+
+// APICategoryCreate adds a top level category.
+func APICategoryCreate(w http.ResponseWriter, req *http.Request) error {
+	var c api.Category
+	if err := readJSON(req, &c); err != nil {
+		return err
+	}
+
+	if err := checkCategoryName(nil, c.Name); err != nil {
+		return badRequest("%s", err)
+	}
+
+	if c.Price.Currency == "" {
+		c.Price.Currency = cfg.Currency
+	}
+
+	price, err := getCategoryPrice(c.Price.Price, c.Price.WholesalePrice, c.Price.Currency)
+	if err != nil {
+		return badRequest("invalid price: %s", err)
+	}
+
+	err = audited(req, "category.create", c.Name, nil, price, func() error {
+		return store.AddCategory(c.Name, price)
+	})
+	if err != nil {
+		return err
+	}
+
+	makeNavbarLinks()
+
+	return writeJSON(w, http.StatusCreated, api.Category{Name: c.Name, Path: []string{c.Name}, Price: newAPIPrice(price)})
+}
+
+// APISubcategoryCreate adds a category to the one at the path.
+func APISubcategoryCreate(w http.ResponseWriter, req *http.Request) error {
+	path := getPath(req)
+
+	var c api.Category
+	if err := readJSON(req, &c); err != nil {
+		return err
+	}
+
+	if err := checkCategoryName(path, c.Name); err != nil {
+		return badRequest("%s", err)
+	}
+
+	err := audited(req, "category.create", pathName(append(append([]string{}, path...), c.Name)), nil, nil, func() error {
+		return store.AddSubcategory(path, c.Name)
+	})
+	if err != nil {
+		return err
+	}
+
+	path = append(path, c.Name)
+	makeNavbarLinks()
+
+	out, err := newAPICategory(path)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, out)
+}
+
+// APICategoryUpdate renames a category.
+func APICategoryUpdate(w http.ResponseWriter, req *http.Request) error {
+	path := getPath(req)
+
+	var c api.Category
+	if err := readJSON(req, &c); err != nil {
+		return err
+	}
+
+	if err := checkCategoryName(path[:len(path)-1], c.Name); err != nil {
+		return badRequest("%s", err)
+	}
+
+	renamed := append(append([]string{}, path[:len(path)-1]...), c.Name)
+	err := audited(req, "category.rename", pathName(renamed), pathName(path), pathName(renamed), func() error {
+		return store.RenameCategory(path, c.Name)
+	})
+	if err != nil {
+		return err
+	}
+
+	makeNavbarLinks()
+
+	return writeJSON(w, http.StatusOK, api.Category{Name: c.Name, Path: renamed})
+}
+
+func APICategoryDelete(w http.ResponseWriter, req *http.Request) error {
+	path := getPath(req)
+
+	err := audited(req, "category.delete", pathName(path), pathName(path), nil, func() error {
+		return store.DeleteCategory(path)
+	})
+	if err != nil {
+		return err
+	}
+
+	makeNavbarLinks()
+
+	return writeJSON(w, http.StatusNoContent, nil)
+}
+
+func APIPrice(w http.ResponseWriter, req *http.Request) error {
+	p, err := store.GetPrice(getPath(req))
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, newAPIPrice(p))
+}
+
+func APIPriceUpdate(w http.ResponseWriter, req *http.Request) error {
+	path := getPath(req)
+
+	var p api.Price
+	if err := readJSON(req, &p); err != nil {
+		return err
+	}
+
+	if p.Currency == "" {
+		p.Currency = cfg.Currency
+	}
+
+	price, err := getCategoryPrice(p.Price, p.WholesalePrice, p.Currency)
+	if err != nil {
+		return badRequest("invalid price: %s", err)
+	}
+
+	if _, err := store.GetSubCategories(path...); err != nil {
+		return err
+	}
+
+	before, err := store.GetPrice(path)
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "category.price", pathName(path), before, price, func() error {
+		return store.SetPrice(path, price)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, newAPIPrice(price))
+}
+
+// APIProducts lists the products in the category given by the category
+// arg, which is a path like Cards/Birthday.
+func APIProducts(w http.ResponseWriter, req *http.Request) error {
+	path := strings.Split(strings.Trim(req.URL.Query().Get("category"), "/"), "/")
+	if path[0] == "" {
+		return badRequest("the category arg is required")
+	}
+
+	products, err := store.GetProducts(path)
+	if err != nil {
+		return err
+	}
+
+	out := make([]api.Product, len(products))
+	for i, p := range products {
+		out[i] = newAPIProduct(p)
+	}
+
+	return writeJSON(w, http.StatusOK, out)
+}
+
+func APIProduct(w http.ResponseWriter, req *http.Request) error {
+	p, err := getProduct(req)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, newAPIProduct(*p))
+}
+
+// APIProductCreate adds a product to the category at the path.
+func APIProductCreate(w http.ResponseWriter, req *http.Request) error {
+	path := getPath(req)
+
+	var ap api.Product
+	if err := readJSON(req, &ap); err != nil {
+		return err
+	}
+
+	if ap.Title == "" || strings.Contains(ap.Title, "/") {
+		return badRequest("invalid product title %q", ap.Title)
+	}
+
+	img, err := decodeImage(ap.Image)
+	if err != nil {
+		return err
+	}
+
+	if img == nil {
+		return badRequest("a new product needs an image")
+	}
+
+	weight := ap.Weight
+	if weight == 0 {
+		weight = cfg.DefaultWeight
+	}
+
+	p := store.NewProduct(ap.Title, path, store.ProductDescription(ap.Description), store.ProductWeight(weight), store.ProductTags(store.ParseTags(strings.Join(ap.Tags, ","))), store.ProductSoldOut(ap.SoldOut))
+	p.EditedBy = actor(req)
+	err = audited(req, "product.create", productName(p), nil, auditProduct(p), func() error {
+		return p.Add(img)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusCreated, newAPIProduct(*p))
+}
+
+// APIProductUpdate changes a product's description, weight, category or
+// image.  Products can't be renamed yet.
+func APIProductUpdate(w http.ResponseWriter, req *http.Request) error {
+	p, err := getProduct(req)
+	if err != nil {
+		return err
+	}
+
+	ap := newAPIProduct(*p)
+	if err := readJSON(req, &ap); err != nil {
+		return err
+	}
+
+	if ap.Title != p.Title {
+		return badRequest("products can't be renamed")
+	}
+
+	if ok, err := store.IsCategory(ap.Path); err != nil {
+		return err
+	} else if !ok || len(ap.Path) == 0 {
+		return badRequest("there is no category %q", pathName(ap.Path))
+	}
+
+	img, err := decodeImage(ap.Image)
+	if err != nil {
+		return err
+	}
+
+	opts := []func(*store.Product){
+		store.ProductDescription(ap.Description),
+		store.ProductWeight(ap.Weight),
+		store.ProductTags(store.ParseTags(strings.Join(ap.Tags, ","))),
+		store.ProductSoldOut(ap.SoldOut),
+	}
+	if img != nil {
+		opts = append(opts, store.ProductImage(img))
+	}
+
+	p2 := store.NewProduct(p.Title, ap.Path, opts...)
+	p2.EditedBy = actor(req)
+
+	after := p.Merge(p2)
+	err = audited(req, "product.update", productName(&after), auditProduct(p), auditProduct(&after), func() error {
+		return p.Update(p2)
+	})
+	if err != nil {
+		return err
+	}
+
+	clearEtag(p.Title)
+	makeNavbarLinks()
+
+	return writeJSON(w, http.StatusOK, newAPIProduct(*p))
+}
+
+func APIProductDelete(w http.ResponseWriter, req *http.Request) error {
+	p, err := getProduct(req)
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "product.delete", productName(p), auditProduct(p), nil, func() error {
+		return p.Delete()
+	})
+	if err != nil {
+		return err
+	}
+
+	clearEtag(p.Title)
+
+	return writeJSON(w, http.StatusNoContent, nil)
+}
+
+func newAPIBlog(b store.Blog) api.Blog {
+	return api.Blog{
+		ID:    b.Key(),
+		Title: b.Title,
+		Date:  b.Date,
+		Body:  b.Body,
+		HTML:  b.Render(),
+		Tags:  b.Tags,
+	}
+}
+
+func APIBlogs(w http.ResponseWriter, req *http.Request) error {
+	keys, err := store.Blogs()
+	if err != nil {
+		return err
+	}
+
+	blogs := []api.Blog{}
+	for _, k := range keys {
+		b, err := store.GetBlog(k.ID)
+		if err != nil {
+			return err
+		}
+		blogs = append(blogs, newAPIBlog(b))
+	}
+
+	return writeJSON(w, http.StatusOK, blogs)
+}
+
+func APIBlog(w http.ResponseWriter, req *http.Request) error {
+	b, err := store.GetBlog(mux.Vars(req)["id"])
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, newAPIBlog(b))
+}
+
+// APIBlogCreate saves a new blog.  Like the admin page, the date is
+// always today.
+func APIBlogCreate(w http.ResponseWriter, req *http.Request) error {
+	var ab api.Blog
+	if err := readJSON(req, &ab); err != nil {
+		return err
+	}
+
+	if ab.Title == "" || strings.Contains(ab.Title, "/") {
+		return badRequest("invalid blog title %q", ab.Title)
+	}
+
+	img, err := decodeImage(ab.Image)
+	if err != nil {
+		return err
+	}
+
+	b := store.Blog{Title: ab.Title, Date: time.Now(), Body: ab.Body, Tags: store.ParseTags(strings.Join(ab.Tags, ",")), EditedBy: actor(req)}
+	err = audited(req, "blog.create", b.Title, nil, b, func() error {
+		return b.Save(img)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusCreated, newAPIBlog(b))
+}
+
+// APIBlogUpdate changes a blog.  Fields that aren't sent keep their
+// current values.  Changing the title or date changes the blog's id.
+func APIBlogUpdate(w http.ResponseWriter, req *http.Request) error {
+	b, err := store.GetBlog(mux.Vars(req)["id"])
+	if err != nil {
+		return err
+	}
+
+	ab := newAPIBlog(b)
+	if err := readJSON(req, &ab); err != nil {
+		return err
+	}
+
+	if ab.Title == "" || strings.Contains(ab.Title, "/") {
+		return badRequest("invalid blog title %q", ab.Title)
+	}
+
+	if ab.Date.IsZero() {
+		ab.Date = b.Date
+	}
+
+	img, err := decodeImage(ab.Image)
+	if err != nil {
+		return err
+	}
+
+	b2 := store.Blog{Title: ab.Title, Date: ab.Date, Body: ab.Body, Tags: store.ParseTags(strings.Join(ab.Tags, ",")), EditedBy: actor(req)}
+	err = audited(req, "blog.update", b2.Title, b, b.Merge(b2), func() error {
+		return b.Update(b2, img)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, newAPIBlog(b))
+}
+
+func APIBlogDelete(w http.ResponseWriter, req *http.Request) error {
+	b, err := store.GetBlog(mux.Vars(req)["id"])
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "blog.delete", b.Title, b, nil, func() error {
+		return b.Delete()
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+)
+
+// apiScopes are the capabilities that mean something to /api/v1.
+var apiScopes = []store.Capability{
+	store.CatalogWrite,
+	store.PricingWrite,
+	store.BlogWrite,
+}
+
+type apiKeysPage struct {
+	page
+	Keys   []store.APIKey
+	Scopes []store.Capability
+	Token  string
+	Error  string
+}
+
+// AdminAPIKeys lists the api keys, revoked ones included.
+func AdminAPIKeys(w http.ResponseWriter, req *http.Request) error {
+	return renderAPIKeys(w, req, "", "")
+}
+
+// AdminAPIKeyCreate makes a new api key.  The token is only ever shown
+// on the page this returns.
+func AdminAPIKeyCreate(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	var scopes []store.Capability
+	for _, c := range req.PostForm["scopes"] {
+		scopes = append(scopes, store.Capability(c))
+	}
+
+	token, k, err := store.GenerateAPIKey(req.PostFormValue("name"), getUser(req).Email, scopes)
+	if err != nil {
+		return renderAPIKeys(w, req, "", err.Error())
+	}
+
+	a := k
+	a.Hash = ""
+	err = audited(req, "apikey.create", k.ID, nil, a, func() error {
+		return k.Save()
+	})
+	if err != nil {
+		return err
+	}
+
+	return renderAPIKeys(w, req, token, "")
+}
+
+func AdminAPIKeyRevoke(w http.ResponseWriter, req *http.Request) error {
+	id := mux.Vars(req)["id"]
+	err := audited(req, "apikey.revoke", id, nil, nil, func() error {
+		return store.RevokeAPIKey(id)
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/apikeys")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func renderAPIKeys(w http.ResponseWriter, req *http.Request, token, msg string) error {
+	keys, err := store.GetAPIKeys()
+	if err != nil {
+		return err
+	}
+
+	p := apiKeysPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Keys:   keys,
+		Scopes: apiScopes,
+		Token:  token,
+		Error:  msg,
+	}
+
+	return templates.Get("admin/apikeys.html").ExecuteTemplate(w, "base", p)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+)
+
+type auditPage struct {
+	page
+	Entries []store.AuditEntry
+	Actor   string
+	Action  string
+	Target  string
+	From    string
+	To      string
+}
+
+// audit records a change made by whoever is logged in, or by the api key
+// that made the request.  It is called before the change is saved (see
+// audited), so a change can never be saved without its entry.
+func audit(req *http.Request, action, target string, before, after interface{}) error {
+	return store.Audit(actor(req), action, target, before, after)
+}
+
+// audited records the change and then makes it.  If making it fails, a
+// second entry, with .failed on the end of the action, says so and why.
+func audited(req *http.Request, action, target string, before, after interface{}, change func() error) error {
+	if err := audit(req, action, target, before, after); err != nil {
+		return err
+	}
+
+	err := change()
+	if err != nil {
+		if aerr := audit(req, action+".failed", target, nil, err.Error()); aerr != nil {
+			lg.Println("couldn't audit failed change", action, target, aerr)
+		}
+	}
+	return err
+}
+
+// actor is who is making the request: the user's email or the api key.
+func actor(req *http.Request) string {
+	if u := getUser(req); u != nil {
+		return u.Email
+	} else if k := getAPIKey(req); k != nil {
+		return fmt.Sprintf("api:%s (%s)", k.Name, k.ID)
+	}
+	return ""
+}
+
+// auditUser leaves the secrets out of the audit log.
+func auditUser(u store.User) store.User {
+	u.HashedPassword = nil
+	u.TOTPSecret = ""
+	u.TOTPPending = ""
+	u.RecoveryCodes = nil
+	return u
+}
+
+// auditSessions leaves out the session ids, which are what a browser
+// logs in with.
+func auditSessions(sessions ...store.Session) []store.Session {
+	out := make([]store.Session, len(sessions))
+	for i, s := range sessions {
+		s.ID = ""
+		out[i] = s
+	}
+	return out
+}
+
+// auditProduct includes the fields that are part of the product's key,
+// which don't get marshaled with the rest of it.
+func auditProduct(p *store.Product) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       p.Title,
+		"category":    pathName(p.Path),
+		"description": p.Description,
+		"weight":      p.Weight,
+		"tags":        p.Tags,
+		"sold_out":    p.SoldOut,
+		"status":      p.Status,
+	}
+}
+
+// AdminAudit shows the audit log, filtered by the actor, action, target,
+// from and to args.  With format=json it is downloaded instead.
+func AdminAudit(w http.ResponseWriter, req *http.Request) error {
+	args := req.URL.Query()
+	f := store.AuditFilter{
+		Actor:  args.Get("actor"),
+		Action: args.Get("action"),
+		Target: args.Get("target"),
+	}
+
+	var err error
+	if s := args.Get("from"); s != "" {
+		if f.From, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			return err
+		}
+	}
+
+	if s := args.Get("to"); s != "" {
+		if f.To, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			return err
+		}
+		f.To = f.To.AddDate(0, 0, 1)
+	}
+
+	entries, err := store.GetAudit(f)
+	if err != nil {
+		return err
+	}
+
+	if args.Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.json"`, time.Now().Format("2006-01-02")))
+		if entries == nil {
+			entries = []store.AuditEntry{}
+		}
+		return json.NewEncoder(w).Encode(entries)
+	}
+
+	p := auditPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Entries: entries,
+		Actor:   f.Actor,
+		Action:  f.Action,
+		Target:  f.Target,
+		From:    args.Get("from"),
+		To:      args.Get("to"),
+	}
+
+	return templates.Get("admin/audit.html").ExecuteTemplate(w, "base", p)
+}
@@ -5,34 +5,51 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/cswank/store/internal/store"
 	"github.com/gorilla/securecookie"
 )
 
+// twoFactorTimeout is how long someone has to type in their code after
+// their password checks out.
+const twoFactorTimeout = 5 * time.Minute
+
 var (
-	hashKey, blockKey      []byte
-	sc                     *securecookie.SecureCookie
-	domain, authCookieName string
+	hashKey, blockKey                           []byte
+	sc                                          *securecookie.SecureCookie
+	domain, authCookieName, twoFactorCookieName string
 )
 
 func Authentication(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		user, err := getUserFromCookie(req)
+		user, session, err := getUserFromCookie(req)
 		if err != nil && err != http.ErrNoCookie {
 			ctx := context.WithValue(req.Context(), "error", err)
 			req = req.WithContext(ctx)
 		} else {
 			ctx := context.WithValue(req.Context(), "user", user)
+			ctx = context.WithValue(ctx, "session", session)
 			req = req.WithContext(ctx)
 		}
 		h.ServeHTTP(w, req)
 	})
 }
 
-func getCookie(email string) *http.Cookie {
+func getSession(req *http.Request) *store.Session {
+	s := req.Context().Value("session")
+	if s == nil {
+		return nil
+	}
+	return s.(*store.Session)
+}
+
+// getCookie only holds the session ID, everything else about the login
+// lives in the sessions bucket.
+func getCookie(s store.Session) *http.Cookie {
 	val := map[string]string{
-		"email": email,
+		"session": s.ID,
 	}
 
 	encoded, err := sc.Encode(authCookieName, val)
@@ -43,28 +60,98 @@ func getCookie(email string) *http.Cookie {
 		Name:     authCookieName,
 		Value:    encoded,
 		Path:     "/",
+		MaxAge:   int(cfg.SessionMaxAge.Seconds()),
+		Secure:   cfg.TLS,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func clearCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   authCookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+}
+
+// getTwoFactorCookie remembers who got their password right while they
+// are on /login/2fa.
+func getTwoFactorCookie(email string) *http.Cookie {
+	val := map[string]string{
+		"email":   email,
+		"expires": strconv.FormatInt(time.Now().Add(twoFactorTimeout).Unix(), 10),
+	}
+
+	encoded, err := sc.Encode(twoFactorCookieName, val)
+	if err != nil {
+		log.Println("couldn't encode cookie", err)
+	}
+	return &http.Cookie{
+		Name:     twoFactorCookieName,
+		Value:    encoded,
+		Path:     "/login/2fa",
+		MaxAge:   int(twoFactorTimeout.Seconds()),
+		Secure:   cfg.TLS,
 		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func clearTwoFactorCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   twoFactorCookieName,
+		Value:  "",
+		Path:   "/login/2fa",
+		MaxAge: -1,
+	})
+}
+
+func getTwoFactorEmail(req *http.Request) (string, error) {
+	cookie, err := req.Cookie(twoFactorCookieName)
+	if err != nil {
+		return "", err
+	}
+
+	var m map[string]string
+	if err := sc.Decode(twoFactorCookieName, cookie.Value, &m); err != nil {
+		return "", err
 	}
+
+	expires, err := strconv.ParseInt(m["expires"], 10, 64)
+	if err != nil || time.Now().Unix() > expires || m["email"] == "" {
+		return "", errors.New("expired two factor login")
+	}
+
+	return m["email"], nil
 }
 
-func getUserFromCookie(req *http.Request) (*store.User, error) {
+func getUserFromCookie(req *http.Request) (*store.User, *store.Session, error) {
 	user := &store.User{}
 	cookie, err := req.Cookie(authCookieName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var m map[string]string
 	err = sc.Decode(authCookieName, cookie.Value, &m)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if m["email"] == "" {
-		return nil, errors.New("no way, eh")
+	//cookies from before sessions only had an email and are no good
+	if m["session"] == "" {
+		return nil, nil, errors.New("no way, eh")
 	}
-	user.Email = m["email"]
+
+	s, err := store.GetSession(m["session"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user.Email = s.Email
 	err = user.Fetch()
 	user.HashedPassword = []byte{}
-	return user, err
+	return user, &s, err
 }
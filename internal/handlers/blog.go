@@ -5,7 +5,7 @@ import (
 	"html/template"
 	"net/http"
 	"reflect"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/cswank/store/internal/store"
@@ -14,12 +14,70 @@ import (
 	"github.com/gorilla/schema"
 )
 
+const blogsPerPage = 10
+
 type blogPage struct {
 	page
+	blogArchive
 	Blog  store.Blog
 	Body  template.HTML
 	ID    string
 	Blogs []store.BlogKey
+	Pager pager
+
+	Comments       []store.Comment
+	Captcha        bool
+	CaptchaSiteKey string
+	Commented      bool
+}
+
+// blogArchive is what the side of the blog pages links to.
+type blogArchive struct {
+	Months []store.BlogMonth
+	Tags   []string
+}
+
+func getBlogArchive() (blogArchive, error) {
+	months, err := store.BlogMonths()
+	if err != nil {
+		return blogArchive{}, err
+	}
+
+	tags, err := store.BlogTags()
+	return blogArchive{Months: months, Tags: tags}, err
+}
+
+type pager struct {
+	Page int
+	Prev string
+	Next string
+}
+
+// paginate returns the blogs on the page in the page arg (the first
+// if there isn't one) and links to the pages either side.
+func paginate(req *http.Request, blogs []store.BlogKey) ([]store.BlogKey, pager) {
+	n, err := strconv.Atoi(req.URL.Query().Get("page"))
+	if err != nil || n < 1 {
+		n = 1
+	}
+
+	p := pager{Page: n}
+	if n > 1 {
+		p.Prev = fmt.Sprintf("%s?page=%d", req.URL.Path, n-1)
+	}
+
+	start := (n - 1) * blogsPerPage
+	if start >= len(blogs) {
+		return nil, p
+	}
+
+	end := start + blogsPerPage
+	if end < len(blogs) {
+		p.Next = fmt.Sprintf("%s?page=%d", req.URL.Path, n+1)
+	} else {
+		end = len(blogs)
+	}
+	return blogs[start:end], p
 }
 
 func Blog(w http.ResponseWriter, req *http.Request) error {
@@ -38,38 +96,125 @@ func Blog(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
-	blogs, err := store.Blogs()
+	if !b.Live() && !Can(store.BlogWrite)(req) {
+		return store.ErrNotFound
+	}
+
+	blogs, err := store.PublishedBlogs()
+	if err != nil {
+		return err
+	}
+
+	archive, err := getBlogArchive()
 	if err != nil {
 		return err
 	}
 
-	body := strings.Replace(b.Body, "\n", "\n<br/>", -1)
+	body := b.Render()
+
 	p := blogPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Name:  cfg.Name,
 			Head:  html["head"],
 		},
-		Blog:  b,
-		ID:    b.Key(),
-		Blogs: blogs,
-		Body:  template.HTML(body),
+		blogArchive: archive,
+		Blog:        b,
+		ID:          b.Key(),
+		Body:        template.HTML(body),
+		Captcha:     captcha,
+		Commented:   req.URL.Query().Get("commented") == "true",
+	}
+	p.Blogs, p.Pager = paginate(req, blogs)
+
+	if captcha {
+		p.CaptchaSiteKey = cfg.RecaptchaSiteKey
+	}
+
+	if p.Comments, err = store.ApprovedComments(b.Key()); err != nil {
+		return err
 	}
 
 	return templates.Get("blogs/blogs.html").ExecuteTemplate(w, "base", p)
 }
 
+type blogListPage struct {
+	page
+	blogArchive
+	Heading string
+	Blogs   []store.BlogKey
+	Pager   pager
+}
+
+// BlogMonth lists the blogs from the {month} (2006-01).
+func BlogMonth(w http.ResponseWriter, req *http.Request) error {
+	month := mux.Vars(req)["month"]
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return store.ErrNotFound
+	}
+
+	blogs, err := store.BlogsIn(month)
+	if err != nil {
+		return err
+	}
+
+	return blogList(w, req, t.Format("January 2006"), blogs)
+}
+
+// BlogTag lists the blogs with the {tag}.
+func BlogTag(w http.ResponseWriter, req *http.Request) error {
+	tag := mux.Vars(req)["tag"]
+	blogs, err := store.BlogsTagged(tag)
+	if err != nil {
+		return err
+	}
+
+	return blogList(w, req, fmt.Sprintf("Tagged %s", tag), blogs)
+}
+
+func blogList(w http.ResponseWriter, req *http.Request, heading string, blogs []store.BlogKey) error {
+	if len(blogs) == 0 {
+		return store.ErrNotFound
+	}
+
+	archive, err := getBlogArchive()
+	if err != nil {
+		return err
+	}
+
+	p := blogListPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Name:  cfg.Name,
+			Head:  html["head"],
+		},
+		blogArchive: archive,
+		Heading:     heading,
+	}
+	p.Blogs, p.Pager = paginate(req, blogs)
+
+	return templates.Get("blogs/list.html").ExecuteTemplate(w, "base", p)
+}
+
 type blogFormPage struct {
 	page
-	Action string
-	Blog   store.Blog
-	URI    string
+	Action   string
+	Blog     store.Blog
+	ID       string
+	URI      string
+	Preview  template.HTML
+	Media    []string
+	MediaURI string
 }
 
 func BlogForm(w http.ResponseWriter, req *http.Request) error {
 	vars := mux.Vars(req)
-	var action string
+	var action, id string
 	var b store.Blog
+	var media []string
 	if vars["blog"] == "new" {
 		action = "/admin/blogs"
 		b.Date = time.Now()
@@ -80,22 +225,137 @@ func BlogForm(w http.ResponseWriter, req *http.Request) error {
 		if err != nil {
 			return err
 		}
+
+		id = b.Key()
+		media, err = store.BlogMedia(id)
+		if err != nil {
+			return err
+		}
 	}
 
 	p := blogFormPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Name:  cfg.Name,
 			Head:  html["head"],
 		},
-		Action: action,
-		Blog:   b,
-		URI:    fmt.Sprintf("/admin/blogs/%s", b.Key()),
+		Action:  action,
+		Blog:    b,
+		ID:      id,
+		URI:     fmt.Sprintf("/admin/blogs/%s", b.Key()),
+		Preview: template.HTML(store.PreviewBlog(id, b.Body)),
+		Media:   media,
+	}
+
+	if id != "" {
+		p.MediaURI = fmt.Sprintf("/admin/blogs/%s/media", id)
 	}
 
 	return templates.Get("admin/blog-form.html").ExecuteTemplate(w, "base", p)
 }
 
+// BlogPreview renders the markdown in the body field the way the blog
+// will show it, for the preview on the blog form.
+func BlogPreview(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := w.Write([]byte(store.PreviewBlog(req.PostFormValue("blog"), req.PostFormValue("body"))))
+	return err
+}
+
+// BlogMediaImage serves an image from a blog's library.  Like the blog,
+// only staff that can edit blogs see the images of one that isn't live.
+func BlogMediaImage(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+
+	b, err := store.GetBlog(vars["blog"])
+	if err != nil {
+		return err
+	}
+
+	if !b.Live() && !Can(store.BlogWrite)(req) {
+		return store.ErrNotFound
+	}
+
+	img, err := store.GetBlogMedia(vars["blog"], vars["image"])
+	if err != nil {
+		return err
+	}
+
+	if img == nil {
+		return store.ErrNotFound
+	}
+
+	setEtag(w, req.URL.Path, img)
+	w.Header().Set("Content-Type", "image/png")
+	_, err = w.Write(img)
+	return err
+}
+
+// AddBlogMedia adds the png files in the images field to a blog's
+// library.  Each is named after the file it came from.
+func AddBlogMedia(w http.ResponseWriter, req *http.Request) error {
+	b, err := store.GetBlog(mux.Vars(req)["blog"])
+	if err != nil {
+		return err
+	}
+
+	if err := req.ParseMultipartForm(32 << 20); err != nil {
+		return err
+	}
+
+	key := b.Key()
+	for _, fh := range req.MultipartForm.File["images"] {
+		name, err := store.MediaName(fh.Filename)
+		if err != nil {
+			return err
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			return err
+		}
+
+		err = audited(req, "blog.media.add", b.Title, nil, name, func() error {
+			return store.AddBlogMedia(key, name, f)
+		})
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		clearBlogMediaEtag(key, name)
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/admin/blogs/%s", key))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// DeleteBlogMedia removes an image from a blog's library.
+func DeleteBlogMedia(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	b, err := store.GetBlog(vars["blog"])
+	if err != nil {
+		return err
+	}
+
+	key := b.Key()
+	err = audited(req, "blog.media.delete", b.Title, vars["image"], nil, func() error {
+		return store.DeleteBlogMedia(key, vars["image"])
+	})
+	if err != nil {
+		return err
+	}
+
+	clearBlogMediaEtag(key, vars["image"])
+	return nil
+}
+
 func BlogImage(w http.ResponseWriter, req *http.Request) error {
 	vars := mux.Vars(req)
 
@@ -119,6 +379,7 @@ func ManageBlogs(w http.ResponseWriter, req *http.Request) error {
 
 	p := blogPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Name:  cfg.Name,
 			Head:  html["head"],
@@ -136,7 +397,10 @@ func DeleteBlog(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
-	if err := b.Delete(); err != nil {
+	err = audited(req, "blog.delete", b.Title, b, nil, func() error {
+		return b.Delete()
+	})
+	if err != nil {
 		return err
 	}
 
@@ -162,17 +426,22 @@ func UpdateBlog(w http.ResponseWriter, req *http.Request) error {
 	}
 
 	var b2 store.Blog
-	dec := schema.NewDecoder()
-	dec.IgnoreUnknownKeys(true)
-	dec.RegisterConverter(time.Time{}, func(value string) reflect.Value {
-		s, _ := time.Parse("01/02/2006", value)
-		return reflect.ValueOf(s)
-	})
+	dec := getBlogDecoder()
 	if err := dec.Decode(&b2, req.PostForm); err != nil {
 		return err
 	}
 
-	if err := b.Update(b2, ff); err != nil {
+	b2.Status, b2.PublishAt, err = getPublishing(req)
+	if err != nil {
+		return err
+	}
+	b2.Tags = store.ParseTags(req.FormValue("tags"))
+	b2.EditedBy = actor(req)
+
+	err = audited(req, "blog.update", b2.Title, b, b.Merge(b2), func() error {
+		return b.Update(b2, ff)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -194,17 +463,44 @@ func CreateBlog(w http.ResponseWriter, req *http.Request) error {
 	}
 
 	var b store.Blog
-	dec := schema.NewDecoder()
-	dec.IgnoreUnknownKeys(true)
-	dec.RegisterConverter(time.Time{}, func(value string) reflect.Value {
-		s, _ := time.Parse("01/02/2006", value)
-		return reflect.ValueOf(s)
-	})
+	dec := getBlogDecoder()
 	if err := dec.Decode(&b, req.PostForm); err != nil {
 		return err
 	}
 
+	b.Status, b.PublishAt, err = getPublishing(req)
+	if err != nil {
+		return err
+	}
+	b.Tags = store.ParseTags(req.FormValue("tags"))
+	b.EditedBy = actor(req)
+	if b.Date.IsZero() {
+		b.Date = time.Now()
+	}
+
+	err = audited(req, "blog.create", b.Title, nil, b, func() error {
+		return b.Save(ff)
+	})
+	if err != nil {
+		return err
+	}
+
 	w.Header().Set("Location", "/admin/blogs")
 	w.WriteHeader(http.StatusFound)
-	return b.Save(ff)
+	return nil
+}
+
+// getBlogDecoder decodes the blog form.  The date comes from a date
+// input, or from older browsers as mm/dd/yyyy.
+func getBlogDecoder() *schema.Decoder {
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	dec.RegisterConverter(time.Time{}, func(value string) reflect.Value {
+		s, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			s, _ = time.Parse("01/02/2006", value)
+		}
+		return reflect.ValueOf(s)
+	})
+	return dec
 }
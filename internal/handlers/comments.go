@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cswank/store/internal/email"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+// AddComment saves a comment on the {blog} and lets the admin know that
+// there is one to moderate.  It isn't shown until it is approved.
+func AddComment(w http.ResponseWriter, req *http.Request) error {
+	b, err := store.GetBlog(mux.Vars(req)["blog"])
+	if err != nil {
+		return err
+	}
+
+	if !b.Live() {
+		return store.ErrNotFound
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	var c store.Comment
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	if err := dec.Decode(&c, req.PostForm); err != nil {
+		return err
+	}
+
+	c.Blog = b.Key()
+	c.IP = remoteIP(req)
+	if err := store.AddComment(&c); err != nil {
+		return err
+	}
+
+	//the commenter's name and address stay in the body, out of the
+	//headers
+	from := c.Name
+	if c.Email != "" {
+		from = fmt.Sprintf("%s (%s)", c.Name, c.Email)
+	}
+
+	m := email.Msg{
+		From:    cfg.Email,
+		To:      cfg.Email,
+		Subject: fmt.Sprintf("New comment on %s", b.Title),
+		Body: fmt.Sprintf(
+			"%s left a comment on %s:\n\n%s\n\nApprove or reject it at %s/admin/comments",
+			from, b.Title, c.Body, siteURL(req),
+		),
+	}
+
+	if err := email.Send(m); err != nil {
+		lg.Println("couldn't send comment notification", err)
+	}
+
+	u := url.URL{Path: "/blog/" + b.Key(), RawQuery: "commented=true", Fragment: "comments"}
+	w.Header().Set("Location", u.String())
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+type commentsPage struct {
+	page
+	Status   store.CommentStatus
+	Statuses []store.CommentStatus
+	Comments []store.Comment
+}
+
+// AdminComments is the moderation queue.  It shows the pending comments
+// unless the status arg asks for others.
+func AdminComments(w http.ResponseWriter, req *http.Request) error {
+	s := store.CommentPending
+	if q := req.URL.Query().Get("status"); q != "" {
+		var err error
+		if s, err = store.ParseCommentStatus(q); err != nil {
+			return err
+		}
+	}
+
+	comments, err := store.CommentsWithStatus(s)
+	if err != nil {
+		return err
+	}
+
+	p := commentsPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Name:  cfg.Name,
+			Head:  html["head"],
+		},
+		Status:   s,
+		Statuses: store.CommentStatuses,
+		Comments: comments,
+	}
+
+	return templates.Get("admin/comments.html").ExecuteTemplate(w, "base", p)
+}
+
+// ModerateComment approves, rejects or marks as spam the comment {id} on
+// the {blog}.
+func ModerateComment(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	s, err := store.ParseCommentStatus(req.PostForm.Get("status"))
+	if err != nil {
+		return err
+	}
+
+	before, err := store.GetComment(vars["blog"], vars["id"])
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "comment."+string(s), before.Blog+"/"+before.ID, before.Status, s, func() error {
+		_, err := store.ModerateComment(vars["blog"], vars["id"], s)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/comments")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
@@ -25,6 +25,7 @@ func Contact(w http.ResponseWriter, req *http.Request) error {
 	s := req.URL.Query().Get("submitted")
 	p := contactPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Admin: Admin(req),
 			Name:  name,
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+)
+
+var csrfCookieName string
+
+// CSRF makes sure a POST, PUT or DELETE came from one of our own pages.
+// The token is an HMAC of the session ID, or of a random ID kept in a
+// cookie for people that haven't logged in, and gets to the templates
+// through page.CSRF.  Forms send it as csrf_token and javascript sends
+// it in the X-CSRF-Token header.  It has to come after Authentication in
+// the chain.
+func CSRF(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := csrfHMAC(csrfID(w, req))
+		ctx := context.WithValue(req.Context(), "csrf", token)
+		req = req.WithContext(ctx)
+
+		switch req.Method {
+		case "GET", "HEAD", "OPTIONS":
+		default:
+			if !validCSRF(req, token) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte("Invalid CSRF Token"))
+				return
+			}
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+func csrfToken(req *http.Request) string {
+	t := req.Context().Value("csrf")
+	if t == nil {
+		return ""
+	}
+	return t.(string)
+}
+
+func validCSRF(req *http.Request, token string) bool {
+	t := req.Header.Get("X-CSRF-Token")
+	if t == "" {
+		t = req.FormValue("csrf_token")
+	}
+	return t != "" && hmac.Equal([]byte(t), []byte(token))
+}
+
+func csrfHMAC(id string) string {
+	h := hmac.New(sha256.New, hashKey)
+	h.Write([]byte("csrf:"))
+	h.Write([]byte(id))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// csrfID is the session ID when there is one.  Otherwise it comes from
+// the csrf cookie, which is set here the first time it is needed.
+func csrfID(w http.ResponseWriter, req *http.Request) string {
+	if s := getSession(req); s != nil {
+		return "session:" + s.ID
+	}
+
+	if cookie, err := req.Cookie(csrfCookieName); err == nil {
+		var id string
+		if err := sc.Decode(csrfCookieName, cookie.Value, &id); err == nil && id != "" {
+			return "anonymous:" + id
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Println("couldn't make csrf id", err)
+	}
+	id := hex.EncodeToString(b)
+
+	encoded, err := sc.Encode(csrfCookieName, id)
+	if err != nil {
+		log.Println("couldn't encode cookie", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    encoded,
+		Path:     "/",
+		Secure:   cfg.TLS,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return "anonymous:" + id
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cswank/store/internal/money"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+const currencyCookie = "currency"
+
+// getCurrency is the currency the shopper picked in the navbar.
+func getCurrency(req *http.Request) store.Currency {
+	c, err := req.Cookie(currencyCookie)
+	if err != nil {
+		return store.BaseCurrency()
+	}
+
+	cur, err := store.GetCurrency(c.Value)
+	if err != nil {
+		return store.BaseCurrency()
+	}
+	return cur
+}
+
+// displayPrice formats an amount in the store's currency in the
+// shopper's currency.
+func displayPrice(req *http.Request, amount money.Money) string {
+	return getCurrency(req).Format(amount)
+}
+
+func getCurrencyLinks(req *http.Request) *link {
+	currencies, err := store.GetCurrencies()
+	if err != nil {
+		lg.Println("error getting currencies", err)
+		return nil
+	}
+
+	if len(currencies) == 0 {
+		return nil
+	}
+
+	currencies = append([]store.Currency{store.BaseCurrency()}, currencies...)
+	l := link{Name: getCurrency(req).Code, Link: "#"}
+	for _, c := range currencies {
+		l.Children = append(l.Children, link{
+			Name: c.Code,
+			Link: fmt.Sprintf("/currency/%s", c.Code),
+			Post: true,
+		})
+	}
+	return &l
+}
+
+// SetCurrency remembers the shopper's currency and sends them back to
+// the page they were on.
+func SetCurrency(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	cur, err := store.GetCurrency(vars["currency"])
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   currencyCookie,
+		Value:  cur.Code,
+		Path:   "/",
+		MaxAge: 365 * 24 * 60 * 60,
+	})
+
+	w.Header().Set("Location", localReferer(req))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// localReferer is the path and query of the page the request came from,
+// so that a Referer from another site can't send the shopper there.
+func localReferer(req *http.Request) string {
+	u, err := url.Parse(req.Referer())
+	if err != nil {
+		return "/"
+	}
+
+	//a path starting with // or /\ is another host to a browser
+	if !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "//") || strings.HasPrefix(u.Path, "/\\") {
+		return "/"
+	}
+
+	l := url.URL{Path: u.Path, RawQuery: u.RawQuery}
+	return l.String()
+}
+
+type currenciesPage struct {
+	page
+	Base       store.Currency
+	Currencies []store.Currency
+}
+
+func AdminCurrencies(w http.ResponseWriter, req *http.Request) error {
+	currencies, err := store.GetCurrencies()
+	if err != nil {
+		return err
+	}
+
+	p := currenciesPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Base:       store.BaseCurrency(),
+		Currencies: currencies,
+	}
+
+	return templates.Get("admin/currencies.html").ExecuteTemplate(w, "base", p)
+}
+
+func AdminCurrencyUpdate(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	var c store.Currency
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	if err := dec.Decode(&c, req.PostForm); err != nil {
+		return err
+	}
+
+	var before interface{}
+	if old, err := store.GetCurrency(c.Code); err == nil {
+		before = old
+	} else if err != store.ErrNotFound {
+		return err
+	}
+
+	err := audited(req, "currency.update", c.Code, before, c, func() error {
+		return c.Save()
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/currencies")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func AdminCurrencyDelete(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	err := audited(req, "currency.delete", vars["currency"], nil, nil, func() error {
+		return store.DeleteCurrency(vars["currency"])
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/currencies")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cswank/store/internal/money"
+	"github.com/cswank/store/internal/store"
+)
+
+// facets filter the products on the category and subcategory pages.
+// They come from the tag (which can be repeated), min, max and instock
+// args.  Min and max are in the shopper's currency.
+type facets struct {
+	Path    string
+	Tags    []tagFacet
+	Min     string
+	Max     string
+	InStock bool
+	Active  bool
+
+	selected map[string]bool
+	min      *money.Money
+	max      *money.Money
+	counts   map[string]int
+}
+
+type tagFacet struct {
+	Name     string
+	Count    int
+	Selected bool
+}
+
+func getFacets(req *http.Request) *facets {
+	args := req.URL.Query()
+	f := &facets{
+		Path:     req.URL.Path,
+		Min:      strings.TrimSpace(args.Get("min")),
+		Max:      strings.TrimSpace(args.Get("max")),
+		InStock:  args.Get("instock") != "",
+		selected: map[string]bool{},
+		counts:   map[string]int{},
+	}
+
+	for _, t := range args["tag"] {
+		f.selected[t] = true
+	}
+
+	code := getCurrency(req).Code
+	if m, err := money.Parse(f.Min, code); err == nil && f.Min != "" {
+		f.min = &m
+	}
+	if m, err := money.Parse(f.Max, code); err == nil && f.Max != "" {
+		f.max = &m
+	}
+
+	f.Active = len(f.selected) > 0 || f.min != nil || f.max != nil || f.InStock
+	return f
+}
+
+// filter returns the products that match, and counts their tags for the
+// tag facet.  Price is what the shopper pays, which is the same for
+// every product in a category.
+func (f *facets) filter(req *http.Request, products []store.Product, price money.Money) []store.Product {
+	if !f.priceOK(req, price) {
+		return nil
+	}
+
+	var out []store.Product
+	for _, p := range products {
+		if f.InStock && p.SoldOut {
+			continue
+		}
+
+		ok := true
+		for t := range f.selected {
+			if !p.HasTag(t) {
+				ok = false
+				break
+			}
+		}
+
+		if !ok {
+			continue
+		}
+
+		for _, t := range p.Tags {
+			f.counts[t]++
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func (f *facets) priceOK(req *http.Request, price money.Money) bool {
+	if f.min == nil && f.max == nil {
+		return true
+	}
+
+	p, err := getCurrency(req).Convert(price)
+	if err != nil {
+		p = price
+	}
+
+	return (f.min == nil || p.Amount >= f.min.Amount) && (f.max == nil || p.Amount <= f.max.Amount)
+}
+
+// done fills in the tag facet once every product has been filtered.
+func (f *facets) done() *facets {
+	for t := range f.selected {
+		if _, ok := f.counts[t]; !ok {
+			f.counts[t] = 0
+		}
+	}
+
+	for t, n := range f.counts {
+		f.Tags = append(f.Tags, tagFacet{Name: t, Count: n, Selected: f.selected[t]})
+	}
+
+	sort.Slice(f.Tags, func(i, j int) bool {
+		return f.Tags[i].Name < f.Tags[j].Name
+	})
+	return f
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cswank/store/internal/store"
+)
+
+// feedSize is how many of the newest blogs go in the feeds.
+const feedSize = 20
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// BlogRSS is an RSS 2.0 feed of the newest blogs.
+func BlogRSS(w http.ResponseWriter, req *http.Request) error {
+	blogs, err := getFeedBlogs()
+	if err != nil {
+		return err
+	}
+
+	site := siteURL(req)
+	f := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       cfg.Name,
+			Link:        site + "/blog",
+			Description: fmt.Sprintf("The %s blog", cfg.Name),
+		},
+	}
+
+	for _, b := range blogs {
+		l := blogURL(site, b)
+		f.Channel.Items = append(f.Channel.Items, rssItem{
+			Title:       b.Title,
+			Link:        l,
+			GUID:        l,
+			PubDate:     b.Date.Format(time.RFC1123Z),
+			Description: b.HTML,
+		})
+	}
+
+	return writeFeed(w, "application/rss+xml", f)
+}
+
+// BlogAtom is an Atom feed of the newest blogs.
+func BlogAtom(w http.ResponseWriter, req *http.Request) error {
+	blogs, err := getFeedBlogs()
+	if err != nil {
+		return err
+	}
+
+	site := siteURL(req)
+	f := atomFeed{
+		Title: cfg.Name,
+		ID:    site + "/blog",
+		Links: []atomLink{
+			{Href: site + "/blog"},
+			{Href: site + "/blog/feed.atom", Rel: "self"},
+		},
+	}
+
+	for i, b := range blogs {
+		if i == 0 {
+			f.Updated = b.Date.Format(time.RFC3339)
+		}
+
+		l := blogURL(site, b)
+		f.Entries = append(f.Entries, atomEntry{
+			Title:   b.Title,
+			ID:      l,
+			Updated: b.Date.Format(time.RFC3339),
+			Link:    atomLink{Href: l},
+			Content: atomContent{Type: "html", Body: b.HTML},
+		})
+	}
+
+	if f.Updated == "" {
+		f.Updated = time.Now().Format(time.RFC3339)
+	}
+
+	return writeFeed(w, "application/atom+xml", f)
+}
+
+// getFeedBlogs returns the newest published blogs, rendered.
+func getFeedBlogs() ([]store.Blog, error) {
+	keys, err := store.PublishedBlogs()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) > feedSize {
+		keys = keys[:feedSize]
+	}
+
+	blogs := make([]store.Blog, len(keys))
+	for i, k := range keys {
+		b, err := store.GetBlog(k.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		b.HTML = b.Render()
+		blogs[i] = b
+	}
+	return blogs, nil
+}
+
+func writeFeed(w http.ResponseWriter, contentType string, f interface{}) error {
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(f)
+}
+
+// siteURL is where the feeds say the site is.
+func siteURL(req *http.Request) string {
+	if len(cfg.Domains) > 0 {
+		return "https://" + cfg.Domains[0]
+	}
+	return "https://" + req.Host
+}
+
+func blogURL(site string, b store.Blog) string {
+	return site + (&url.URL{Path: "/blog/" + b.Key()}).String()
+}
@@ -19,6 +19,8 @@ var (
 	errInvalidLogin = errors.New("invalid login")
 	lock            sync.Mutex
 	shoppingLinks   []link
+	pageLinks       map[store.PageNav][]link
+	menu            []store.MenuItem
 	cfg             config.Config
 	ico             []byte
 
@@ -39,11 +41,15 @@ func Init(c config.Config) {
 		"home":  cfg.Home,
 	}
 
+	//The home and about files are only used until those pages are
+	//written in the admin, so they don't have to exist.
 	html = make(map[string]template.HTML)
 	for name, pth := range htmlLookup {
 		d, err := ioutil.ReadFile(pth)
-		if err != nil {
+		if err != nil && name == "head" {
 			log.Fatal("could not read ", name, pth)
+		} else if err != nil {
+			continue
 		}
 		html[name] = template.HTML(string(d))
 	}
@@ -66,6 +72,8 @@ func Init(c config.Config) {
 
 	domain = domains[0]
 	authCookieName = fmt.Sprintf("%s-user", domain)
+	twoFactorCookieName = fmt.Sprintf("%s-2fa", domain)
+	csrfCookieName = fmt.Sprintf("%s-csrf", domain)
 	hashKey = []byte(cfg.HashKey)
 	blockKey = []byte(cfg.BlockKey)
 	if string(hashKey) == "" || string(blockKey) == "" {
@@ -111,6 +119,7 @@ func HandleErr(f HandlerFunc) http.HandlerFunc {
 
 func NotFound(w http.ResponseWriter, req *http.Request) {
 	p := page{
+		CSRF:    csrfToken(req),
 		Links:   getNavbarLinks(req),
 		Admin:   Admin(req),
 		Shopify: shopifyKey,
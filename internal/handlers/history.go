@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cswank/store/internal/diff"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+)
+
+type historyPage struct {
+	page
+	Title     string
+	Back      string
+	Action    string
+	Revisions []store.Revision
+	Selected  int
+	Diff      []diff.Line
+}
+
+// newHistoryPage shows the revisions, newest first, and what changed in
+// the one in the revision arg.  texts are the revisions written out as
+// lines, in the same order.  It is the newest one if there is no arg.
+func newHistoryPage(req *http.Request, revs []store.Revision, texts []string) (historyPage, error) {
+	p := historyPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Name:  cfg.Name,
+			Head:  html["head"],
+		},
+		Revisions: revs,
+	}
+
+	if len(revs) == 0 {
+		return p, nil
+	}
+
+	p.Selected = revs[0].N
+	if r := req.URL.Query().Get("revision"); r != "" {
+		n, err := strconv.Atoi(r)
+		if err != nil || n < 1 || n > len(revs) {
+			return p, store.ErrNotFound
+		}
+		p.Selected = n
+	}
+
+	//revs are newest first, so the one before is the next one
+	i := len(revs) - p.Selected
+	var prev string
+	if i+1 < len(texts) {
+		prev = texts[i+1]
+	}
+	p.Diff = diff.Lines(prev, texts[i])
+	return p, nil
+}
+
+// getRevision reads the revision form field.
+func getRevision(req *http.Request) (int, error) {
+	n, err := strconv.Atoi(req.FormValue("revision"))
+	if err != nil {
+		return 0, store.ErrNotFound
+	}
+	return n, nil
+}
+
+// ProductHistory shows the saved versions of the product at the {path}.
+func ProductHistory(w http.ResponseWriter, req *http.Request) error {
+	p, err := getProduct(req)
+	if err != nil {
+		return err
+	}
+
+	revs, err := store.ProductRevisions(p.Path, p.Title)
+	if err != nil {
+		return err
+	}
+
+	rs := make([]store.Revision, len(revs))
+	texts := make([]string, len(revs))
+	for i, r := range revs {
+		rs[i] = r.Revision
+		texts[i] = r.Text()
+	}
+
+	page, err := newHistoryPage(req, rs, texts)
+	if err != nil {
+		return err
+	}
+
+	page.Title = productName(p)
+	page.Back = adminLink(append(append([]string{}, p.Path...), p.Title))
+	page.Action = "/admin/history/products/" + productName(p)
+	return templates.Get("admin/history.html").ExecuteTemplate(w, "base", page)
+}
+
+// RestoreProduct makes the revision in the revision form field the
+// newest version of the product at the {path}.
+func RestoreProduct(w http.ResponseWriter, req *http.Request) error {
+	p, err := getProduct(req)
+	if err != nil {
+		return err
+	}
+
+	n, err := getRevision(req)
+	if err != nil {
+		return err
+	}
+
+	p2, err := p.Restoring(n, actor(req))
+	if err != nil {
+		return err
+	}
+
+	after := p.Merge(p2)
+	err = audited(req, "product.restore", fmt.Sprintf("%s@%d", productName(p), n), auditProduct(p), auditProduct(&after), func() error {
+		return p.Update(p2)
+	})
+	if err != nil {
+		return err
+	}
+
+	clearEtag(p.Title)
+
+	makeNavbarLinks()
+	w.Header().Set("Location", "/admin/history/products/"+productName(p))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// BlogHistory shows the saved versions of the {blog}.
+func BlogHistory(w http.ResponseWriter, req *http.Request) error {
+	b, err := store.GetBlog(mux.Vars(req)["blog"])
+	if err != nil {
+		return err
+	}
+
+	revs, err := store.BlogRevisions(b.Key())
+	if err != nil {
+		return err
+	}
+
+	rs := make([]store.Revision, len(revs))
+	texts := make([]string, len(revs))
+	for i, r := range revs {
+		rs[i] = r.Revision
+		texts[i] = r.Text()
+	}
+
+	page, err := newHistoryPage(req, rs, texts)
+	if err != nil {
+		return err
+	}
+
+	page.Title = b.Title
+	page.Back = "/admin/blogs/" + b.Key()
+	page.Action = fmt.Sprintf("/admin/blogs/%s/history", b.Key())
+	return templates.Get("admin/history.html").ExecuteTemplate(w, "base", page)
+}
+
+// RestoreBlog makes the revision in the revision form field the newest
+// version of the {blog}.
+func RestoreBlog(w http.ResponseWriter, req *http.Request) error {
+	b, err := store.GetBlog(mux.Vars(req)["blog"])
+	if err != nil {
+		return err
+	}
+
+	n, err := getRevision(req)
+	if err != nil {
+		return err
+	}
+
+	b2, err := b.Restoring(n, actor(req))
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "blog.restore", fmt.Sprintf("%s@%d", b.Key(), n), b, b.Merge(b2), func() error {
+		return b.Update(b2, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/admin/blogs/%s/history", b.Key()))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
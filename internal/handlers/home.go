@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/cswank/store/internal/store"
 	"github.com/cswank/store/internal/templates"
 )
 
@@ -16,6 +17,8 @@ type page struct {
 	Name    string
 	Message string
 	Head    template.HTML
+	//CSRF goes in a hidden csrf_token input in every form
+	CSRF string
 }
 
 type homePage struct {
@@ -28,15 +31,21 @@ var (
 )
 
 func Home(w http.ResponseWriter, req *http.Request) error {
+	home, err := getPageHTML(store.HomePage)
+	if err != nil {
+		return err
+	}
+
 	p := homePage{
 		page: page{
+			CSRF:    csrfToken(req),
 			Links:   getNavbarLinks(req),
 			Admin:   Admin(req),
 			Shopify: shopifyKey,
 			Name:    name,
 			Head:    html["head"],
 		},
-		Home: html["home"],
+		Home: home,
 	}
 
 	return templates.Get("index.html").ExecuteTemplate(w, "base", p)
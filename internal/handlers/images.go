@@ -49,6 +49,12 @@ func clearEtag(title string) {
 	eLock.Unlock()
 }
 
+func clearBlogMediaEtag(blog, name string) {
+	eLock.Lock()
+	delete(etags, fmt.Sprintf("/images/blogs/%s/%s", blog, name))
+	eLock.Unlock()
+}
+
 //ETag short-circuts the request if the client already has this resource.
 func ETag(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
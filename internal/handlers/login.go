@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/cswank/store/internal/email"
 	"github.com/cswank/store/internal/store"
@@ -26,6 +27,7 @@ type loginPage struct {
 func Login(w http.ResponseWriter, req *http.Request) error {
 	p := loginPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Admin: Admin(req),
 			Head:  html["head"],
@@ -51,14 +53,56 @@ func DoLogin(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
+	ip := remoteIP(req)
+	wait, err := store.CheckLogin(ip, u.Email)
+	if err != nil {
+		return err
+	}
+
+	if wait > 0 {
+		return tooManyLogins(w, "/login", wait)
+	}
+
 	ok, err := u.CheckPassword()
 	if !ok || err != nil {
-		return errInvalidLogin
+		return loginFailed(ip, u.Email)
+	}
+
+	if isCustomer(&u) && !u.Verified {
+		w.Header().Set("Location", "/login?error=please click on the link in the email we sent you before logging in")
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	//the session waits until they get past /login/2fa
+	if u.TwoFactor() {
+		http.SetCookie(w, getTwoFactorCookie(u.Email))
+		w.Header().Set("Location", "/login/2fa")
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	return startSession(w, req, &u)
+}
+
+func startSession(w http.ResponseWriter, req *http.Request, u *store.User) error {
+	ip := remoteIP(req)
+	if err := store.LoginSucceeded(u.Email); err != nil {
+		return err
+	}
+
+	s, err := store.NewSession(u.Email, ip, req.UserAgent())
+	if err != nil {
+		return err
 	}
 
-	http.SetCookie(w, getCookie(u.Email))
-	if isAdmin(&u) {
+	http.SetCookie(w, getCookie(s))
+	if isStaff(u) && !twoFactorDone(u) {
+		w.Header().Set("Location", "/admin/2fa")
+	} else if isStaff(u) {
 		w.Header().Set("Location", "/admin")
+	} else if isCustomer(u) {
+		w.Header().Set("Location", "/account")
 	} else {
 		w.Header().Set("Location", "/wholesale")
 	}
@@ -66,9 +110,130 @@ func DoLogin(w http.ResponseWriter, req *http.Request) error {
 	return nil
 }
 
+// TwoFactorLogin asks for the code from an authenticator app after the
+// password has been checked.
+func TwoFactorLogin(w http.ResponseWriter, req *http.Request) error {
+	if _, err := getTwoFactorEmail(req); err != nil {
+		w.Header().Set("Location", "/login")
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	p := loginPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Head:  html["head"],
+		},
+		Error: req.URL.Query().Get("error"),
+	}
+
+	return templates.Get("login-2fa.html").ExecuteTemplate(w, "base", p)
+}
+
+func DoTwoFactorLogin(w http.ResponseWriter, req *http.Request) error {
+	em, err := getTwoFactorEmail(req)
+	if err != nil {
+		w.Header().Set("Location", "/login?error=please log in again")
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	ip := remoteIP(req)
+	wait, err := store.CheckLogin(ip, em)
+	if err != nil {
+		return err
+	}
+
+	if wait > 0 {
+		return tooManyLogins(w, "/login/2fa", wait)
+	}
+
+	u := store.User{Email: em}
+	if err := u.Fetch(); err != nil {
+		return err
+	}
+
+	ok, err := u.CheckTwoFactor(req.FormValue("code"))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		if err := loginFailed(ip, em); err != errInvalidLogin {
+			return err
+		}
+		w.Header().Set("Location", "/login/2fa?error=that code didn't work")
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	clearTwoFactorCookie(w)
+	return startSession(w, req, &u)
+}
+
+// loginFailed counts the failure and emails the owner of the account if
+// it is now locked.
+func loginFailed(ip, em string) error {
+	locked, err := store.LoginFailed(ip, em)
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		if err := sendLockoutEmail(em); err != nil {
+			lg.Println("couldn't send lockout email to", em, err)
+		}
+	}
+
+	return errInvalidLogin
+}
+
+func tooManyLogins(w http.ResponseWriter, pth string, wait time.Duration) error {
+	msg := fmt.Sprintf("too many failed logins, please try again in %s", (wait + time.Second - 1).Truncate(time.Second))
+	w.Header().Set("Location", fmt.Sprintf("%s?error=%s", pth, url.QueryEscape(msg)))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func sendLockoutEmail(em string) error {
+	//don't send mail to addresses that were just guesses
+	u := store.User{Email: em}
+	if err := u.Fetch(); err == store.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	body := `Dear %s,
+There have been %d failed attempts to log in to your %s account, so
+logging in has been locked for %s.
+
+If this was you, you can try again after that or reset your password at
+https://%s/login/reset.  If it wasn't you, you may want to change your
+password.
+
+Sincerely,
+%s
+`
+	m := email.Msg{
+		To:      em,
+		From:    cfg.Email,
+		Subject: fmt.Sprintf("%s account locked", cfg.Name),
+		Body:    fmt.Sprintf(body, em, cfg.LoginMaxAttempts, cfg.Domains[0], cfg.LoginLockout, cfg.Domains[0], cfg.Domains[0]),
+	}
+
+	return email.Send(m)
+}
+
 func Logout(w http.ResponseWriter, req *http.Request) error {
 	p := loginPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Admin: Admin(req),
 			Head:  html["head"],
@@ -78,13 +243,26 @@ func Logout(w http.ResponseWriter, req *http.Request) error {
 }
 
 func DoLogout(w http.ResponseWriter, req *http.Request) error {
-	cookie := &http.Cookie{
-		Name:   authCookieName,
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
+	if s := getSession(req); s != nil {
+		if err := store.DeleteSession(s.ID); err != nil {
+			return err
+		}
 	}
-	http.SetCookie(w, cookie)
+
+	clearCookie(w)
+	w.Header().Set("Location", "/")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// DoLogoutEverywhere ends every session the user has, e.g. after
+// logging in on a computer they don't own.
+func DoLogoutEverywhere(w http.ResponseWriter, req *http.Request) error {
+	if err := store.DeleteSessions(getUser(req).Email); err != nil {
+		return err
+	}
+
+	clearCookie(w)
 	w.Header().Set("Location", "/")
 	w.WriteHeader(http.StatusFound)
 	return nil
@@ -93,6 +271,7 @@ func DoLogout(w http.ResponseWriter, req *http.Request) error {
 func ResetPage(w http.ResponseWriter, req *http.Request) error {
 	p := loginPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Admin: Admin(req),
 			Head:  html["head"],
@@ -168,6 +347,7 @@ func ResetPassword(w http.ResponseWriter, req *http.Request) error {
 
 	p := loginPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Admin: Admin(req),
 			Head:  html["head"],
@@ -207,6 +387,11 @@ func DoResetPassword(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
+	//whoever had the old password shouldn't stay logged in
+	if err := store.DeleteSessions(u.Email); err != nil {
+		return err
+	}
+
 	w.Header().Set("Location", "/wholesale")
 	w.WriteHeader(http.StatusFound)
 	return nil
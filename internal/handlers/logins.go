@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+)
+
+type loginsPage struct {
+	page
+	Attempts []store.LoginAttempts
+}
+
+// AdminLogins lists the IP addresses and accounts with failed logins so
+// they can be unlocked.
+func AdminLogins(w http.ResponseWriter, req *http.Request) error {
+	attempts, err := store.GetLoginAttempts()
+	if err != nil {
+		return err
+	}
+
+	p := loginsPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Attempts: attempts,
+	}
+
+	return templates.Get("admin/logins.html").ExecuteTemplate(w, "base", p)
+}
+
+func AdminUnlock(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	var before *store.LoginAttempts
+	attempts, err := store.GetLoginAttempts()
+	if err != nil {
+		return err
+	}
+
+	for i, a := range attempts {
+		if a.Key == vars["key"] {
+			before = &attempts[i]
+		}
+	}
+
+	err = audited(req, "login.unlock", vars["key"], before, nil, func() error {
+		return store.Unlock(vars["key"])
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/logins")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/schema"
+)
+
+type menuPage struct {
+	page
+	Items     []store.MenuItem
+	New       store.MenuItem
+	Audiences []string
+	Error     string
+}
+
+// AdminMenu is where the navbar is edited.
+func AdminMenu(w http.ResponseWriter, req *http.Request) error {
+	items, err := store.GetMenu()
+	if err != nil {
+		return err
+	}
+
+	roles, err := store.GetRoles()
+	if err != nil {
+		return err
+	}
+
+	audiences := append([]string{}, store.Audiences...)
+	for _, r := range roles {
+		audiences = append(audiences, r.Name)
+	}
+
+	p := menuPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Name:  cfg.Name,
+			Head:  html["head"],
+		},
+		Items:     items,
+		Audiences: audiences,
+		Error:     req.URL.Query().Get("error"),
+	}
+
+	return templates.Get("admin/menu.html").ExecuteTemplate(w, "base", p)
+}
+
+type menuForm struct {
+	Items []store.MenuItem `schema:"items"`
+}
+
+// AdminMenuUpdate saves the navbar.  The items come in the order they
+// are shown, without the ones marked for removal and the blank one for
+// adding a new item.
+func AdminMenuUpdate(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	var f menuForm
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	if err := dec.Decode(&f, req.PostForm); err != nil {
+		return err
+	}
+
+	remove := map[int]bool{}
+	for _, s := range req.PostForm["remove"] {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		remove[i] = true
+	}
+
+	items := []store.MenuItem{}
+	for i, m := range f.Items {
+		if remove[i] || (m.Name == "" && m.Link == "") {
+			continue
+		}
+		items = append(items, m)
+	}
+
+	before, err := store.GetMenu()
+	if err != nil {
+		return err
+	}
+
+	var saveErr error
+	err = audited(req, "menu.update", "navbar", before, items, func() error {
+		saveErr = store.SaveMenu(items)
+		return saveErr
+	})
+	if saveErr != nil {
+		return menuError(w, saveErr.Error())
+	} else if err != nil {
+		return err
+	}
+
+	makeNavbarLinks()
+	w.Header().Set("Location", "/admin/menu")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func menuError(w http.ResponseWriter, msg string) error {
+	w.Header().Set("Location", "/admin/menu?error="+url.QueryEscape(msg))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
@@ -1,11 +1,8 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
 
 	"github.com/cswank/store/internal/store"
 )
@@ -15,25 +12,43 @@ type link struct {
 	Name     string
 	Link     string
 	HasLink  bool
+	External bool
 	Style    string
+	//Post links are buttons in a form, for things a GET shouldn't change
+	Post     bool
 	Children []link
 }
 
+// getNavbarLinks is the menu saved in the admin, as much of it as the
+// user gets to see, followed by the links that depend on who they are.
 func getNavbarLinks(req *http.Request) []link {
-	l := []link{
-		{Name: "Home", Link: "/"},
-		{Name: "Shop", Link: "/shop", Children: getShoppingLinks()},
-		//{Name: "Blog", Link: "/blog"},
-		//{Name: "About", Link: "/about"},
-		{Name: "Wholesale", Link: "/wholesale"},
-		{Name: "Contact", Link: "/contact"},
-		{Name: "Cart", Link: "/cart"},
+	var l []link
+	for _, m := range getMenu() {
+		if m.Hidden || !inAudience(req, m) {
+			continue
+		}
+
+		lnk := link{Name: m.Name, Link: m.Link, External: m.External()}
+		if m.Shop {
+			shop := append([]link{}, getShoppingLinks()...)
+			lnk.Children = append(shop, getPageLinks(store.NavShop)...)
+		}
+		l = append(l, lnk)
 	}
+	l = append(l, getPageLinks(store.NavMain)...)
 
-	if Admin(req) {
+	if c := getCurrencyLinks(req); c != nil {
+		l = append(l, *c)
+	}
+
+	if Staff(req) {
 		l = append(l, link{Name: "Admin", Link: "/admin"})
 	}
 
+	if getUser(req) == nil || Customer(req) {
+		l = append(l, link{Name: "Account", Link: "/account"})
+	}
+
 	if Read(req) {
 		l = append(l, link{Name: "Logout", Link: "/logout", Style: "float:right"})
 	}
@@ -44,6 +59,8 @@ func getNavbarLinks(req *http.Request) []link {
 func makeNavbarLinks() {
 	lock.Lock()
 	shoppingLinks = nil
+	pageLinks = nil
+	menu = nil
 	lock.Unlock()
 	getShoppingLinks()
 }
@@ -52,7 +69,7 @@ func getShoppingLinks() []link {
 	return getDBShoppingLinks()
 }
 
-func getMenuShoppingLinks() []link {
+func getDBShoppingLinks() []link {
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -60,67 +77,105 @@ func getMenuShoppingLinks() []link {
 		return shoppingLinks
 	}
 
-	f, err := os.Open(cfg.ShoppingMenu)
+	cats, err := store.GetCategoryTree()
 	if err != nil {
-		log.Fatal("could not open shopping menu", cfg.ShoppingMenu, err)
-	}
-	defer f.Close()
-
-	if err := json.NewDecoder(f).Decode(&shoppingLinks); err != nil {
-		log.Fatal("could not decode shopping menu", err)
+		lg.Println("error getting cats", err)
+		return nil
 	}
 
+	shoppingLinks = getCategoryLinks(cats, 0)
 	return shoppingLinks
 }
 
-func getDBShoppingLinks() []link {
+// getCategoryLinks flattens the category tree into a list.  Top level
+// categories are headings and the ones below them are indented by their
+// depth.
+func getCategoryLinks(cats []store.Category, depth int) []link {
+	var l []link
+	for _, c := range cats {
+		lnk := link{Name: c.Name, Link: shopLink(c.Path), HasLink: true, Category: depth == 0}
+		if depth > 1 {
+			lnk.Style = fmt.Sprintf("padding-left: %dem", depth-1)
+		}
+		l = append(l, lnk)
+		l = append(l, getCategoryLinks(c.Children, depth+1)...)
+	}
+	return l
+}
+
+// getPageLinks links to the pages that go in the nav.
+func getPageLinks(nav store.PageNav) []link {
 	lock.Lock()
 	defer lock.Unlock()
 
-	if shoppingLinks != nil {
-		return shoppingLinks
+	if pageLinks != nil {
+		return pageLinks[nav]
 	}
 
-	cats, err := store.GetCategories()
+	pages, err := store.Pages()
 	if err != nil {
-		lg.Println("error getting cats")
+		lg.Println("error getting pages", err)
 		return nil
 	}
 
-	var l []link
-
-	for _, cat := range cats {
-		scl := getSubcatLinks(cat)
-		l = append(l, link{Category: true, Name: cat, Link: fmt.Sprintf("/shop/%s", cat), HasLink: true})
-		if len(scl) > 0 {
-			l = append(l, scl...)
+	pageLinks = map[store.PageNav][]link{}
+	for _, p := range pages {
+		if p.Nav != store.NavNone {
+			pageLinks[p.Nav] = append(pageLinks[p.Nav], link{Name: p.Title, Link: pageLink(p.Slug), HasLink: true})
 		}
 	}
-
-	shoppingLinks = l
-	return shoppingLinks
+	return pageLinks[nav]
 }
 
-func getSubcatLinks(cat string) []link {
+func getMenu() []store.MenuItem {
+	lock.Lock()
+	defer lock.Unlock()
 
-	subcats, err := store.GetSubCategories(cat)
+	if menu != nil {
+		return menu
+	}
+
+	items, err := store.GetMenu()
 	if err != nil {
-		lg.Println("error getting subcats")
-		return nil
+		lg.Println("error getting menu", err)
+		return store.DefaultMenu
 	}
 
-	l := make([]link, len(subcats))
+	menu = items
+	return menu
+}
 
-	for i, subcat := range subcats {
-		if subcat == "NOSUBCATEGORIES" {
-			return []link{}
-		}
+// inAudience is true if the user is one of the people the menu item is
+// for.
+func inAudience(req *http.Request, m store.MenuItem) bool {
+	if len(m.Audiences) == 0 {
+		return true
+	}
 
-		l[i] = link{
-			Link: fmt.Sprintf("/shop/%s/%s", cat, subcat),
-			Name: subcat,
+	user := getUser(req)
+	for _, a := range m.Audiences {
+		switch a {
+		case store.AudienceGuests:
+			if user == nil {
+				return true
+			}
+		case store.AudienceCustomers:
+			if Customer(req) {
+				return true
+			}
+		case store.AudienceWholesalers:
+			if Wholesaler(req) {
+				return true
+			}
+		case store.AudienceStaff:
+			if Staff(req) {
+				return true
+			}
+		default:
+			if user != nil && user.HasRole(a) {
+				return true
+			}
 		}
 	}
-
-	return l
+	return false
 }
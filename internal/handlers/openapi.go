@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cswank/store/internal/api"
+	"github.com/cswank/store/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// apiDoc is what the route table can't say about an /api/v1 route.  The
+// key in apiDocs is the route's name, which becomes its operationId.
+type apiDoc struct {
+	summary  string
+	scope    store.Capability
+	request  interface{}
+	response interface{}
+	status   int
+	query    []string
+}
+
+var (
+	apiDocs = map[string]apiDoc{
+		"listCategories":    {summary: "List the top level categories and their prices", response: []api.Category{}},
+		"createCategory":    {summary: "Create a top level category", scope: store.CatalogWrite, request: api.Category{}, response: api.Category{}, status: http.StatusCreated},
+		"getCategory":       {summary: "Get a category, its price and the names of its subcategories and products", response: api.Category{}},
+		"createSubcategory": {summary: "Create a category in this one", scope: store.CatalogWrite, request: api.Category{}, response: api.Category{}, status: http.StatusCreated},
+		"renameCategory":    {summary: "Rename a category", scope: store.CatalogWrite, request: api.Category{}, response: api.Category{}},
+		"deleteCategory":    {summary: "Delete a category and everything in it", scope: store.CatalogWrite, status: http.StatusNoContent},
+		"getPrice":          {summary: "Get a category's price, which can come from a parent", response: api.Price{}},
+		"setPrice":          {summary: "Set a category's price", scope: store.PricingWrite, request: api.Price{}, response: api.Price{}},
+		"listProducts":      {summary: "List the products in a category", query: []string{"category"}, response: []api.Product{}},
+		"createProduct":     {summary: "Create a product in a category, image is required", scope: store.CatalogWrite, request: api.Product{}, response: api.Product{}, status: http.StatusCreated},
+		"getProduct":        {summary: "Get a product", response: api.Product{}},
+		"updateProduct":     {summary: "Update a product or move it to the category at its path", scope: store.CatalogWrite, request: api.Product{}, response: api.Product{}},
+		"deleteProduct":     {summary: "Delete a product", scope: store.CatalogWrite, status: http.StatusNoContent},
+		"listBlogs":         {summary: "List the blogs, newest first", response: []api.Blog{}},
+		"createBlog":        {summary: "Create a blog dated today", scope: store.BlogWrite, request: api.Blog{}, response: api.Blog{}, status: http.StatusCreated},
+		"getBlog":           {summary: "Get a blog", response: api.Blog{}},
+		"updateBlog":        {summary: "Update a blog, which changes its id if the title or date change", scope: store.BlogWrite, request: api.Blog{}, response: api.Blog{}},
+		"deleteBlog":        {summary: "Delete a blog", scope: store.BlogWrite, status: http.StatusNoContent},
+	}
+
+	pathVar  = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+	timeType = reflect.TypeOf(time.Time{})
+	apiPkg   = reflect.TypeOf(api.Error{}).PkgPath()
+)
+
+// OpenAPI serves an OpenAPI 3 document for the /api/v1 routes in r.
+func OpenAPI(r *mux.Router) HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) error {
+		doc, err := openAPI(r)
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, http.StatusOK, doc)
+	}
+}
+
+type object map[string]interface{}
+
+func openAPI(r *mux.Router) (object, error) {
+	schemas := object{}
+	errRef := schemaFor(reflect.TypeOf(api.Error{}), schemas)
+	paths := object{}
+
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(tpl, "/api/v1/") {
+			return nil
+		}
+
+		method, err := routeMethod(route, tpl)
+		if err != nil || method == "" {
+			return err
+		}
+
+		doc := apiDocs[route.GetName()]
+		op := object{
+			"operationId": route.GetName(),
+			"summary":     doc.summary,
+			"responses": object{
+				"default": object{
+					"description": "error",
+					"content":     object{"application/json": object{"schema": errRef}},
+				},
+			},
+		}
+
+		if doc.scope != "" {
+			op["description"] = "Needs an api key with the " + string(doc.scope) + " scope."
+		}
+
+		var params []object
+		for _, m := range pathVar.FindAllStringSubmatch(tpl, -1) {
+			param := object{"name": m[1], "in": "path", "required": true, "schema": object{"type": "string"}}
+			if m[1] == "path" {
+				param["description"] = "category names, then a product's title for products, separated by /"
+			}
+			params = append(params, param)
+		}
+		for _, q := range doc.query {
+			params = append(params, object{"name": q, "in": "query", "required": true, "schema": object{"type": "string"}})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if doc.request != nil {
+			op["requestBody"] = object{
+				"required": true,
+				"content":  object{"application/json": object{"schema": schemaFor(reflect.TypeOf(doc.request), schemas)}},
+			}
+		}
+
+		status := doc.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		resp := object{"description": http.StatusText(status)}
+		if doc.response != nil {
+			resp["content"] = object{"application/json": object{"schema": schemaFor(reflect.TypeOf(doc.response), schemas)}}
+		}
+		op["responses"].(object)[strconv.Itoa(status)] = resp
+
+		key := pathVar.ReplaceAllString(tpl, "{$1}")
+		p, ok := paths[key].(object)
+		if !ok {
+			p = object{}
+			paths[key] = p
+		}
+		p[strings.ToLower(method)] = op
+		return nil
+	})
+
+	return object{
+		"openapi": "3.0.0",
+		"info":    object{"title": name + " API", "version": "1"},
+		"paths":   paths,
+		"security": []object{
+			{"apiKey": []string{}},
+		},
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"apiKey": object{"type": "http", "scheme": "bearer", "description": "an api key from /admin/apikeys"},
+			},
+		},
+	}, err
+}
+
+// routeMethod finds the method a route was registered with.  This
+// version of mux doesn't say, so it asks the route which one it matches.
+func routeMethod(route *mux.Route, tpl string) (string, error) {
+	var pairs []string
+	for _, m := range pathVar.FindAllStringSubmatch(tpl, -1) {
+		pairs = append(pairs, m[1], "x")
+	}
+
+	u, err := route.URLPath(pairs...)
+	if err != nil {
+		return "", err
+	}
+
+	for _, method := range []string{"GET", "POST", "PUT", "DELETE"} {
+		req, err := http.NewRequest(method, u.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if route.Match(req, &mux.RouteMatch{}) {
+			return method, nil
+		}
+	}
+	return "", nil
+}
+
+// schemaFor describes t with a json schema.  Structs from the api package
+// go in schemas and are referred to by name.
+func schemaFor(t reflect.Type, schemas object) object {
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Slice:
+		return object{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case t.Kind() == reflect.String:
+		return object{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return object{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return object{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return object{"type": "number"}
+	case t.Kind() != reflect.Struct:
+		return object{}
+	}
+
+	ref := object{"$ref": "#/components/schemas/" + t.Name()}
+	if t.PkgPath() == apiPkg {
+		if _, ok := schemas[t.Name()]; ok {
+			return ref
+		}
+	}
+
+	props := object{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		props[tag] = schemaFor(f.Type, schemas)
+	}
+
+	s := object{"type": "object", "properties": props}
+	if t.PkgPath() != apiPkg {
+		return s
+	}
+
+	schemas[t.Name()] = s
+	return ref
+}
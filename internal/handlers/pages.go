@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+type cmsPage struct {
+	page
+	Title string
+	Body  template.HTML
+}
+
+// Page shows one of the pages written in the admin.
+func Page(w http.ResponseWriter, req *http.Request) error {
+	slug := mux.Vars(req)["page"]
+	if slug == store.HomePage {
+		return store.ErrNotFound
+	}
+
+	p, err := store.GetPage(slug)
+	if err != nil {
+		return err
+	}
+
+	return templates.Get("page.html").ExecuteTemplate(w, "base", cmsPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Admin: Admin(req),
+			Name:  cfg.Name,
+			Head:  html["head"],
+		},
+		Title: p.Title,
+		Body:  template.HTML(p.HTML),
+	})
+}
+
+// getPageHTML is the body of the page with the slug.  Until it has been
+// written in the admin it is the file that the site was started with.
+func getPageHTML(slug string) (template.HTML, error) {
+	p, err := store.GetPage(slug)
+	if err == store.ErrNotFound {
+		return html[slug], nil
+	} else if err != nil {
+		return "", err
+	}
+	return template.HTML(p.HTML), nil
+}
+
+func pageLink(slug string) string {
+	switch slug {
+	case store.HomePage:
+		return "/"
+	case store.AboutPage:
+		return "/about"
+	}
+	return (&url.URL{Path: "/" + slug}).String()
+}
+
+type adminPagesPage struct {
+	page
+	Pages []store.Page
+	Error string
+}
+
+// AdminPages lists the pages.
+func AdminPages(w http.ResponseWriter, req *http.Request) error {
+	pages, err := store.Pages()
+	if err != nil {
+		return err
+	}
+
+	p := adminPagesPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Name:  cfg.Name,
+			Head:  html["head"],
+		},
+		Pages: pages,
+		Error: req.URL.Query().Get("error"),
+	}
+
+	return templates.Get("admin/pages.html").ExecuteTemplate(w, "base", p)
+}
+
+type pageFormPage struct {
+	page
+	Action    string
+	New       bool
+	Page      store.Page
+	Link      string
+	Preview   template.HTML
+	Revisions []store.Page
+	Navs      []store.PageNav
+	Error     string
+}
+
+// PageForm is where a page is written.  {page} is new for a page that
+// doesn't exist yet, or a revision number can be passed in the revision
+// arg to look at an older version before restoring it.
+func PageForm(w http.ResponseWriter, req *http.Request) error {
+	slug := mux.Vars(req)["page"]
+	p := pageFormPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Links: getNavbarLinks(req),
+			Name:  cfg.Name,
+			Head:  html["head"],
+		},
+		Action: "/admin/pages",
+		New:    slug == "new",
+		Navs:   []store.PageNav{store.NavNone, store.NavMain, store.NavShop},
+		Error:  req.URL.Query().Get("error"),
+	}
+
+	if !p.New {
+		var err error
+		if p.Page, err = store.GetPage(slug); err == store.ErrNotFound && (slug == store.HomePage || slug == store.AboutPage) {
+			//the page hasn't been written yet, so it is still the file
+			p.New = true
+			p.Page = store.Page{Slug: slug, Title: slug}
+		} else if err != nil {
+			return err
+		} else {
+			p.Action = "/admin/pages/" + slug
+		}
+
+		if p.Revisions, err = store.PageRevisions(slug); err != nil {
+			return err
+		}
+	}
+
+	if r := req.URL.Query().Get("revision"); r != "" {
+		n, err := strconv.Atoi(r)
+		if err != nil {
+			return store.ErrNotFound
+		}
+
+		if p.Page, err = store.GetPageRevision(slug, n); err != nil {
+			return err
+		}
+	}
+
+	if p.Page.Slug != "" && p.Page.Slug != store.HomePage {
+		p.Link = pageLink(p.Page.Slug)
+	}
+	p.Preview = template.HTML(store.RenderPage(p.Page.Body))
+
+	return templates.Get("admin/page-form.html").ExecuteTemplate(w, "base", p)
+}
+
+// PagePreview renders the body form field the way the page will show it.
+func PagePreview(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := w.Write([]byte(store.RenderPage(req.PostFormValue("body"))))
+	return err
+}
+
+// SavePage creates a page, or saves a new version of the {page}.
+func SavePage(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	var p store.Page
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	if err := dec.Decode(&p, req.PostForm); err != nil {
+		return err
+	}
+
+	action := "page.create"
+	var before interface{}
+	if slug, ok := mux.Vars(req)["page"]; ok {
+		old, err := store.GetPage(slug)
+		if err != nil {
+			return err
+		}
+
+		action = "page.update"
+		before = old
+		p.Slug = slug
+	} else if _, err := store.GetPage(p.Slug); err == nil {
+		return pageError(w, "new", fmt.Sprintf("there is already a page at /%s", p.Slug))
+	}
+
+	var saveErr error
+	err := audited(req, action, p.Slug, before, p, func() error {
+		saveErr = p.Save(actor(req))
+		return saveErr
+	})
+	if saveErr != nil {
+		slug := mux.Vars(req)["page"]
+		if slug == "" {
+			slug = "new"
+		}
+		return pageError(w, slug, saveErr.Error())
+	} else if err != nil {
+		return err
+	}
+
+	makeNavbarLinks()
+	w.Header().Set("Location", "/admin/pages")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// RestorePage makes revision {revision} the newest version of the
+// {page}.
+func RestorePage(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	n, err := strconv.Atoi(vars["revision"])
+	if err != nil {
+		return store.ErrNotFound
+	}
+
+	before, err := store.GetPage(vars["page"])
+	if err != nil && err != store.ErrNotFound {
+		return err
+	}
+
+	p, err := store.GetPageRevision(vars["page"], n)
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "page.restore", fmt.Sprintf("%s@%d", p.Slug, n), before, p, func() error {
+		_, err := store.RestorePage(vars["page"], n, actor(req))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	makeNavbarLinks()
+	w.Header().Set("Location", "/admin/pages/"+p.Slug)
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// DeletePage deletes the {page} and its history.  The home and about
+// pages go back to the files the site was started with.
+func DeletePage(w http.ResponseWriter, req *http.Request) error {
+	slug := mux.Vars(req)["page"]
+	p, err := store.GetPage(slug)
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "page.delete", slug, p, nil, func() error {
+		return store.DeletePage(slug)
+	})
+	if err != nil {
+		return err
+	}
+
+	makeNavbarLinks()
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func pageError(w http.ResponseWriter, slug, msg string) error {
+	w.Header().Set("Location", "/admin/pages/"+slug+"?error="+url.QueryEscape(msg))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+)
+
+type rolesPage struct {
+	page
+	Roles        []store.Role
+	Capabilities []store.Capability
+	Staff        []store.User
+	Error        string
+}
+
+// AdminRoles lists the roles, what each one can do, and who has them.
+func AdminRoles(w http.ResponseWriter, req *http.Request) error {
+	roles, err := store.GetRoles()
+	if err != nil {
+		return err
+	}
+
+	users, err := store.GetUsers()
+	if err != nil && err != store.ErrNotFound {
+		return err
+	}
+
+	var staff []store.User
+	for _, u := range users {
+		if len(u.Roles) > 0 {
+			staff = append(staff, u)
+		}
+	}
+
+	p := rolesPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Roles:        roles,
+		Capabilities: store.Capabilities,
+		Staff:        staff,
+		Error:        req.URL.Query().Get("error"),
+	}
+
+	return templates.Get("admin/roles.html").ExecuteTemplate(w, "base", p)
+}
+
+// AdminRoleUpdate creates a role or replaces its capabilities.
+func AdminRoleUpdate(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	r := store.Role{Name: req.PostFormValue("name")}
+	for _, c := range req.PostForm["capabilities"] {
+		r.Capabilities = append(r.Capabilities, store.Capability(c))
+	}
+
+	var saveErr error
+	err := audited(req, "role.update", r.Name, nil, r, func() error {
+		saveErr = r.Save()
+		return saveErr
+	})
+	if saveErr != nil {
+		return rolesError(w, saveErr.Error())
+	} else if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/roles")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func AdminRoleDelete(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	err := audited(req, "role.delete", vars["role"], nil, nil, func() error {
+		return store.DeleteRole(vars["role"])
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/roles")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// AdminStaffUpdate sets the roles for an existing user.  Unchecking all
+// of them takes away their access to the admin pages.
+func AdminStaffUpdate(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	u := store.User{Email: req.PostFormValue("email")}
+	if err := u.Fetch(); err == store.ErrNotFound {
+		return rolesError(w, "there is no account for "+u.Email)
+	} else if err != nil {
+		return err
+	}
+
+	for _, name := range req.PostForm["roles"] {
+		if _, err := store.GetRole(name); err != nil {
+			return rolesError(w, "there is no role called "+name)
+		}
+	}
+
+	before := u.Roles
+	u.Roles = req.PostForm["roles"]
+	err := audited(req, "staff.update", u.Email, before, u.Roles, func() error {
+		return u.Save()
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/roles")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func rolesError(w http.ResponseWriter, msg string) error {
+	w.Header().Set("Location", "/admin/roles?error="+url.QueryEscape(msg))
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/cswank/store/internal/store"
+)
+
+// PublishScheduled publishes the scheduled products and blogs whose time
+// has come, checking every interval.  It never returns.
+func PublishScheduled(interval time.Duration) {
+	for range time.Tick(interval) {
+		n, err := store.PublishScheduled(time.Now())
+		if err != nil {
+			log.Println("couldn't publish scheduled products and blogs:", err)
+			continue
+		}
+
+		if n > 0 {
+			log.Printf("published %d scheduled products and blogs\n", n)
+			makeNavbarLinks()
+		}
+	}
+}
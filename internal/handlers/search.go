@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+)
+
+const (
+	searchLimit  = 50
+	suggestLimit = 8
+)
+
+type searchPage struct {
+	page
+	Query   string
+	Results []store.SearchResult
+}
+
+// Search shows the products and blogs that match the q arg.
+func Search(w http.ResponseWriter, req *http.Request) error {
+	q := strings.TrimSpace(req.URL.Query().Get("q"))
+	results, err := store.Search(q, searchLimit)
+	if err != nil {
+		return err
+	}
+
+	p := searchPage{
+		page: page{
+			CSRF:    csrfToken(req),
+			Links:   getNavbarLinks(req),
+			Admin:   Admin(req),
+			Shopify: shopifyKey,
+			Name:    name,
+			Head:    html["head"],
+		},
+		Query:   q,
+		Results: results,
+	}
+
+	return templates.Get("search.html").ExecuteTemplate(w, "base", p)
+}
+
+// SearchSuggest is the typeahead for the search box.  It returns the
+// best few matches for what has been typed so far.
+func SearchSuggest(w http.ResponseWriter, req *http.Request) error {
+	results, err := store.Search(req.URL.Query().Get("q"), suggestLimit)
+	if err != nil {
+		return err
+	}
+
+	for i := range results {
+		results[i].Snippet = ""
+	}
+
+	if results == nil {
+		results = []store.SearchResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(results)
+}
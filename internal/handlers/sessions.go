@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+)
+
+type sessionsPage struct {
+	page
+	Email    string
+	Sessions []store.Session
+}
+
+// AdminSessions lists everyone who is logged in, or just one user's
+// sessions when the email arg is set.
+func AdminSessions(w http.ResponseWriter, req *http.Request) error {
+	email := req.URL.Query().Get("email")
+	sessions, err := store.GetSessions(email)
+	if err != nil {
+		return err
+	}
+
+	p := sessionsPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Email:    email,
+		Sessions: sessions,
+	}
+
+	return templates.Get("admin/sessions.html").ExecuteTemplate(w, "base", p)
+}
+
+func AdminSessionDelete(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	s, err := store.GetSession(vars["session"])
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "session.delete", s.Email, auditSessions(s), nil, func() error {
+		return store.DeleteSession(vars["session"])
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/sessions")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// AdminUserSessionsDelete logs a user out everywhere.
+func AdminUserSessionsDelete(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	sessions, err := store.GetSessions(vars["email"])
+	if err != nil {
+		return err
+	}
+
+	err = audited(req, "session.delete", vars["email"], auditSessions(sessions...), nil, func() error {
+		return store.DeleteSessions(vars["email"])
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/sessions")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cswank/store/internal/money"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+)
+
+type shippingPage struct {
+	page
+	Zones []shippingZone
+}
+
+// shippingZone is a store.ShippingZone flattened into the strings the
+// admin form works with.
+type shippingZone struct {
+	Name      string
+	Countries string
+	States    string
+	Rates     string
+}
+
+func AdminShipping(w http.ResponseWriter, req *http.Request) error {
+	zones, err := store.GetShippingZones()
+	if err != nil {
+		return err
+	}
+
+	z := make([]shippingZone, len(zones))
+	for i, zone := range zones {
+		rates := make([]string, len(zone.Rates))
+		for j, r := range zone.Rates {
+			rates[j] = fmt.Sprintf("%d %s", r.MaxWeight, r.Price)
+		}
+		z[i] = shippingZone{
+			Name:      zone.Name,
+			Countries: strings.Join(zone.Countries, ", "),
+			States:    strings.Join(zone.States, ", "),
+			Rates:     strings.Join(rates, "\n"),
+		}
+	}
+
+	p := shippingPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Zones: z,
+	}
+
+	return templates.Get("admin/shipping.html").ExecuteTemplate(w, "base", p)
+}
+
+func AdminShippingUpdate(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	rates, err := parseRates(req.FormValue("rates"))
+	if err != nil {
+		return err
+	}
+
+	z := store.ShippingZone{
+		Name:      strings.TrimSpace(req.FormValue("name")),
+		Countries: splitList(req.FormValue("countries")),
+		States:    splitList(req.FormValue("states")),
+		Rates:     rates,
+	}
+
+	err = audited(req, "shipping.update", z.Name, nil, z, func() error {
+		return z.Save()
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/shipping")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func AdminShippingDelete(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	err := audited(req, "shipping.delete", vars["zone"], nil, nil, func() error {
+		return store.DeleteShippingZone(vars["zone"])
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/shipping")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// CartShipping estimates shipping for the weight of the items in the
+// cart.  The cart lives in the browser so it passes the weight along.
+func CartShipping(w http.ResponseWriter, req *http.Request) error {
+	args := req.URL.Query()
+	weight, err := strconv.Atoi(args.Get("weight"))
+	if err != nil {
+		return err
+	}
+
+	addr := store.Address{
+		Country: args.Get("country"),
+		State:   args.Get("state"),
+	}
+
+	price, err := store.ShippingCost(addr, weight)
+	if err == store.ErrNoShipping {
+		w.WriteHeader(http.StatusNotFound)
+		return json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	} else if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(map[string]string{"price": price.String()})
+}
+
+// parseRates reads one "<max weight> <price>" pair per line.
+func parseRates(s string) ([]store.ShippingRate, error) {
+	var rates []store.ShippingRate
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid shipping rate: %q", line)
+		}
+
+		max, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid shipping weight: %q", line)
+		}
+
+		price, err := money.Parse(fields[1], cfg.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shipping price: %q", line)
+		}
+
+		rates = append(rates, store.ShippingRate{MaxWeight: max, Price: price})
+	}
+	return rates, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"path"
 	"strconv"
+	"strings"
 
+	"github.com/cswank/store/internal/money"
 	"github.com/cswank/store/internal/store"
 	"github.com/cswank/store/internal/templates"
 	"github.com/gorilla/mux"
@@ -26,6 +28,9 @@ type cartPage struct {
 	Price             string
 	DiscountCode      string
 	UnderConstruction bool
+	Country           string
+	State             string
+	Currency          store.Currency
 }
 
 func Cart(w http.ResponseWriter, req *http.Request) error {
@@ -37,6 +42,7 @@ func Cart(w http.ResponseWriter, req *http.Request) error {
 
 	p := cartPage{
 		page: page{
+			CSRF:    csrfToken(req),
 			Links:   getNavbarLinks(req),
 			Admin:   Admin(req),
 			Shopify: shopifyKey,
@@ -45,28 +51,46 @@ func Cart(w http.ResponseWriter, req *http.Request) error {
 		},
 		DiscountCode:      dc,
 		UnderConstruction: cfg.UnderConstruction,
+		Currency:          getCurrency(req),
 	}
+
+	if u := getUser(req); u != nil {
+		addr := shipTo(u)
+		p.Country = addr.Country
+		p.State = addr.State
+	}
+
 	return templates.Get("cart.html").ExecuteTemplate(w, "base", p)
 }
 
-func getPrice(req *http.Request, price store.Price) string {
+// getPrice is the shopper's price in the store's currency.
+func getPrice(req *http.Request, price store.Price) money.Money {
+	amount := price.Price
 	if Wholesaler(req) {
-		return price.WholesalePrice
+		amount = price.WholesalePrice
 	}
-	return price.Price
+	return toBase(amount)
 }
 
-func LineItem(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, vars := getVars(req)
-
-	x, err := store.GetPrice(cat)
+func toBase(amount money.Money) money.Money {
+	a, err := store.ToBase(amount)
 	if err != nil {
-		return err
+		lg.Println("couldn't convert price", amount, amount.Currency, err)
+		return amount
 	}
+	return a
+}
 
-	price := getPrice(req, x)
-	p := store.NewProduct(vars["title"], cat, subcat, store.ProductPrice(price))
-	if err := p.Fetch(); err != nil {
+// lineItem is a product in the cart with its total in the shopper's
+// currency.
+type lineItem struct {
+	*store.Product
+	Total string
+}
+
+func LineItem(w http.ResponseWriter, req *http.Request) error {
+	p, err := getProductPrice(req)
+	if err != nil {
 		return err
 	}
 
@@ -83,14 +107,9 @@ func LineItem(w http.ResponseWriter, req *http.Request) error {
 	}
 
 	p.Quantity = int(q)
-	pr, err := strconv.ParseFloat(price, 10)
-	if err != nil {
-		return err
-	}
-
-	t := float64(q) * pr
-	p.Total = fmt.Sprintf("%.02f", t)
-	return templates.Get("lineitem.html").ExecuteTemplate(w, "lineitem.html", p)
+	p.Total = p.Price.Mul(p.Quantity)
+	item := lineItem{Product: p, Total: displayPrice(req, p.Total)}
+	return templates.Get("lineitem.html").ExecuteTemplate(w, "lineitem.html", item)
 }
 
 type shopPage struct {
@@ -102,6 +121,7 @@ func Shop(w http.ResponseWriter, req *http.Request) error {
 	p := shopPage{
 		Shopping: getShoppingLinks(),
 		page: page{
+			CSRF:    csrfToken(req),
 			Links:   getNavbarLinks(req),
 			Admin:   Admin(req),
 			Shopify: shopifyKey,
@@ -113,38 +133,63 @@ func Shop(w http.ResponseWriter, req *http.Request) error {
 
 type categoryPage struct {
 	page
+	Name          string
+	Breadcrumbs   []link
 	SubCategories []link
-	Products      map[string][]product
+	Products      []product
+	Groups        []productGroup
+	Facets        *facets
 }
 
-func Category(w http.ResponseWriter, req *http.Request) error {
-	cat, _, _ := getVars(req)
+// productGroup is the products in one of the categories below the one
+// being shown.  They are only shown when the shopper is filtering.
+type productGroup struct {
+	Name     string
+	Link     string
+	Products []product
+}
 
-	subs, err := store.GetSubCategories(cat)
+// Category shows the category at the path, or the product if the path
+// ends with a product's title.
+func Category(w http.ResponseWriter, req *http.Request) error {
+	pth := getPath(req)
+	ok, err := store.IsCategory(pth)
 	if err != nil {
 		return err
+	} else if !ok {
+		return Product(w, req)
+	} else if len(pth) == 0 {
+		return store.ErrNotFound
 	}
 
-	if len(subs) == 1 && subs[0] == "NOSUBCATEGORIES" {
-		return showSubcategory(cat, subs[0], w, req)
-	}
-
-	links := getLinks(fmt.Sprintf("/shop/%s", cat), subs)
-
-	price, err := store.GetPrice(cat)
+	subs, err := store.GetSubCategories(pth...)
 	if err != nil {
 		return err
 	}
 
-	products, err := getProductsFromLinks(cat, links, getPrice(req, price))
+	f := getFacets(req)
+	products, err := getCategoryProducts(req, f, pth)
 	if err != nil {
 		return err
 	}
 
+	var groups []productGroup
+	if f.Active {
+		groups, err = getProductGroups(req, f, pth)
+		if err != nil {
+			return err
+		}
+	}
+
 	p := categoryPage{
-		SubCategories: links,
+		Name:          pth[len(pth)-1],
+		Breadcrumbs:   getBreadcrumbs(pth),
+		SubCategories: getLinks(shopLink(pth), subs),
 		Products:      products,
+		Groups:        groups,
+		Facets:        f.done(),
 		page: page{
+			CSRF:    csrfToken(req),
 			Admin:   Admin(req),
 			Links:   getNavbarLinks(req),
 			Shopify: shopifyKey,
@@ -155,16 +200,46 @@ func Category(w http.ResponseWriter, req *http.Request) error {
 	return templates.Get("category.html").ExecuteTemplate(w, "base", p)
 }
 
-func getProductsFromLinks(cat string, links []link, price string) (map[string][]product, error) {
-	m := map[string][]product{}
-	for _, l := range links {
-		prods, err := store.GetProducts(cat, l.Name)
-		if err != nil {
-			return nil, err
-		}
-		m[l.Name] = getProducts(cat, l.Name, prods, price)
+// getCategoryProducts returns the products that are directly in the
+// category at pth and pass the filters.
+func getCategoryProducts(req *http.Request, f *facets, pth []string) ([]product, error) {
+	prods, err := store.GetProducts(pth)
+	if err != nil {
+		return nil, err
+	}
+	prods = store.LiveProducts(prods)
+
+	price, err := store.GetPrice(pth)
+	if err != nil {
+		return nil, err
 	}
-	return m, nil
+
+	pr := getPrice(req, price)
+	return getProducts(pth, f.filter(req, prods, pr), pr, displayPrice(req, pr)), nil
+}
+
+// getProductGroups returns the products that pass the filters from every
+// category below the one at pth.
+func getProductGroups(req *http.Request, f *facets, pth []string) ([]productGroup, error) {
+	var groups []productGroup
+	err := store.WalkCategories(pth, func(p []string, _ []store.Product) error {
+		if len(p) == len(pth) {
+			return nil
+		}
+
+		prods, err := getCategoryProducts(req, f, p)
+		if err != nil || len(prods) == 0 {
+			return err
+		}
+
+		groups = append(groups, productGroup{
+			Name:     strings.Join(p[len(pth):], " / "),
+			Link:     shopLink(p),
+			Products: prods,
+		})
+		return nil
+	})
+	return groups, err
 }
 
 func getLinks(href string, names []string) []link {
@@ -175,74 +250,113 @@ func getLinks(href string, names []string) []link {
 	return links
 }
 
-type subCategoryPage struct {
-	page
-	Subcat   string
-	Products []product
-	Shopping []link
+// getBreadcrumbs links to the shop and to each category on the way to
+// the one at pth.
+func getBreadcrumbs(pth []string) []link {
+	l := []link{{Name: "Shop", Link: "/shop"}}
+	for i, n := range pth {
+		l = append(l, link{Name: n, Link: shopLink(pth[:i+1])})
+	}
+	return l
 }
 
-func getProducts(cat, subcat string, prods []store.Product, price string) []product {
+func shopLink(pth []string) string {
+	return "/shop/" + strings.Join(pth, "/")
+}
+
+func getProducts(pth []string, prods []store.Product, price money.Money, display string) []product {
 	out := make([]product, len(prods))
 	for i, p := range prods {
 		out[i] = product{
-			Title:  p.Title,
-			Image:  fmt.Sprintf("/shop/images/products/%s/thumb.png", p.Title),
-			Link:   fmt.Sprintf("/shop/%s/%s/%s", cat, subcat, p.Title),
-			Price:  price,
-			ID:     p.ID,
-			Cat:    cat,
-			Subcat: subcat,
+			Title:   p.Title,
+			Image:   fmt.Sprintf("/shop/images/products/%s/thumb.png", p.Title),
+			Link:    p.Link(),
+			Price:   price.String(),
+			Display: display,
+			Weight:  p.Weight,
+			ID:      p.ID,
+			Path:    pathName(pth),
+			SoldOut: p.SoldOut,
 		}
 	}
 	return out
 }
 
-func getVars(req *http.Request) (string, string, map[string]string) {
-	vars := mux.Vars(req)
-	cat := vars["category"]
-	subcat := vars["subcategory"]
-	return cat, subcat, vars
+// getPath splits the {path} of a catch-all route into the names of
+// categories, which can end with a product's title.
+func getPath(req *http.Request) []string {
+	p := strings.Trim(mux.Vars(req)["path"], "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
 }
 
-func SubCategory(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, _ := getVars(req)
-	return showSubcategory(cat, subcat, w, req)
+// getProduct fetches the product whose category and title are the
+// {path} of a catch-all route.
+func getProduct(req *http.Request) (*store.Product, error) {
+	pth := getPath(req)
+	if len(pth) < 2 {
+		return nil, store.ErrNotFound
+	}
 
+	p := store.NewProduct(pth[len(pth)-1], pth[:len(pth)-1])
+	return p, p.Fetch()
 }
 
-func showSubcategory(cat, subcat string, w http.ResponseWriter, req *http.Request) error {
-	prods, err := store.GetProducts(cat, subcat)
+// getProductPrice fetches the product at the path with the shopper's
+// price.  Products that aren't live are only there for staff that can
+// edit the catalog, so they can preview them.
+func getProductPrice(req *http.Request) (*store.Product, error) {
+	p, err := getProduct(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	price, err := store.GetPrice(cat)
+	if !p.Live() && !Can(store.CatalogWrite)(req) {
+		return nil, store.ErrNotFound
+	}
+
+	price, err := store.GetPrice(p.Path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	p := subCategoryPage{
-		page: page{
-			Admin:   Admin(req),
-			Links:   getNavbarLinks(req),
-			Shopify: shopifyKey,
-			Name:    name,
-			Head:    html["head"],
-		},
-		Subcat:   subcat,
-		Products: getProducts(cat, subcat, prods, getPrice(req, price)),
-		Shopping: getShoppingLinks(),
+	p.Price = getPrice(req, price)
+	return p, nil
+}
+
+func pathName(pth []string) string {
+	return strings.Join(pth, "/")
+}
+
+func productName(p *store.Product) string {
+	return pathName(append(append([]string{}, p.Path...), p.Title))
+}
+
+// reservedCategories are taken by other routes under /shop.
+var reservedCategories = map[string]bool{"images": true, "tags": true}
+
+// checkCategoryName is an error if name can't be a category in the one
+// at parent.
+func checkCategoryName(parent []string, name string) error {
+	if name == "" || strings.Contains(name, "/") || name == "_price_" {
+		return fmt.Errorf("invalid category name %q", name)
+	}
+
+	if len(parent) == 0 && reservedCategories[name] {
+		return fmt.Errorf("%q can't be the name of a top level category", name)
 	}
-	return templates.Get("subcategory.html").ExecuteTemplate(w, "base", p)
+	return nil
 }
 
 type productPage struct {
 	page
+	Price       string
 	Product     store.Product
+	Breadcrumbs []link
 	Back        string
 	BackText    string
-	Subcategory string
 }
 
 type product struct {
@@ -252,61 +366,87 @@ type product struct {
 	Link      string `json:"link"`
 	ProductID string `json:"product_id"`
 	Price     string `json:"price"`
-	Cat       string `json:"cat"`
-	Subcat    string `json:"subcat"`
+	Display   string `json:"display"`
+	Path      string `json:"path"`
+	Weight    int    `json:"weight"`
+	SoldOut   bool   `json:"sold_out"`
 }
 
 func GetProduct(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, vars := getVars(req)
-
-	price, err := store.GetPrice(cat)
+	p, err := getProductPrice(req)
 	if err != nil {
 		return err
 	}
 
-	p := store.NewProduct(vars["title"], cat, subcat, store.ProductPrice(getPrice(req, price)))
-	if err := p.Fetch(); err != nil {
-		return err
-	}
-
 	return json.NewEncoder(w).Encode(p)
 }
 
 func Product(w http.ResponseWriter, req *http.Request) error {
-	cat, subcat, vars := getVars(req)
-
-	price, err := store.GetPrice(cat)
+	p, err := getProductPrice(req)
 	if err != nil {
 		return err
 	}
 
-	p := store.NewProduct(vars["title"], cat, subcat, store.ProductPrice(getPrice(req, price)))
+	page := productPage{
+		page: page{
+			CSRF:    csrfToken(req),
+			Links:   getNavbarLinks(req),
+			Admin:   Admin(req),
+			Shopify: shopifyKey,
+			Name:    name,
+			Head:    html["head"],
+		},
+		Price:       displayPrice(req, p.Price),
+		Product:     *p,
+		Breadcrumbs: getBreadcrumbs(p.Path),
+		Back:        shopLink(p.Path),
+		BackText:    p.Path[len(p.Path)-1],
+	}
+	return templates.Get("product.html").ExecuteTemplate(w, "base", page)
+}
 
-	if err := p.Fetch(); err != nil {
+type tagPage struct {
+	page
+	Tag      string
+	Products []product
+}
+
+// Tag shows the products with a tag from every category.
+func Tag(w http.ResponseWriter, req *http.Request) error {
+	tag := mux.Vars(req)["tag"]
+	prods, err := store.GetTaggedProducts(tag)
+	if err != nil {
 		return err
 	}
-
-	var back, backText string
-	if subcat == "NOSUBCATEGORIES" {
-		back = fmt.Sprintf("/shop/%s", cat)
-		backText = cat
-	} else {
-		back = fmt.Sprintf("/shop/%s/%s", cat, subcat)
-		backText = subcat
+	prods = store.LiveProducts(prods)
+
+	prices := map[string]money.Money{}
+	var products []product
+	for _, p := range prods {
+		k := pathName(p.Path)
+		pr, ok := prices[k]
+		if !ok {
+			price, err := store.GetPrice(p.Path)
+			if err != nil {
+				return err
+			}
+			pr = getPrice(req, price)
+			prices[k] = pr
+		}
+		products = append(products, getProducts(p.Path, []store.Product{p}, pr, displayPrice(req, pr))...)
 	}
 
-	page := productPage{
+	page := tagPage{
 		page: page{
-			Links:   getNavbarLinks(req),
+			CSRF:    csrfToken(req),
 			Admin:   Admin(req),
+			Links:   getNavbarLinks(req),
 			Shopify: shopifyKey,
 			Name:    name,
 			Head:    html["head"],
 		},
-		Product:     *p,
-		Back:        back,
-		BackText:    backText,
-		Subcategory: subcat,
+		Tag:      tag,
+		Products: products,
 	}
-	return templates.Get("product.html").ExecuteTemplate(w, "base", page)
+	return templates.Get("tag.html").ExecuteTemplate(w, "base", page)
 }
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+type taxPage struct {
+	page
+	Rates  []store.TaxRate
+	Report []store.TaxLine
+	From   string
+	To     string
+}
+
+func AdminTaxes(w http.ResponseWriter, req *http.Request) error {
+	rates, err := store.GetTaxRates()
+	if err != nil {
+		return err
+	}
+
+	from, to, err := getReportRange(req)
+	if err != nil {
+		return err
+	}
+
+	report, err := store.TaxReport(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	p := taxPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Rates:  rates,
+		Report: report,
+		From:   from.Format("2006-01-02"),
+		To:     to.Format("2006-01-02"),
+	}
+
+	return templates.Get("admin/taxes.html").ExecuteTemplate(w, "base", p)
+}
+
+// getReportRange reads the from and to dates of a report, defaulting to
+// the current month.
+func getReportRange(req *http.Request) (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	args := req.URL.Query()
+	var err error
+	if s := args.Get("from"); s != "" {
+		if from, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			return from, to, err
+		}
+	}
+
+	if s := args.Get("to"); s != "" {
+		if to, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			return from, to, err
+		}
+	}
+
+	return from, to, nil
+}
+
+func AdminTaxUpdate(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	var t store.TaxRate
+	dec := schema.NewDecoder()
+	dec.IgnoreUnknownKeys(true)
+	if err := dec.Decode(&t, req.PostForm); err != nil {
+		return err
+	}
+
+	err := audited(req, "tax.update", t.Jurisdiction(), nil, t, func() error {
+		return t.Save()
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/taxes")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func AdminTaxDelete(w http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	err := audited(req, "tax.delete", vars["jurisdiction"], nil, nil, func() error {
+		return store.DeleteTaxRate(vars["jurisdiction"])
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/taxes")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
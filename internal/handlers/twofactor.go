@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"image/png"
+	"net/http"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/templates"
+)
+
+type twoFactorPage struct {
+	page
+	Enabled       bool
+	Required      bool
+	QR            template.URL
+	Secret        string
+	RecoveryCodes []string
+	Remaining     int
+	Error         string
+}
+
+// AdminTwoFactor shows the QR code for setting up an authenticator app,
+// or lets an admin that already has one manage it.
+func AdminTwoFactor(w http.ResponseWriter, req *http.Request) error {
+	u := store.User{Email: getUser(req).Email}
+	if err := u.Fetch(); err != nil {
+		return err
+	}
+
+	p := newTwoFactorPage(req, u)
+	if !u.TwoFactor() {
+		uri, err := u.StartTwoFactor()
+		if err != nil {
+			return err
+		}
+
+		p.QR, err = qrCode(uri)
+		if err != nil {
+			return err
+		}
+		p.Secret = u.TOTPPending
+	}
+
+	return templates.Get("admin/2fa.html").ExecuteTemplate(w, "base", p)
+}
+
+// AdminTwoFactorEnable turns on two factor login once the admin has
+// typed in a code from their app.
+func AdminTwoFactorEnable(w http.ResponseWriter, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	u := store.User{Email: getUser(req).Email}
+	if err := u.Fetch(); err != nil {
+		return err
+	}
+
+	var codes []string
+	var enableErr error
+	err := audited(req, "2fa.enable", u.Email, false, true, func() error {
+		codes, enableErr = u.EnableTwoFactor(req.FormValue("code"))
+		return enableErr
+	})
+	if enableErr == store.ErrInvalidCode {
+		w.Header().Set("Location", "/admin/2fa?error=that code didn't work, please try again")
+		w.WriteHeader(http.StatusFound)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	p := newTwoFactorPage(req, u)
+	p.RecoveryCodes = codes
+	return templates.Get("admin/2fa.html").ExecuteTemplate(w, "base", p)
+}
+
+// AdminRecoveryCodes throws away the old recovery codes and shows a new
+// set.
+func AdminRecoveryCodes(w http.ResponseWriter, req *http.Request) error {
+	u := store.User{Email: getUser(req).Email}
+	if err := u.Fetch(); err != nil {
+		return err
+	}
+
+	//the number of codes left, never the codes
+	var codes []string
+	err := audited(req, "2fa.recovery_codes", u.Email, len(u.RecoveryCodes), store.RecoveryCodeCount, func() error {
+		var err error
+		codes, err = u.NewRecoveryCodes()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	p := newTwoFactorPage(req, u)
+	p.RecoveryCodes = codes
+	return templates.Get("admin/2fa.html").ExecuteTemplate(w, "base", p)
+}
+
+func AdminTwoFactorDisable(w http.ResponseWriter, req *http.Request) error {
+	if cfg.AdminTwoFactor {
+		w.Header().Set("Location", "/admin/2fa?error=two factor login is required for admins")
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	u := store.User{Email: getUser(req).Email}
+	if err := u.Fetch(); err != nil {
+		return err
+	}
+
+	err := audited(req, "2fa.disable", u.Email, true, false, func() error {
+		return u.DisableTwoFactor()
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/admin/2fa")
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func newTwoFactorPage(req *http.Request, u store.User) twoFactorPage {
+	return twoFactorPage{
+		page: page{
+			CSRF:  csrfToken(req),
+			Admin: Admin(req),
+			Links: getNavbarLinks(req),
+			Name:  name,
+			Head:  html["head"],
+		},
+		Enabled:   u.TwoFactor(),
+		Required:  cfg.AdminTwoFactor,
+		Remaining: len(u.RecoveryCodes),
+		Error:     req.URL.Query().Get("error"),
+	}
+}
+
+// qrCode is a png data uri so the secret never has its own url.
+func qrCode(uri string) (template.URL, error) {
+	c, err := qr.Encode(uri, qr.M, qr.Auto)
+	if err != nil {
+		return "", err
+	}
+
+	c, err = barcode.Scale(c, 256, 256)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c); err != nil {
+		return "", err
+	}
+
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
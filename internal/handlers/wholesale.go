@@ -7,9 +7,11 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cswank/store/internal/email"
+	"github.com/cswank/store/internal/money"
 	"github.com/cswank/store/internal/store"
 	"github.com/cswank/store/internal/templates"
 	"github.com/gorilla/mux"
@@ -40,23 +42,19 @@ func Wholesale(w http.ResponseWriter, req *http.Request) error {
 
 type wholesalePage struct {
 	page
-	Products map[string]map[string][]product
+	Products []productGroup
 	Items    map[string]product
 }
 
 func getWholesaleForm(w http.ResponseWriter, req *http.Request) error {
-	cats, err := store.GetCategories()
-	if err != nil {
-		return err
-	}
-
-	prods, items, err := getWholesaleProducts(cats)
+	prods, items, err := getWholesaleProducts(getCurrency(req))
 	if err != nil {
 		return err
 	}
 
 	p := wholesalePage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Name:  cfg.Name,
 			Head:  html["head"],
@@ -72,6 +70,7 @@ func getWholesaleForm(w http.ResponseWriter, req *http.Request) error {
 //that was sent, or the admin has not clicked the confirm button
 func getWholesaleProcessing(w http.ResponseWriter, req *http.Request) error {
 	p := page{
+		CSRF:    csrfToken(req),
 		Links:   getNavbarLinks(req),
 		Name:    cfg.Name,
 		Head:    html["head"],
@@ -96,8 +95,11 @@ type invoiceEmail struct {
 	Number     int
 	Date       time.Time
 	StyleSheet string
-	Total      float64
+	Total      string
 	Price      string
+	Shipping   string
+	Tax        invoiceTax
+	ShipTo     store.Address
 	Products   []invoiceProduct
 	Customer   *store.User
 }
@@ -108,17 +110,58 @@ type invoiceSent struct {
 }
 
 func sendInvoice(w http.ResponseWriter, req *http.Request) error {
-	products, total := getInvoiceProducts(req)
+	cur := getCurrency(req)
+	price, products, total, err := getInvoiceProducts(req, cur)
+	if err != nil {
+		return err
+	}
+
 	u := getUser(req)
 
+	addr := shipTo(u)
+	shipping, cost, err := getInvoiceShipping(addr, products, cur)
+	if err != nil {
+		return err
+	}
+
+	tax, err := getInvoiceTax(u, addr, total, cost, cur)
+	if err != nil {
+		return err
+	}
+
+	grand, err := money.Sum(total, cost, tax.amount)
+	if err != nil {
+		return err
+	}
+
+	rec := store.Invoice{
+		Date:              time.Now(),
+		Email:             u.Email,
+		Jurisdiction:      tax.Jurisdiction,
+		Subtotal:          total,
+		Shipping:          cost,
+		Tax:               tax.amount,
+		Total:             grand,
+		ResaleCertificate: u.ResaleCertificate,
+		Currency:          cur.Code,
+		ExchangeRate:      cur.Rate,
+	}
+
+	if err := rec.Save(); err != nil {
+		return err
+	}
+
 	i := invoiceEmail{
-		Number:     0,
-		Date:       time.Now(),
-		Total:      total,
+		Number:     rec.Number,
+		Date:       rec.Date,
+		Total:      cur.Format(rec.Total),
 		StyleSheet: cfg.InvoiceStylesheet,
 		Customer:   u,
 		Products:   products,
-		Price:      cfg.DefaultPrice,
+		Price:      cur.Format(price),
+		Shipping:   shipping,
+		Tax:        tax,
+		ShipTo:     addr,
 	}
 
 	var buf bytes.Buffer
@@ -139,6 +182,7 @@ func sendInvoice(w http.ResponseWriter, req *http.Request) error {
 
 	p := invoiceSent{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Name:  cfg.Name,
 			Head:  html["head"],
@@ -159,50 +203,164 @@ type invoicePreview struct {
 	page
 	Products []invoiceProduct
 	Price    string
+	Shipping string
+	Tax      invoiceTax
 	Total    string
 }
 
 func previewInvoice(w http.ResponseWriter, req *http.Request) error {
-	products, total := getInvoiceProducts(req)
+	cur := getCurrency(req)
+	price, products, total, err := getInvoiceProducts(req, cur)
+	if err != nil {
+		return err
+	}
+
+	u := getUser(req)
+	addr := shipTo(u)
+	shipping, cost, err := getInvoiceShipping(addr, products, cur)
+	if err != nil {
+		return err
+	}
+
+	tax, err := getInvoiceTax(u, addr, total, cost, cur)
+	if err != nil {
+		return err
+	}
+
+	grand, err := money.Sum(total, cost, tax.amount)
+	if err != nil {
+		return err
+	}
 
 	p := invoicePreview{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Name:  cfg.Name,
 			Head:  html["head"],
 		},
 		Products: products,
-		Price:    cfg.DefaultPrice,
-		Total:    fmt.Sprintf("%.02f", total),
+		Price:    cur.Format(price),
+		Shipping: shipping,
+		Tax:      tax,
+		Total:    cur.Format(grand),
 	}
 
 	return templates.Get("wholesale/preview.html").ExecuteTemplate(w, "base", p)
 }
 
-func getInvoiceProducts(req *http.Request) ([]invoiceProduct, float64) {
+// getInvoiceProducts returns the unit price, the products ordered and
+// their total.
+func getInvoiceProducts(req *http.Request, cur store.Currency) (money.Money, []invoiceProduct, money.Money, error) {
 	var products []invoiceProduct
-	price, _ := strconv.ParseFloat(cfg.DefaultPrice, 32)
-	var total float64
+	var total money.Money
+	p, err := store.DefaultPrice()
+	if err != nil {
+		return total, nil, total, err
+	}
+
+	price := p.Price
 	for key, values := range req.Form { // range over map
 		for _, value := range values { // range over []string
 			if value == "0" {
 				continue
 			}
-			q, err := strconv.ParseFloat(value, 32)
+			q, err := strconv.Atoi(value)
 			if err != nil {
 				log.Println("couldn't parse form value", key, value, err)
 				continue
 			}
-			t := price * q
-			total += t
+			t := price.Mul(q)
+			if total, err = total.Add(t); err != nil {
+				return price, nil, total, err
+			}
 			products = append(products, invoiceProduct{
-				Total:    fmt.Sprintf("%.02f", t),
+				Total:    cur.Format(t),
 				Title:    key,
-				Quantity: int(q),
+				Quantity: q,
 			})
 		}
 	}
-	return products, total
+	return price, products, total, nil
+}
+
+// shipTo is the wholesaler's shipping address, or their billing
+// address if they never entered a separate one.
+func shipTo(u *store.User) store.Address {
+	if u.ShippingAddress.Address == "" {
+		return u.Address
+	}
+	return u.ShippingAddress
+}
+
+// getInvoiceShipping returns the shipping line for an invoice and its
+// cost.  When no shipping zone covers the address the line says so and
+// the cost is left off the total.
+func getInvoiceShipping(addr store.Address, products []invoiceProduct, cur store.Currency) (string, money.Money, error) {
+	weights, err := getProductWeights()
+	if err != nil {
+		return "", money.Money{}, err
+	}
+
+	var weight int
+	for _, p := range products {
+		weight += weights[p.Title] * p.Quantity
+	}
+
+	cost, err := store.ShippingCost(addr, weight)
+	if err == store.ErrNoShipping {
+		return "to be determined", money.Money{}, nil
+	} else if err != nil {
+		return "", cost, err
+	}
+
+	return cur.Format(cost), cost, nil
+}
+
+type invoiceTax struct {
+	Jurisdiction string
+	Rate         string
+	Exempt       string
+	Amount       string
+
+	amount money.Money
+}
+
+// getInvoiceTax applies the tax rate for the ship-to address unless the
+// wholesaler has a resale certificate on file.
+func getInvoiceTax(u *store.User, addr store.Address, subtotal, shipping money.Money, cur store.Currency) (invoiceTax, error) {
+	rate, err := store.GetTaxRate(addr)
+	if err != nil {
+		return invoiceTax{}, err
+	}
+
+	t := invoiceTax{
+		Jurisdiction: rate.Jurisdiction(),
+		Rate:         rate.Rate,
+	}
+
+	if u.TaxExempt() {
+		t.Exempt = u.ResaleCertificate
+		t.Amount = cur.Format(money.New(0, cfg.Currency))
+		return t, nil
+	}
+
+	t.amount, err = rate.Tax(subtotal, shipping)
+	t.Amount = cur.Format(t.amount)
+	return t, err
+}
+
+// getProductWeights maps product titles to their weight.  The wholesale
+// form only posts titles, so this has to look at every product.
+func getProductWeights() (map[string]int, error) {
+	m := map[string]int{}
+	err := store.WalkCategories(nil, func(_ []string, prods []store.Product) error {
+		for _, p := range prods {
+			m[p.Title] = p.Weight
+		}
+		return nil
+	})
+	return m, err
 }
 
 func ConfirmInvoice(w http.ResponseWriter, req *http.Request) error {
@@ -213,6 +371,7 @@ func WholesaleApplication(w http.ResponseWriter, req *http.Request) error {
 	params := req.URL.Query()
 	p := formPage{
 		page: page{
+			CSRF:  csrfToken(req),
 			Links: getNavbarLinks(req),
 			Name:  cfg.Name,
 			Head:  html["head"],
@@ -225,40 +384,37 @@ func WholesaleApplication(w http.ResponseWriter, req *http.Request) error {
 	return templates.Get("wholesale/application-form.html").ExecuteTemplate(w, "base", p)
 }
 
-func getWholesaleProducts(cats []string) (map[string]map[string][]product, map[string]product, error) {
-	m := map[string]map[string][]product{}
-	m2 := map[string]product{}
-	for _, cat := range cats {
-
-		price, err := store.GetPrice(cat)
-		if err != nil {
-			return nil, nil, err
+// getWholesaleProducts returns the products in each category that has
+// any, at wholesale prices, and every product by id.
+func getWholesaleProducts(cur store.Currency) ([]productGroup, map[string]product, error) {
+	var groups []productGroup
+	m := map[string]product{}
+	err := store.WalkCategories(nil, func(pth []string, prods []store.Product) error {
+		prods = store.LiveProducts(prods)
+		if len(prods) == 0 {
+			return nil
 		}
 
-		subcats, err := store.GetSubCategories(cat)
+		price, err := store.GetPrice(pth)
 		if err != nil {
-			return nil, nil, err
+			return err
 		}
-		a := map[string][]product{}
-		for _, subcat := range subcats {
-			prods, err := store.GetProducts(cat, subcat)
-			if err != nil {
-				return nil, nil, err
-			}
-			pp := getProducts(cat, subcat, prods, price.WholesalePrice)
-			a[subcat] = pp
-			for _, p := range pp {
-				m2[p.ID] = p
-			}
+
+		pr := toBase(price.WholesalePrice)
+		pp := getProducts(pth, prods, pr, cur.Format(pr))
+		groups = append(groups, productGroup{Name: strings.Join(pth, " / "), Link: shopLink(pth), Products: pp})
+		for _, p := range pp {
+			m[p.ID] = p
 		}
-		m[cat] = a
-	}
-	return m, m2, nil
+		return nil
+	})
+	return groups, m, err
 }
 
 func WholesaleThanks(w http.ResponseWriter, req *http.Request) error {
 	msg := fmt.Sprintf("Your email address is confirmed.  As soon as the site administrator approves your account you will be able to log into %s and make purchases at wholesale prices", cfg.Domains[0])
 	p := page{
+		CSRF:    csrfToken(req),
 		Links:   getNavbarLinks(req),
 		Name:    cfg.Name,
 		Head:    html["head"],
@@ -346,6 +502,7 @@ func WholesaleVerify(w http.ResponseWriter, req *http.Request) error {
 	vars := mux.Vars(req)
 
 	p := page{
+		CSRF:    csrfToken(req),
 		Links:   getNavbarLinks(req),
 		Admin:   Admin(req),
 		Shopify: shopifyKey,
@@ -353,7 +510,7 @@ func WholesaleVerify(w http.ResponseWriter, req *http.Request) error {
 		Name:    name,
 	}
 	var f func(io.Writer, string, interface{}) error
-	u, err := store.VerifyWholesaler(vars["token"])
+	u, err := store.VerifyUser(vars["token"])
 
 	if err != nil {
 		log.Printf("failed to confirm user %s with token %s, err: %v\n", u.Email, vars["token"], err)
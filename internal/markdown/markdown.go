@@ -0,0 +1,271 @@
+// Package markdown renders the subset of markdown that blogs are written
+// in: headings, paragraphs, lists, quotes, code, rules, emphasis, links,
+// images and product cards.  Everything the author typed is escaped, so
+// raw html in a post comes out as text and the result is safe to put in a
+// page.
+//
+// A product card is a line of its own like
+//
+//	{{product Cards/Birthday/Happy Cake}}
+//
+// and is replaced by whatever Options.Card returns for the path.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	heading  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bullet   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	numbered = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	rule     = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	card     = regexp.MustCompile(`^\s*\{\{\s*product\s+(.+?)\s*\}\}\s*$`)
+)
+
+// Options are how a post refers to things outside of it.
+type Options struct {
+	//Card renders the product card for a path.  Cards are left out
+	//without it.
+	Card func(path string) string
+
+	//Image is the url of the image that ![alt](src) refers to, or ""
+	//if there isn't one.  Images are left out without it.
+	Image func(src string) string
+}
+
+// Render turns src into html.
+func Render(src string, o Options) string {
+	r := renderer{
+		lines: strings.Split(strings.Replace(src, "\r\n", "\n", -1), "\n"),
+		opts:  o,
+	}
+	r.render()
+	return r.out.String()
+}
+
+type renderer struct {
+	lines []string
+	i     int
+	opts  Options
+	out   strings.Builder
+}
+
+func (r *renderer) render() {
+	for r.i < len(r.lines) {
+		line := r.lines[r.i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			r.i++
+		case strings.HasPrefix(strings.TrimSpace(line), "```"):
+			r.code()
+		case heading.MatchString(line):
+			m := heading.FindStringSubmatch(line)
+			fmt.Fprintf(&r.out, "<h%d>%s</h%d>\n", len(m[1]), r.inline(m[2]), len(m[1]))
+			r.i++
+		case rule.MatchString(line):
+			r.out.WriteString("<hr/>\n")
+			r.i++
+		case card.MatchString(line):
+			if r.opts.Card != nil {
+				if c := r.opts.Card(card.FindStringSubmatch(line)[1]); c != "" {
+					r.out.WriteString(c + "\n")
+				}
+			}
+			r.i++
+		case bullet.MatchString(line):
+			r.list("ul", bullet)
+		case numbered.MatchString(line):
+			r.list("ol", numbered)
+		case strings.HasPrefix(strings.TrimSpace(line), ">"):
+			r.quote()
+		default:
+			r.paragraph()
+		}
+	}
+}
+
+// code is a fenced block, which is copied as it is.
+func (r *renderer) code() {
+	r.i++
+	var lines []string
+	for ; r.i < len(r.lines); r.i++ {
+		if strings.HasPrefix(strings.TrimSpace(r.lines[r.i]), "```") {
+			r.i++
+			break
+		}
+		lines = append(lines, html.EscapeString(r.lines[r.i]))
+	}
+	fmt.Fprintf(&r.out, "<pre><code>%s</code></pre>\n", strings.Join(lines, "\n"))
+}
+
+// list is the items that follow, one per line.  A line that is indented
+// and isn't an item carries on the one before it.
+func (r *renderer) list(tag string, item *regexp.Regexp) {
+	var items []string
+	for ; r.i < len(r.lines); r.i++ {
+		line := r.lines[r.i]
+		if m := item.FindStringSubmatch(line); m != nil {
+			items = append(items, m[1])
+		} else if strings.TrimSpace(line) != "" && strings.HasPrefix(line, " ") && len(items) > 0 {
+			items[len(items)-1] += " " + strings.TrimSpace(line)
+		} else {
+			break
+		}
+	}
+
+	fmt.Fprintf(&r.out, "<%s>\n", tag)
+	for _, it := range items {
+		fmt.Fprintf(&r.out, "<li>%s</li>\n", r.inline(it))
+	}
+	fmt.Fprintf(&r.out, "</%s>\n", tag)
+}
+
+func (r *renderer) quote() {
+	var lines []string
+	for ; r.i < len(r.lines); r.i++ {
+		line := strings.TrimSpace(r.lines[r.i])
+		if !strings.HasPrefix(line, ">") {
+			break
+		}
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, ">")))
+	}
+	fmt.Fprintf(&r.out, "<blockquote>%s</blockquote>\n", Render(strings.Join(lines, "\n"), r.opts))
+}
+
+// paragraph runs until a blank line or the start of another block.  The
+// line breaks in it are kept, which is how blogs were shown before they
+// were markdown.
+func (r *renderer) paragraph() {
+	var lines []string
+	for ; r.i < len(r.lines); r.i++ {
+		line := r.lines[r.i]
+		if strings.TrimSpace(line) == "" || (len(lines) > 0 && r.startsBlock(line)) {
+			break
+		}
+		lines = append(lines, r.inline(strings.TrimSpace(line)))
+	}
+	fmt.Fprintf(&r.out, "<p>%s</p>\n", strings.Join(lines, "<br/>\n"))
+}
+
+func (r *renderer) startsBlock(line string) bool {
+	t := strings.TrimSpace(line)
+	return heading.MatchString(line) || bullet.MatchString(line) || numbered.MatchString(line) ||
+		card.MatchString(line) || strings.HasPrefix(t, "```") || strings.HasPrefix(t, ">")
+}
+
+// inline renders the emphasis, code, images and links in s and escapes
+// the rest.
+func (r *renderer) inline(s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_[]()#+-.!>{}", s[i+1]) > -1:
+			out.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '`':
+			if j := strings.IndexByte(s[i+1:], '`'); j > -1 {
+				fmt.Fprintf(&out, "<code>%s</code>", html.EscapeString(s[i+1:i+1+j]))
+				i += j + 2
+				continue
+			}
+		case strings.HasPrefix(s[i:], "**") || strings.HasPrefix(s[i:], "__"):
+			if j := strings.Index(s[i+2:], s[i:i+2]); j > 0 {
+				fmt.Fprintf(&out, "<strong>%s</strong>", r.inline(s[i+2:i+2+j]))
+				i += j + 4
+				continue
+			}
+		case (c == '*' || c == '_') && opens(s, i):
+			if j := closes(s, i); j > -1 {
+				fmt.Fprintf(&out, "<em>%s</em>", r.inline(s[i+1:j]))
+				i = j + 1
+				continue
+			}
+		case c == '!' && strings.HasPrefix(s[i:], "!["):
+			if alt, src, n := link(s[i+1:]); n > 0 {
+				if r.opts.Image != nil {
+					if u := r.opts.Image(src); u != "" {
+						fmt.Fprintf(&out, `<img src="%s" alt="%s"/>`, html.EscapeString(u), html.EscapeString(alt))
+					}
+				}
+				i += n + 1
+				continue
+			}
+		case c == '[':
+			if text, href, n := link(s[i:]); n > 0 {
+				if safeURL(href) {
+					fmt.Fprintf(&out, `<a href="%s">%s</a>`, html.EscapeString(href), r.inline(text))
+				} else {
+					out.WriteString(r.inline(text))
+				}
+				i += n
+				continue
+			}
+		}
+		out.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return out.String()
+}
+
+// opens is true if the * or _ at i can start emphasis.  An _ in the
+// middle of a word (snake_case) can't.
+func opens(s string, i int) bool {
+	if i+1 >= len(s) || s[i+1] == ' ' {
+		return false
+	}
+	return s[i] == '*' || i == 0 || !isWord(s[i-1])
+}
+
+func closes(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == s[i] && s[j-1] != ' ' && j > i+1 && (s[i] == '*' || j+1 == len(s) || !isWord(s[j+1])) {
+			return j
+		}
+	}
+	return -1
+}
+
+func isWord(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// link reads [text](href) from the start of s and returns how long it
+// was, or 0 if it isn't a link.  The href can have parentheses in it as
+// long as they are balanced, like wikipedia's do.
+func link(s string) (string, string, int) {
+	end := strings.Index(s, "](")
+	if end < 0 {
+		return "", "", 0
+	}
+
+	depth := 0
+	for c := end + 2; c < len(s); c++ {
+		switch s[c] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return s[1:end], strings.TrimSpace(s[end+2 : c]), c + 1
+			}
+			depth--
+		}
+	}
+	return "", "", 0
+}
+
+// safeURL keeps javascript: and friends out of the links.
+func safeURL(href string) bool {
+	h := strings.ToLower(href)
+	for _, p := range []string{"http://", "https://", "mailto:", "/", "#"} {
+		if strings.HasPrefix(h, p) {
+			return !strings.HasPrefix(h, "//")
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package markdown_test
+
+import (
+	"github.com/cswank/store/internal/markdown"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("markdown", func() {
+
+	render := func(s string) string {
+		return markdown.Render(s, markdown.Options{
+			Card: func(pth string) string {
+				return "<card " + pth + ">"
+			},
+			Image: func(src string) string {
+				if src == "missing.png" {
+					return ""
+				}
+				return "/images/blogs/post/" + src
+			},
+		})
+	}
+
+	It("renders headings and paragraphs", func() {
+		Expect(render("# Spring\n\nNew cards\nare in.")).To(Equal("<h1>Spring</h1>\n<p>New cards<br/>\nare in.</p>\n"))
+	})
+
+	It("renders lists", func() {
+		Expect(render("- one\n- two\n\n1. first\n2. second")).To(Equal("<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n<ol>\n<li>first</li>\n<li>second</li>\n</ol>\n"))
+	})
+
+	It("renders emphasis and code", func() {
+		Expect(render("*a* **b** `c<d>` snake_case_name")).To(Equal("<p><em>a</em> <strong>b</strong> <code>c&lt;d&gt;</code> snake_case_name</p>\n"))
+	})
+
+	It("renders links", func() {
+		Expect(render("see [the shop](/shop/Cards)")).To(Equal(`<p>see <a href="/shop/Cards">the shop</a></p>` + "\n"))
+	})
+
+	It("drops links that could run script", func() {
+		Expect(render("[click](javascript:alert(1))")).To(Equal("<p>click</p>\n"))
+	})
+
+	It("renders links with parentheses in them", func() {
+		Expect(render("[cake](https://en.wikipedia.org/wiki/Cake_(disambiguation)) too")).To(Equal(`<p><a href="https://en.wikipedia.org/wiki/Cake_(disambiguation)">cake</a> too</p>` + "\n"))
+	})
+
+	It("escapes html", func() {
+		Expect(render(`<script>alert("hi")</script>`)).To(Equal("<p>&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;</p>\n"))
+	})
+
+	It("renders images", func() {
+		Expect(render(`a ![the "cake"](cake.png) b ![gone](missing.png)`)).To(Equal(`<p>a <img src="/images/blogs/post/cake.png" alt="the &#34;cake&#34;"/> b </p>` + "\n"))
+	})
+
+	It("renders product cards", func() {
+		Expect(render("look:\n{{product Cards/Birthday/Happy Cake}}")).To(Equal("<p>look:</p>\n<card Cards/Birthday/Happy Cake>\n"))
+	})
+
+	It("leaves product cards out without a card func", func() {
+		Expect(markdown.Render("{{product Cards/Cake}}", markdown.Options{})).To(Equal(""))
+	})
+})
@@ -0,0 +1,198 @@
+// Package money does arithmetic on prices in integer minor units (cents)
+// so that invoice totals don't pick up floating point rounding errors.
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// every currency the store deals in has two decimal places
+const (
+	places = 2
+	scale  = 100
+)
+
+var decimal = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)$`)
+
+// Money is an amount in minor units of a currency, e.g. 450 USD is $4.50.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Parse reads a decimal amount such as "4.50", "4.5" or "4".
+func Parse(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || !decimal.MatchString(s) {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	if i := strings.Index(s, "."); i >= 0 && len(s[i+1:]) > places {
+		return Money{}, fmt.Errorf("invalid amount %q: more than %d decimal places", s, places)
+	}
+
+	return New(round(r.Mul(r, big.NewRat(scale, 1))), currency), nil
+}
+
+// ParseRate reads a decimal rate such as an exchange rate or a tax
+// percentage.
+func ParseRate(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || !decimal.MatchString(s) {
+		return nil, fmt.Errorf("invalid rate %q", s)
+	}
+	return r, nil
+}
+
+// String is the amount without a currency, e.g. "4.50".
+func (m Money) String() string {
+	a := m.Amount
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, a/scale, places, a%scale)
+}
+
+// Format is the amount for display: "$4.50" when there is a symbol,
+// otherwise "4.50 USD".
+func (m Money) Format(symbol string) string {
+	if symbol == "" {
+		return fmt.Sprintf("%s %s", m, m.Currency)
+	}
+
+	if m.Amount < 0 {
+		return fmt.Sprintf("-%s%s", symbol, New(-m.Amount, m.Currency))
+	}
+	return fmt.Sprintf("%s%s", symbol, m)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add sums two amounts.  The zero Money has no currency and can be added
+// to anything, but two different currencies can't be added.
+func (m Money) Add(o Money) (Money, error) {
+	cur := m.Currency
+	if cur == "" {
+		cur = o.Currency
+	} else if o.Currency != "" && o.Currency != cur {
+		return Money{}, fmt.Errorf("money: can't add %s to %s", o.Currency, cur)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: cur}, nil
+}
+
+// Sum adds up the amounts, which all have to be in the same currency.
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, a := range amounts {
+		var err error
+		if total, err = total.Add(a); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Mul multiplies the amount by a quantity.
+func (m Money) Mul(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// Scale multiplies the amount by r, rounding half away from zero to the
+// nearest minor unit.
+func (m Money) Scale(r *big.Rat) Money {
+	x := new(big.Rat).Mul(big.NewRat(m.Amount, 1), r)
+	return Money{Amount: round(x), Currency: m.Currency}
+}
+
+// Percent is rate percent of the amount, e.g. Percent("7.25") for tax.
+func (m Money) Percent(rate string) (Money, error) {
+	r, err := ParseRate(rate)
+	if err != nil {
+		return Money{}, err
+	}
+	return m.Scale(r.Quo(r, big.NewRat(100, 1))), nil
+}
+
+// Convert changes the amount into another currency given how many units
+// of it one unit of m's currency buys.
+func (m Money) Convert(rate, currency string) (Money, error) {
+	r, err := ParseRate(rate)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if r.Sign() <= 0 {
+		return Money{}, fmt.Errorf("invalid rate %q", rate)
+	}
+
+	c := m.Scale(r)
+	c.Currency = strings.ToUpper(currency)
+	return c, nil
+}
+
+// UnmarshalJSON reads the {"amount": 450, "currency": "USD"} form as well
+// as the decimal strings ("4.50") and floats (4.5) that prices and
+// invoices were stored as before this package existed.  Those come back
+// without a currency.
+func (m *Money) UnmarshalJSON(d []byte) error {
+	s := strings.TrimSpace(string(d))
+	switch {
+	case s == "null":
+		return nil
+	case strings.HasPrefix(s, "{"):
+		type money Money
+		var x money
+		if err := json.Unmarshal(d, &x); err != nil {
+			return err
+		}
+		*m = Money(x)
+		return nil
+	case strings.HasPrefix(s, `"`):
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			*m = Money{}
+			return nil
+		}
+	}
+
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return fmt.Errorf("invalid amount %s", d)
+	}
+
+	*m = Money{Amount: round(r.Mul(r, big.NewRat(scale, 1)))}
+	return nil
+}
+
+// round rounds half away from zero.
+func round(r *big.Rat) int64 {
+	n := new(big.Int).Abs(r.Num())
+	d := r.Denom()
+	q, rem := new(big.Int).QuoRem(n, d, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(d) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
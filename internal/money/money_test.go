@@ -0,0 +1,97 @@
+package money_test
+
+import (
+	"encoding/json"
+
+	"github.com/cswank/store/internal/money"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("money", func() {
+
+	Context("Parse", func() {
+		It("reads decimal strings", func() {
+			m, err := money.Parse("4.5", "usd")
+			Expect(err).To(BeNil())
+			Expect(m).To(Equal(money.New(450, "USD")))
+			Expect(m.String()).To(Equal("4.50"))
+		})
+
+		It("rejects fractions of a cent", func() {
+			_, err := money.Parse("4.505", "USD")
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("rejects things that aren't amounts", func() {
+			_, err := money.Parse("1e3", "USD")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("arithmetic", func() {
+		It("doesn't drift the way floats do", func() {
+			m, _ := money.Parse("0.10", "USD")
+			var total money.Money
+			for i := 0; i < 3; i++ {
+				var err error
+				total, err = total.Add(m)
+				Expect(err).To(BeNil())
+			}
+			Expect(total.String()).To(Equal("0.30"))
+			Expect(m.Mul(3)).To(Equal(total))
+		})
+
+		It("rounds percentages half away from zero", func() {
+			m := money.New(1000, "USD")
+			t, err := m.Percent("7.25")
+			Expect(err).To(BeNil())
+			Expect(t).To(Equal(money.New(73, "USD")))
+		})
+
+		It("converts currencies", func() {
+			m := money.New(1000, "USD")
+			e, err := m.Convert("0.925", "eur")
+			Expect(err).To(BeNil())
+			Expect(e).To(Equal(money.New(925, "EUR")))
+			Expect(e.Format("€")).To(Equal("€9.25"))
+			Expect(e.Format("")).To(Equal("9.25 EUR"))
+		})
+
+		It("won't add different currencies", func() {
+			_, err := money.New(1, "USD").Add(money.New(1, "EUR"))
+			Expect(err).To(MatchError("money: can't add EUR to USD"))
+		})
+
+		It("sums amounts", func() {
+			s, err := money.Sum(money.New(1, "USD"), money.Money{}, money.New(2, "USD"))
+			Expect(err).To(BeNil())
+			Expect(s).To(Equal(money.New(3, "USD")))
+
+			_, err = money.Sum(money.New(1, "USD"), money.New(2, "EUR"))
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("json", func() {
+		It("round trips", func() {
+			d, err := json.Marshal(money.New(450, "USD"))
+			Expect(err).To(BeNil())
+			Expect(string(d)).To(Equal(`{"amount":450,"currency":"USD"}`))
+
+			var m money.Money
+			Expect(json.Unmarshal(d, &m)).To(BeNil())
+			Expect(m).To(Equal(money.New(450, "USD")))
+		})
+
+		It("reads the old string and float formats", func() {
+			var x struct {
+				Price money.Money `json:"price"`
+				Total money.Money `json:"total"`
+			}
+			Expect(json.Unmarshal([]byte(`{"price": "4.50", "total": 12.345}`), &x)).To(BeNil())
+			Expect(x.Price).To(Equal(money.Money{Amount: 450}))
+			Expect(x.Total).To(Equal(money.Money{Amount: 1235}))
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package shopify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type LineItem struct {
+	Title    string `json:"title"`
+	Quantity int    `json:"quantity"`
+	Price    string `json:"price"`
+}
+
+// Order is a retail order placed through the Shopify checkout.
+type Order struct {
+	ID                int        `json:"id"`
+	Name              string     `json:"name"`
+	CreatedAt         time.Time  `json:"created_at"`
+	TotalPrice        string     `json:"total_price"`
+	Currency          string     `json:"currency"`
+	FinancialStatus   string     `json:"financial_status"`
+	FulfillmentStatus string     `json:"fulfillment_status"`
+	LineItems         []LineItem `json:"line_items"`
+}
+
+// Orders returns every order placed with the email address, newest
+// first.
+func Orders(email string) ([]Order, error) {
+	args := url.Values{
+		"email":  {email},
+		"status": {"any"},
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s?%s", ordersURL, args.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status when getting orders from shopify: %d", resp.StatusCode)
+	}
+
+	var m map[string][]Order
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return m["orders"], nil
+}
@@ -38,6 +38,7 @@ var (
 	variantsURL   string
 	deleteURL     string
 	discountURL   string
+	ordersURL     string
 
 	cfg config.Config
 )
@@ -54,6 +55,7 @@ func Init(c config.Config) {
 	variantsURL = fmt.Sprintf("%s/%s", cfg.ShopifyAPI, "admin/variants/%d.json")
 	deleteURL = fmt.Sprintf("%s/%s", cfg.ShopifyAPI, "admin/products/%s.json")
 	discountURL = fmt.Sprintf("%s/%s", cfg.ShopifyAPI, "admin/discounts.json")
+	ordersURL = fmt.Sprintf("%s/%s", cfg.ShopifyAPI, "admin/orders.json")
 }
 
 type Img struct {
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKey lets a script use /api/v1.  The token handed out is
+// <id>.<secret> and only a sha256 of the secret is kept, which is plenty
+// for 32 random bytes.
+type APIKey struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Hash      string       `json:"hash"`
+	Scopes    []Capability `json:"scopes"`
+	CreatedBy string       `json:"created_by"`
+	Created   time.Time    `json:"created"`
+	LastUsed  time.Time    `json:"last_used,omitempty"`
+	Revoked   time.Time    `json:"revoked,omitempty"`
+}
+
+// NewAPIKey saves a key and returns the token for it.  The token can't
+// be recovered later.
+func NewAPIKey(name, createdBy string, scopes []Capability) (string, APIKey, error) {
+	token, k, err := GenerateAPIKey(name, createdBy, scopes)
+	if err != nil {
+		return "", k, err
+	}
+	return token, k, k.Save()
+}
+
+// GenerateAPIKey is NewAPIKey without saving the key, for when something
+// has to happen between making it and saving it.
+func GenerateAPIKey(name, createdBy string, scopes []Capability) (string, APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", APIKey{}, errors.New("an api key needs a name")
+	}
+
+	for _, c := range scopes {
+		if !validCapability(c) {
+			return "", APIKey{}, errors.New("unknown capability " + string(c))
+		}
+	}
+
+	id, err := randHex(8)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	secret, err := randHex(32)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	k := APIKey{
+		ID:        id,
+		Name:      name,
+		Hash:      hashSecret(secret),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		Created:   time.Now(),
+	}
+
+	return id + "." + secret, k, nil
+}
+
+func (k APIKey) Save() error {
+	d, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+
+	return db.Put([]Query{NewQuery(Key(k.ID), Val(d), Buckets("apikeys"))})
+}
+
+// Can is true if the key was given capability c.
+func (k APIKey) Can(c Capability) bool {
+	for _, x := range k.Scopes {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (k APIKey) IsRevoked() bool {
+	return !k.Revoked.IsZero()
+}
+
+func getAPIKey(id string) (APIKey, error) {
+	var k APIKey
+	err := db.Get([]Query{NewQuery(Key(id), Buckets("apikeys"))}, func(_, val []byte) error {
+		return json.Unmarshal(val, &k)
+	})
+	return k, err
+}
+
+// CheckAPIKey returns the key a token belongs to as long as it hasn't
+// been revoked.
+func CheckAPIKey(token string) (APIKey, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	k, err := getAPIKey(parts[0])
+	if err == ErrNotFound {
+		return k, ErrInvalidAPIKey
+	} else if err != nil {
+		return k, err
+	}
+
+	if k.IsRevoked() || subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashSecret(parts[1]))) != 1 {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	if time.Since(k.LastUsed) > touchInterval {
+		k.LastUsed = time.Now()
+		err = k.Save()
+	}
+
+	return k, err
+}
+
+// GetAPIKeys returns every key, revoked ones included, newest first.
+func GetAPIKeys() ([]APIKey, error) {
+	var keys []APIKey
+	err := db.GetAll(NewQuery(Buckets("apikeys")), func(_, val []byte) error {
+		var k APIKey
+		if err := json.Unmarshal(val, &k); err != nil {
+			return err
+		}
+		keys = append(keys, k)
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Created.After(keys[j].Created)
+	})
+	return keys, nil
+}
+
+// RevokeAPIKey stops a key from working.  It is kept so the list of keys
+// shows what happened to it.
+func RevokeAPIKey(id string) error {
+	k, err := getAPIKey(id)
+	if err != nil {
+		return err
+	}
+
+	k.Revoked = time.Now()
+	return k.Save()
+}
+
+func hashSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func randHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
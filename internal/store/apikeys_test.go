@@ -0,0 +1,74 @@
+package store_test
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("api keys", func() {
+
+	var (
+		token string
+		key   store.APIKey
+		db    *mock.DB
+	)
+
+	BeforeEach(func() {
+		db = mock.NewDB(nil, []error{nil})
+		store.Init(config.Config{}, store.SetDB(db))
+
+		var err error
+		token, key, err = store.NewAPIKey("importer", "craig@example.com", []store.Capability{store.CatalogWrite})
+		Expect(err).To(BeNil())
+		Expect(db.Rows).To(HaveLen(1))
+		Expect(string(db.Rows[0].Val)).ToNot(ContainSubstring(strings.Split(token, ".")[1]))
+	})
+
+	useKey := func(k store.APIKey) {
+		d, err := json.Marshal(k)
+		Expect(err).To(BeNil())
+		db = mock.NewDB(map[string][]mock.Result{
+			"apikeys": []mock.Result{{Key: []byte(k.ID), Val: d}},
+		}, []error{nil, nil})
+		store.Init(config.Config{}, store.SetDB(db))
+	}
+
+	It("checks a good token", func() {
+		useKey(key)
+		k, err := store.CheckAPIKey(token)
+		Expect(err).To(BeNil())
+		Expect(k.Name).To(Equal("importer"))
+		Expect(k.Can(store.CatalogWrite)).To(BeTrue())
+		Expect(k.Can(store.BlogWrite)).To(BeFalse())
+	})
+
+	It("rejects the wrong secret", func() {
+		useKey(key)
+		_, err := store.CheckAPIKey(key.ID + ".nope")
+		Expect(err).To(Equal(store.ErrInvalidAPIKey))
+	})
+
+	It("rejects a token without an id", func() {
+		_, err := store.CheckAPIKey("nope")
+		Expect(err).To(Equal(store.ErrInvalidAPIKey))
+	})
+
+	It("rejects a revoked key", func() {
+		key.Revoked = time.Now()
+		useKey(key)
+		_, err := store.CheckAPIKey(token)
+		Expect(err).To(Equal(store.ErrInvalidAPIKey))
+	})
+
+	It("won't make a key with a made up scope", func() {
+		_, _, err := store.NewAPIKey("hacker", "craig@example.com", []store.Capability{"everything"})
+		Expect(err).ToNot(BeNil())
+	})
+})
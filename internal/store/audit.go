@@ -0,0 +1,111 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AuditEntry records one change made in the admin pages.  Entries are
+// only ever added to the audit bucket, nothing updates or deletes them.
+type AuditEntry struct {
+	ID     string          `json:"id"`
+	Time   time.Time       `json:"time"`
+	Actor  string          `json:"actor"`
+	Action string          `json:"action"`
+	Target string          `json:"target"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditFilter narrows down GetAudit.  Empty fields match everything and
+// Target matches any part of an entry's target.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	From   time.Time
+	To     time.Time
+}
+
+func (f AuditFilter) match(e AuditEntry) bool {
+	return (f.Actor == "" || e.Actor == f.Actor) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		(f.Target == "" || strings.Contains(e.Target, f.Target)) &&
+		(f.From.IsZero() || !e.Time.Before(f.From)) &&
+		(f.To.IsZero() || e.Time.Before(f.To))
+}
+
+// Audit saves an entry.  before and after are whatever the thing looked
+// like on either side of the change and are stored as JSON, nil for a
+// create or delete.
+func Audit(actor, action, target string, before, after interface{}) error {
+	now := time.Now()
+
+	//keys sort by time so the bucket reads back in order
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	e := AuditEntry{
+		ID:     now.UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(b),
+		Time:   now,
+		Actor:  actor,
+		Action: action,
+		Target: target,
+	}
+
+	var err error
+	if e.Before, err = auditJSON(before); err != nil {
+		return err
+	}
+
+	if e.After, err = auditJSON(after); err != nil {
+		return err
+	}
+
+	d, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return db.Put([]Query{NewQuery(Key(e.ID), Val(d), Buckets("audit"))})
+}
+
+func auditJSON(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// GetAudit returns the entries that match f, newest first.
+func GetAudit(f AuditFilter) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := db.GetAll(NewQuery(Buckets("audit")), func(_, val []byte) error {
+		var e AuditEntry
+		if err := json.Unmarshal(val, &e); err != nil {
+			return err
+		}
+
+		if f.match(e) {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries, nil
+}
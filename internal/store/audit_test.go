@@ -0,0 +1,59 @@
+package store_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("audit", func() {
+
+	var (
+		db *mock.DB
+	)
+
+	BeforeEach(func() {
+		var results []mock.Result
+		for i, e := range []store.AuditEntry{
+			{ID: "1", Time: time.Now().Add(-48 * time.Hour), Actor: "craig@example.com", Action: "product.delete", Target: "bags/totes/big"},
+			{ID: "2", Time: time.Now().Add(-time.Hour), Actor: "laura@example.com", Action: "category.price", Target: "bags"},
+			{ID: "3", Time: time.Now(), Actor: "craig@example.com", Action: "product.update", Target: "bags/totes/small"},
+		} {
+			d, err := json.Marshal(e)
+			Expect(err).To(BeNil())
+			results = append(results, mock.Result{Key: []byte{byte(i)}, Val: d})
+		}
+
+		db = mock.NewDB(map[string][]mock.Result{"audit": results}, []error{nil, nil})
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	It("returns the newest entries first", func() {
+		entries, err := store.GetAudit(store.AuditFilter{})
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].ID).To(Equal("3"))
+	})
+
+	It("filters", func() {
+		entries, err := store.GetAudit(store.AuditFilter{Actor: "craig@example.com", Target: "totes", From: time.Now().Add(-24 * time.Hour)})
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].ID).To(Equal("3"))
+	})
+
+	It("stores before and after as json", func() {
+		Expect(store.Audit("craig@example.com", "category.rename", "purses", "bags", "purses")).To(BeNil())
+		Expect(db.Rows).To(HaveLen(1))
+
+		var e store.AuditEntry
+		Expect(json.Unmarshal(db.Rows[0].Val, &e)).To(BeNil())
+		Expect(string(e.Before)).To(Equal(`"bags"`))
+		Expect(string(e.After)).To(Equal(`"purses"`))
+	})
+})
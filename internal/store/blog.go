@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"html"
 	"image/png"
 	"io"
+	"net/url"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/cswank/store/internal/markdown"
 )
 
 var (
@@ -20,7 +25,15 @@ var (
 type Blog struct {
 	Title string    `json:"title" schema:"title"`
 	Date  time.Time `json:"date" schema:"date"`
-	Body  string    `json:"body" schema:"body"`
+	Body  string    `json:"body" schema:"body"` //markdown
+	HTML  string    `json:"html,omitempty" schema:"-"`
+	Tags  []string  `json:"tags,omitempty" schema:"-"`
+
+	Status    Status     `json:"status,omitempty" schema:"-"`
+	PublishAt *time.Time `json:"publish_at,omitempty" schema:"-"`
+
+	//EditedBy is who is saving the blog, for its history.
+	EditedBy string `json:"-" schema:"-"`
 
 	image io.Reader
 }
@@ -33,7 +46,7 @@ func CurrentBlog() (Blog, error) {
 		return *currentBlog, nil
 	}
 
-	blogs, err := Blogs()
+	blogs, err := PublishedBlogs()
 
 	if err != nil {
 		return Blog{}, err
@@ -56,18 +69,33 @@ func GetBlog(key string) (Blog, error) {
 }
 
 type BlogKey struct {
-	Date  string
-	Title string
-	ID    string
+	Date   string
+	Title  string
+	ID     string
+	Status Status
+	Tags   []string
 }
 
+// Live is true if readers can see the blog.
+func (k BlogKey) Live() bool {
+	return isLive(k.Status)
+}
+
+// Blogs returns every blog, newest first, whatever its status.
 func Blogs() ([]BlogKey, error) {
 	var blogs []BlogKey
-	err := db.GetAll(Query{Buckets: [][]byte{[]byte("blogs")}}, func(key, _ []byte) error {
+	err := db.GetAll(Query{Buckets: [][]byte{[]byte("blogs")}}, func(key, val []byte) error {
 		k := string(key)
 		i := strings.Index(k, ":")
 		if i > -1 {
-			bk := BlogKey{ID: k, Date: k[:i], Title: k[i+1:]}
+			var b struct {
+				Status Status   `json:"status"`
+				Tags   []string `json:"tags"`
+			}
+			if err := json.Unmarshal(val, &b); err != nil {
+				return err
+			}
+			bk := BlogKey{ID: k, Date: k[:i], Title: k[i+1:], Status: b.Status, Tags: b.Tags}
 			blogs = append(blogs, bk)
 		}
 		return nil
@@ -83,6 +111,22 @@ func Blogs() ([]BlogKey, error) {
 	return blogs, err
 }
 
+// PublishedBlogs returns the blogs that readers can see, newest first.
+func PublishedBlogs() ([]BlogKey, error) {
+	blogs, err := Blogs()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]BlogKey, 0, len(blogs))
+	for _, b := range blogs {
+		if b.Live() {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
 func (b *Blog) Key() string {
 	return fmt.Sprintf("%s:%s", b.Date.Format("2006-01-02"), b.Title)
 }
@@ -93,50 +137,102 @@ func (b *Blog) Fetch() error {
 	})
 }
 
-func (b *Blog) Update(b2 Blog, img io.Reader) error {
+// Merge is b with the changes in b2, which is what Update saves.  A
+// blank status in b2 leaves b's as it is.
+func (b Blog) Merge(b2 Blog) Blog {
+	b.Title = b2.Title
+	b.Date = b2.Date
 	b.Body = b2.Body
-	if b.Title != b2.Title || b.Date != b2.Date {
+	b.Tags = b2.Tags
+	b.EditedBy = b2.EditedBy
+	if b2.Status != "" {
+		b.Status = b2.Status
+		b.PublishAt = b2.PublishAt
+	}
+	return b
+}
+
+// Update saves the changes in b2 (see Merge).  A new title or date moves
+// the blog, with its image, media, comments and history, to its new key.
+func (b *Blog) Update(b2 Blog, img io.Reader) error {
+	key := b.Key()
+	moved := b.Title != b2.Title || b.Date != b2.Date
+	*b = b.Merge(b2)
+	if moved {
 
 		q := []Query{
-			NewQuery(Key(b.Key()), Buckets("blogs")),
+			NewQuery(Key(key), Buckets("blogs")),
 		}
 
 		if img == nil {
 			// get old image data and delete it from db
-			db.Get([]Query{NewQuery(Key(b.Key()), Buckets("images", "blogs"))}, func(_, val []byte) error {
+			db.Get([]Query{NewQuery(Key(key), Buckets("images", "blogs"))}, func(_, val []byte) error {
 				img = bytes.NewBuffer(val)
 				return nil
 			})
-			q = append(q, NewQuery(Key(b.Key()), Buckets("images", "blogs")))
+			q = append(q, NewQuery(Key(key), Buckets("images", "blogs")))
 		}
 
 		if err := db.Delete(q); err != nil {
 			return err
 		}
 
-		b.Title = b2.Title
-		b.Date = b2.Date
+		if err := moveBlogMedia(key, b.Key()); err != nil {
+			return err
+		}
+
+		if err := moveComments(key, b.Key()); err != nil {
+			return err
+		}
+
+		if err := moveRevisions("blogs", key, b.Key()); err != nil {
+			return err
+		}
 	}
 
-	return b.doSave(img)
+	if err := b.doSave(img); err != nil {
+		return err
+	}
+
+	blogLock.Lock()
+	currentBlog = nil
+	blogLock.Unlock()
+	unindexBlog(key)
+	indexBlog(b)
+	return nil
 }
 
+// Save adds the blog, dated today unless it already has a date.
 func (b *Blog) Save(img io.Reader) error {
-	b.Date = time.Now()
+	if b.Date.IsZero() {
+		b.Date = time.Now()
+	}
 	blogLock.Lock()
 	currentBlog = nil
 	blogLock.Unlock()
-	return b.doSave(img)
+	if err := b.doSave(img); err != nil {
+		return err
+	}
+
+	indexBlog(b)
+	return nil
 }
 
 func (b *Blog) doSave(img io.Reader) error {
+	b.HTML = RenderBlog(b.Key(), b.Body)
 	d, err := json.Marshal(b)
 	if err != nil {
 		return err
 	}
 
+	rev, err := revisionQuery("blogs", b.Key(), BlogRevision{Revision: newRevision(b.EditedBy), Blog: *b})
+	if err != nil {
+		return err
+	}
+
 	q := []Query{
 		NewQuery(Key(b.Key()), Val(d), Buckets("blogs")),
+		rev,
 	}
 
 	if img != nil {
@@ -150,6 +246,75 @@ func (b *Blog) doSave(img io.Reader) error {
 	return db.Put(q)
 }
 
+// RenderBlog turns the markdown body of the blog with the key into html.
+// It is done when a blog is saved and the result kept in Blog.HTML.
+// Product cards are only marked where they go, since the products can
+// change after the blog is saved; Render fills them in.
+func RenderBlog(key, body string) string {
+	return markdown.Render(body, markdown.Options{
+		Card:  cardMarker,
+		Image: blogMediaURL(key),
+	})
+}
+
+// Render is the blog's html with the product cards filled in as the
+// products are now.
+func (b Blog) Render() string {
+	h := b.HTML
+	if h == "" {
+		h = RenderBlog(b.Key(), b.Body)
+	}
+	return expandCards(h)
+}
+
+// PreviewBlog is RenderBlog with the product cards filled in, for
+// showing a body that hasn't been saved.
+func PreviewBlog(key, body string) string {
+	return expandCards(RenderBlog(key, body))
+}
+
+// cards are what cardMarker leaves in Blog.HTML.  The path is escaped so
+// it can't end the comment.
+var cards = regexp.MustCompile(`<!--product ([^ ]*?)-->\n?`)
+
+func cardMarker(pth string) string {
+	return fmt.Sprintf("<!--product %s-->", url.PathEscape(pth))
+}
+
+func expandCards(h string) string {
+	return cards.ReplaceAllStringFunc(h, func(m string) string {
+		pth, err := url.PathUnescape(cards.FindStringSubmatch(m)[1])
+		if err != nil {
+			return ""
+		}
+
+		c := productCard(pth)
+		if c == "" {
+			return ""
+		}
+		return c + "\n"
+	})
+}
+
+// productCard links to the product at pth, with its thumbnail.  It is
+// left out if there isn't a product there that shoppers can see.
+func productCard(pth string) string {
+	parts := strings.Split(strings.Trim(pth, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	p := NewProduct(parts[len(parts)-1], parts[:len(parts)-1])
+	if err := p.Fetch(); err != nil || !p.Live() {
+		return ""
+	}
+
+	link := (&url.URL{Path: p.Link()}).String()
+	img := (&url.URL{Path: fmt.Sprintf("/shop/images/products/%s/thumb.png", p.Title)}).String()
+	title := html.EscapeString(p.Title)
+	return fmt.Sprintf(`<a class="product-card" href="%s"><img src="%s" alt="%s"/><span>%s</span></a>`, link, img, title, title)
+}
+
 func GetBlogImage(key string) ([]byte, error) {
 	q := []Query{NewQuery(Key(key), Buckets("images", "blogs"))}
 	var img []byte
@@ -184,8 +349,81 @@ func (b *Blog) Delete() error {
 	if err != nil {
 		return err
 	}
+
+	if err := deleteBlogMedia(b.Key()); err != nil {
+		return err
+	}
+
+	if err := deleteComments(b.Key()); err != nil {
+		return err
+	}
+
 	blogLock.Lock()
 	currentBlog = nil
 	blogLock.Unlock()
+	unindexBlog(b.Key())
 	return nil
 }
+
+// BlogRevision is a saved version of a blog.
+type BlogRevision struct {
+	Revision
+	Blog Blog `json:"blog"`
+}
+
+// Text is the revision as lines that can be compared with another one.
+func (r BlogRevision) Text() string {
+	b := r.Blog
+	lines := []string{
+		"Title: " + b.Title,
+		"Date: " + b.Date.Format("2006-01-02"),
+		"Status: " + string(statusOf(b.Status)),
+		"Tags: " + strings.Join(b.Tags, ", "),
+	}
+
+	if b.PublishAt != nil {
+		lines = append(lines, "Publish at: "+b.PublishAt.Format(time.RFC3339))
+	}
+	return strings.Join(append(lines, "", b.Body), "\n")
+}
+
+// BlogRevisions returns every saved version of the blog with the key,
+// newest first.
+func BlogRevisions(key string) ([]BlogRevision, error) {
+	var revs []BlogRevision
+	err := eachRevision("blogs", key, func(n int, val []byte) error {
+		var r BlogRevision
+		if err := json.Unmarshal(val, &r); err != nil {
+			return err
+		}
+		r.N = n
+		revs = append([]BlogRevision{r}, revs...)
+		return nil
+	})
+	return revs, err
+}
+
+// Restore saves revision n of the blog as its newest version.  A
+// revision with another title or date moves the blog back to them.
+func (b *Blog) Restore(n int, by string) error {
+	b2, err := b.Restoring(n, by)
+	if err != nil {
+		return err
+	}
+	return b.Update(b2, nil)
+}
+
+// Restoring is the change that Restore passes to Update.
+func (b *Blog) Restoring(n int, by string) (Blog, error) {
+	var r BlogRevision
+	if err := getRevision("blogs", b.Key(), n, &r); err != nil {
+		return Blog{}, err
+	}
+
+	b2 := r.Blog
+	b2.EditedBy = by
+	if b2.Status == "" {
+		b2.Status = Published
+	}
+	return b2, nil
+}
@@ -0,0 +1,86 @@
+package store_test
+
+import (
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("blog", func() {
+
+	var (
+		db *mock.DB
+	)
+
+	BeforeEach(func() {
+		db = mock.NewDB(map[string][]mock.Result{
+			"products Cards Birthday": []mock.Result{
+				{Key: []byte("Happy Cake"), Val: []byte(`{"description": "cake"}`)},
+				{Key: []byte("Secret"), Val: []byte(`{"status": "draft"}`)},
+			},
+		}, make([]error, 30))
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	It("renders markdown with product cards", func() {
+		b := store.Blog{Title: "Spring", Body: "## New\n\n{{product Cards/Birthday/Happy Cake}}"}
+		Expect(b.Render()).To(Equal(`<h2>New</h2>` + "\n" + `<a class="product-card" href="/shop/Cards/Birthday/Happy%20Cake"><img src="/shop/images/products/Happy%20Cake/thumb.png" alt="Happy Cake"/><span>Happy Cake</span></a>` + "\n"))
+	})
+
+	It("leaves out cards for products that shoppers can't see", func() {
+		b := store.Blog{Title: "Spring", Body: "{{product Cards/Birthday/Secret}}"}
+		Expect(b.Render()).To(Equal(""))
+	})
+
+	It("fills in the cards when the blog is shown, not when it is saved", func() {
+		b := store.Blog{Title: "Spring", Body: "{{product Cards/Birthday/Happy Cake}}\n\n{{product Cards/Birthday/Secret}}"}
+		Expect(b.Save(nil)).To(BeNil())
+		Expect(b.HTML).To(Equal("<!--product Cards%2FBirthday%2FHappy%20Cake-->\n<!--product Cards%2FBirthday%2FSecret-->\n"))
+		Expect(b.Render()).To(ContainSubstring(`<span>Happy Cake</span>`))
+		Expect(b.Render()).ToNot(ContainSubstring("Secret"))
+
+		//the draft gets published after the blog was saved
+		db = mock.NewDB(map[string][]mock.Result{
+			"products Cards Birthday": []mock.Result{
+				{Key: []byte("Secret"), Val: []byte(`{"status": "published"}`)},
+			},
+		}, make([]error, 30))
+		store.Init(config.Config{}, store.SetDB(db))
+		Expect(b.Render()).To(ContainSubstring(`<span>Secret</span>`))
+	})
+
+	It("doesn't let a card's path end its marker", func() {
+		h := store.RenderBlog("2018-03-01:Spring", "{{product Cards/x--><script>}}")
+		Expect(h).To(Equal("<!--product Cards%2Fx--%3E%3Cscript%3E-->\n"))
+	})
+
+	It("keeps the rendered html when it is saved", func() {
+		b := store.Blog{Title: "Spring", Body: "*new* cards"}
+		Expect(b.Save(nil)).To(BeNil())
+		Expect(b.HTML).To(Equal("<p><em>new</em> cards</p>\n"))
+		Expect(string(db.Rows[len(db.Rows)-1].Val)).To(ContainSubstring(`"html":"\u003cp\u003e\u003cem\u003enew`))
+	})
+
+	It("shows images from the blog's library", func() {
+		h := store.RenderBlog("2018-03-01:Spring", "![a cake](cake.png) ![elsewhere](http://example.com/x.png)")
+		Expect(h).To(Equal(`<p><img src="/images/blogs/2018-03-01:Spring/cake.png" alt="a cake"/> </p>` + "\n"))
+	})
+
+	It("names uploads after their files", func() {
+		n, err := store.MediaName(`C:\Users\me\Birthday Cake.PNG`)
+		Expect(err).To(BeNil())
+		Expect(n).To(Equal("birthday-cake.png"))
+
+		_, err = store.MediaName("")
+		Expect(err).To(Equal(store.ErrBadMediaName))
+	})
+
+	It("keeps images in the blog's library", func() {
+		Expect(store.AddBlogMedia("2018-03-01:Spring", "a/b.png", nil)).To(Equal(store.ErrBadMediaName))
+		Expect(store.DeleteBlogMedia("2018-03-01:Spring", "cake.png")).To(BeNil())
+		Expect(db.Rows[0].Buckets).To(Equal([][]byte{[]byte("images"), []byte("blog-media"), []byte("2018-03-01:Spring")}))
+		Expect(string(db.Rows[0].Key)).To(Equal("cake.png"))
+	})
+})
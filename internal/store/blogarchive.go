@@ -0,0 +1,99 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// BlogMonth is a month that has published blogs, for the archive.
+type BlogMonth struct {
+	Month string //2006-01
+	Count int
+}
+
+// Time is the first day of the month.
+func (m BlogMonth) Time() time.Time {
+	t, _ := time.Parse("2006-01", m.Month)
+	return t
+}
+
+// BlogMonths returns the months that have published blogs, newest
+// first.
+func BlogMonths() ([]BlogMonth, error) {
+	blogs, err := PublishedBlogs()
+	if err != nil {
+		return nil, err
+	}
+
+	var months []BlogMonth
+	for _, b := range blogs {
+		if len(b.Date) < 7 {
+			continue
+		}
+
+		m := b.Date[:7]
+		if n := len(months); n > 0 && months[n-1].Month == m {
+			months[n-1].Count++
+		} else {
+			months = append(months, BlogMonth{Month: m, Count: 1})
+		}
+	}
+	return months, nil
+}
+
+// BlogsIn returns the published blogs from month (2006-01), newest
+// first.
+func BlogsIn(month string) ([]BlogKey, error) {
+	return filterBlogs(func(b BlogKey) bool {
+		return strings.HasPrefix(b.Date, month+"-")
+	})
+}
+
+// BlogsTagged returns the published blogs with the tag, newest first.
+func BlogsTagged(tag string) ([]BlogKey, error) {
+	return filterBlogs(func(b BlogKey) bool {
+		for _, t := range b.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// BlogTags returns the tags on the published blogs.
+func BlogTags() ([]string, error) {
+	blogs, err := PublishedBlogs()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var tags []string
+	for _, b := range blogs {
+		for _, t := range b.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func filterBlogs(f func(BlogKey) bool) ([]BlogKey, error) {
+	blogs, err := PublishedBlogs()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []BlogKey
+	for _, b := range blogs {
+		if f(b) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,52 @@
+package store_test
+
+import (
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("blog archive", func() {
+
+	BeforeEach(func() {
+		db := mock.NewDB(map[string][]mock.Result{
+			"blogs": []mock.Result{
+				{Key: []byte("2018-02-10:Snow"), Val: []byte(`{"title": "Snow", "tags": ["weather"]}`)},
+				{Key: []byte("2018-03-01:Spring"), Val: []byte(`{"title": "Spring", "tags": ["weather", "news"]}`)},
+				{Key: []byte("2018-03-15:Sale"), Val: []byte(`{"title": "Sale", "tags": ["news"]}`)},
+				{Key: []byte("2018-03-20:Secret"), Val: []byte(`{"title": "Secret", "status": "draft", "tags": ["hidden"]}`)},
+			},
+		}, make([]error, 30))
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	It("counts the published blogs in each month", func() {
+		months, err := store.BlogMonths()
+		Expect(err).To(BeNil())
+		Expect(months).To(Equal([]store.BlogMonth{{Month: "2018-03", Count: 2}, {Month: "2018-02", Count: 1}}))
+	})
+
+	It("gets the blogs from a month", func() {
+		blogs, err := store.BlogsIn("2018-03")
+		Expect(err).To(BeNil())
+		Expect(blogs).To(HaveLen(2))
+		Expect(blogs[0].Title).To(Equal("Sale"))
+		Expect(blogs[1].Title).To(Equal("Spring"))
+	})
+
+	It("gets the tags", func() {
+		tags, err := store.BlogTags()
+		Expect(err).To(BeNil())
+		Expect(tags).To(Equal([]string{"news", "weather"}))
+	})
+
+	It("gets the blogs with a tag", func() {
+		blogs, err := store.BlogsTagged("weather")
+		Expect(err).To(BeNil())
+		Expect(blogs).To(HaveLen(2))
+		Expect(blogs[0].Title).To(Equal("Spring"))
+		Expect(blogs[1].Title).To(Equal("Snow"))
+	})
+})
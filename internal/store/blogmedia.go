@@ -0,0 +1,125 @@
+package store
+
+import (
+	"errors"
+	"image/png"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+/*
+Each blog has a library of images that its body can show with
+![alt](name).  They are kept apart from the blog's main image, which is
+at images/blogs/<key>.
+
+images
+   blog-media
+      2018-03-01:Spring
+         cake.png: png
+         table.png: png
+*/
+
+// ErrBadMediaName is returned for an image name that can't be part of a
+// url.
+var ErrBadMediaName = errors.New(`an image name can't be blank or contain a "/" or ":"`)
+
+func blogMediaBuckets(blog string) []string {
+	return []string{"images", "blog-media", blog}
+}
+
+// MediaName is the name an uploaded file is kept under: its base name,
+// lower case and with dashes for spaces, always ending in .png.
+func MediaName(filename string) (string, error) {
+	n := path.Base(strings.Replace(filename, `\`, "/", -1))
+	n = strings.TrimSuffix(n, path.Ext(n))
+	n = strings.ToLower(strings.Join(strings.Fields(n), "-"))
+	if n == "" || n == "." || strings.ContainsAny(n, "/:") {
+		return "", ErrBadMediaName
+	}
+	return n + ".png", nil
+}
+
+// AddBlogMedia resizes the png in r and adds it to the blog's library as
+// name, replacing any image that already has that name.
+func AddBlogMedia(blog, name string, r io.Reader) error {
+	if name == "" || strings.ContainsAny(name, "/:") {
+		return ErrBadMediaName
+	}
+
+	img, err := png.Decode(r)
+	if err != nil {
+		return err
+	}
+
+	d, err := resizeImage(img, uint(full))
+	if err != nil {
+		return err
+	}
+
+	return db.Put([]Query{NewQuery(Buckets(blogMediaBuckets(blog)...), Key(name), Val(d))})
+}
+
+// GetBlogMedia returns an image from the blog's library.
+func GetBlogMedia(blog, name string) ([]byte, error) {
+	var img []byte
+	q := []Query{NewQuery(Buckets(blogMediaBuckets(blog)...), Key(name))}
+	return img, db.Get(q, func(_, val []byte) error {
+		img = val
+		return nil
+	})
+}
+
+// BlogMedia returns the names of the images in the blog's library.
+func BlogMedia(blog string) ([]string, error) {
+	var names []string
+	err := db.GetAll(NewQuery(Buckets(blogMediaBuckets(blog)...)), func(key, _ []byte) error {
+		names = append(names, string(key))
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return names, err
+}
+
+// DeleteBlogMedia removes an image from the blog's library.
+func DeleteBlogMedia(blog, name string) error {
+	return db.Delete([]Query{NewQuery(Buckets(blogMediaBuckets(blog)...), Key(name))})
+}
+
+// moveBlogMedia keeps a blog's library with it when its key changes.
+func moveBlogMedia(old, key string) error {
+	names, err := BlogMedia(old)
+	if err != nil || len(names) == 0 {
+		return err
+	}
+
+	parent := blogMediaBuckets("")[:2]
+	return db.RenameBucket(
+		NewQuery(Buckets(parent...), Key(old)),
+		NewQuery(Buckets(parent...), Key(key)),
+	)
+}
+
+// deleteBlogMedia deletes the blog's whole library.
+func deleteBlogMedia(blog string) error {
+	names, err := BlogMedia(blog)
+	if err != nil || len(names) == 0 {
+		return err
+	}
+	return db.Delete([]Query{NewQuery(Buckets(blogMediaBuckets(blog)...))})
+}
+
+// blogMediaURL resolves the image names in the body of the blog with
+// the key.  Anything that isn't just a name is left out.
+func blogMediaURL(key string) func(string) string {
+	return func(src string) string {
+		if key == "" || src == "" || strings.ContainsAny(src, "/:") {
+			return ""
+		}
+		return (&url.URL{Path: "/images/blogs/" + key + "/" + src}).String()
+	}
+}
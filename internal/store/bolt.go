@@ -119,6 +119,33 @@ func (b *Bolt) Put(rows []Query) error {
 	})
 }
 
+// PutNext puts the row that f makes out of the next number in row's
+// bucket, in the same transaction that takes the number.  A bucket that
+// was filled before it had a sequence starts after the keys already in
+// it.
+func (b *Bolt) PutNext(row Query, f func(n uint64) (Query, error)) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bu, err := b.getOrCreateBucket(tx, row.Buckets)
+		if err != nil {
+			return err
+		}
+
+		n, err := bu.NextSequence()
+		for err == nil && n <= uint64(bu.Stats().KeyN) {
+			n, err = bu.NextSequence()
+		}
+		if err != nil {
+			return err
+		}
+
+		r, err := f(n)
+		if err != nil {
+			return err
+		}
+		return bu.Put(r.Key, r.Val)
+	})
+}
+
 func (b *Bolt) getOrCreateBucket(tx *bolt.Tx, buckets [][]byte) (*bolt.Bucket, error) {
 	var bu *bolt.Bucket
 	for i, n := range buckets {
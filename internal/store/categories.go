@@ -0,0 +1,419 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+/*
+Categories nest to any depth.  Each one is a bucket under products that
+holds its sub-categories (nested buckets), its products (title -> json)
+and, if they have been set, its price and the order its sub-categories
+and products are shown in.
+
+products
+   _order_
+   Cards
+      _price_
+      _order_
+      Birthday
+         Funny
+            you-are-old: product
+      Anniversary
+         fortune-cookie: product
+   Note Pads
+      _price_
+      lined: product
+*/
+
+const (
+	priceKey = "_price_"
+	orderKey = "_order_"
+)
+
+var (
+	//ErrBadName is returned when a category would have a name that
+	//can't be part of a path.
+	ErrBadName = errors.New(`a category name can't be blank, contain a "/", "_price_" or "_order_"`)
+)
+
+// Category is a node in the category tree.
+type Category struct {
+	Name     string
+	Path     []string
+	Children []Category
+}
+
+func categoryBuckets(path []string) []string {
+	return append([]string{"products"}, path...)
+}
+
+// childPath is path plus name.  It never shares path's backing array.
+func childPath(path []string, name string) []string {
+	return append(append([]string{}, path...), name)
+}
+
+func samePath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func checkName(name string) error {
+	if strings.TrimSpace(name) == "" || strings.Contains(name, "/") || name == priceKey || name == orderKey {
+		return ErrBadName
+	}
+	return nil
+}
+
+// GetPrice is the price of the products in the category at path, which
+// is the price of the nearest category (itself or a parent) that has
+// one.
+func GetPrice(path []string) (Price, error) {
+	for i := len(path); i > 0; i-- {
+		var p Price
+		q := []Query{NewQuery(Buckets(categoryBuckets(path[:i])...), Key(priceKey))}
+		err := db.Get(q, func(key, val []byte) error {
+			return json.Unmarshal(val, &p)
+		})
+
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return p, err
+		}
+
+		if p.Price.Currency == "" {
+			p.Price.Currency = strings.ToUpper(cfg.Currency)
+		}
+
+		if p.WholesalePrice.Currency == "" {
+			p.WholesalePrice.Currency = strings.ToUpper(cfg.Currency)
+		}
+
+		return p, nil
+	}
+
+	return DefaultPrice()
+}
+
+func SetPrice(path []string, price Price) error {
+	d, err := json.Marshal(price)
+	if err != nil {
+		return err
+	}
+
+	q := []Query{NewQuery(Buckets(categoryBuckets(path)...), Key(priceKey), Val(d))}
+	return db.Put(q)
+}
+
+// AddCategory adds a top level category.
+func AddCategory(name string, price Price) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+
+	row := NewQuery(Buckets("products"), Key(name))
+	if err := db.AddBucket(row); err != nil {
+		return err
+	}
+
+	return SetPrice([]string{name}, price)
+}
+
+// AddSubcategory adds a category called name to the category at path.
+// It can't have the same name as one of the products that are there.
+func AddSubcategory(path []string, name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+
+	_, products, err := getCategory(path)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range products {
+		if p.Title == name {
+			return ErrExists
+		}
+	}
+
+	row := NewQuery(Buckets(categoryBuckets(path)...), Key(name))
+	return db.AddBucket(row)
+}
+
+// RenameCategory renames the category at path, along with everything in
+// it.
+func RenameCategory(path []string, name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+
+	renamed := childPath(path[:len(path)-1], name)
+	var moves [][2]string
+	err := WalkCategories(path, func(pth []string, products []Product) error {
+		dst := append(append([]string{}, renamed...), pth[len(path):]...)
+		for _, p := range products {
+			moves = append(moves, [2]string{productRevisionID(pth, p.Title), productRevisionID(dst, p.Title)})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	parent := categoryBuckets(path[:len(path)-1])
+	src := NewQuery(Buckets(parent...), Key(path[len(path)-1]))
+	dst := NewQuery(Buckets(parent...), Key(name))
+	if err := db.RenameBucket(src, dst); err != nil {
+		return err
+	}
+
+	//the products' histories go with them
+	for _, m := range moves {
+		if err := moveRevisions("products", m[0], m[1]); err != nil {
+			return err
+		}
+	}
+
+	if err := renameInOrder(path[:len(path)-1], path[len(path)-1], name); err != nil {
+		return err
+	}
+
+	ReindexSearch()
+	return RebuildTags()
+}
+
+// DeleteCategory deletes the category at path, along with everything in
+// it.
+func DeleteCategory(path []string) error {
+	rows := []Query{NewQuery(Buckets(categoryBuckets(path)...))}
+	if err := db.Delete(rows); err != nil {
+		return err
+	}
+
+	ReindexSearch()
+	return RebuildTags()
+}
+
+// SetOrder sets the order that the sub-categories and products in the
+// category at path are listed in.  Anything that isn't named comes after
+// the ones that are.
+func SetOrder(path []string, names []string) error {
+	d, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+
+	q := []Query{NewQuery(Buckets(categoryBuckets(path)...), Key(orderKey), Val(d))}
+	return db.Put(q)
+}
+
+// GetOrder returns the order set by SetOrder for the category at path.
+func GetOrder(path []string) ([]string, error) {
+	var order []string
+	q := []Query{NewQuery(Buckets(categoryBuckets(path)...), Key(orderKey))}
+	err := db.Get(q, func(key, val []byte) error {
+		return json.Unmarshal(val, &order)
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return order, err
+}
+
+// renameInOrder keeps a renamed sub-category or product in its place.
+func renameInOrder(path []string, old, name string) error {
+	order, err := GetOrder(path)
+	if err != nil || len(order) == 0 {
+		return err
+	}
+
+	for i, n := range order {
+		if n == old {
+			order[i] = name
+		}
+	}
+	return SetOrder(path, order)
+}
+
+// sortByOrder stably moves the names found in order to the front, in
+// that order.  The rest keep the (alphabetical) order bolt gave them.
+func sortByOrder(names []string, order []string) []int {
+	pos := make(map[string]int, len(order))
+	for i, n := range order {
+		if _, ok := pos[n]; !ok {
+			pos[n] = i
+		}
+	}
+
+	idx := make([]int, len(names))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	rank := func(i int) int {
+		if p, ok := pos[names[i]]; ok {
+			return p
+		}
+		return len(order)
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		return rank(idx[i]) < rank(idx[j])
+	})
+	return idx
+}
+
+// GetCategories returns the names of the top level categories.
+func GetCategories() ([]string, error) {
+	return GetSubCategories()
+}
+
+// GetSubCategories returns the names of the categories in the category
+// at path.
+func GetSubCategories(path ...string) ([]string, error) {
+	cats, _, err := getCategory(path)
+	return cats, err
+}
+
+// IsCategory is true if path names a category rather than a product.
+func IsCategory(path []string) (bool, error) {
+	if len(path) == 0 {
+		return true, nil
+	}
+
+	_, err := GetSubCategories(path...)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetProducts returns the products that are directly in the category at
+// path (and not in one of its sub-categories).
+func GetProducts(path []string, opts ...func(*Product)) ([]Product, error) {
+	_, products, err := getCategory(path, opts...)
+	return products, err
+}
+
+// GetCategoryTree returns every category.
+func GetCategoryTree() ([]Category, error) {
+	cats, err := getCategoryTree(nil)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return cats, err
+}
+
+func getCategoryTree(path []string) ([]Category, error) {
+	names, err := GetSubCategories(path...)
+	if err != nil {
+		return nil, err
+	}
+
+	cats := make([]Category, len(names))
+	for i, n := range names {
+		p := childPath(path, n)
+		children, err := getCategoryTree(p)
+		if err != nil {
+			return nil, err
+		}
+		cats[i] = Category{Name: n, Path: p, Children: children}
+	}
+	return cats, nil
+}
+
+// WalkCategories calls f for the category at root and every category
+// below it, parents before their children, with the products that are
+// directly in each.  An empty root walks every category.
+func WalkCategories(root []string, f func(path []string, products []Product) error) error {
+	err := walkCategories(root, f)
+	if err == ErrNotFound && len(root) == 0 {
+		return nil
+	}
+	return err
+}
+
+func walkCategories(path []string, f func([]string, []Product) error) error {
+	children, products, err := getCategory(path)
+	if err != nil {
+		return err
+	}
+
+	if len(path) > 0 {
+		if err := f(path, products); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range children {
+		if err := walkCategories(childPath(path, c), f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getCategory reads the category at path.  Nested buckets are its
+// sub-categories and everything else but the price is a product.
+func getCategory(path []string, opts ...func(*Product)) ([]string, []Product, error) {
+	var children []string
+	var products []Product
+	var order []string
+	q := NewQuery(Buckets(categoryBuckets(path)...))
+	err := db.GetAll(q, func(key, val []byte) error {
+		k := string(key)
+		switch {
+		case k == priceKey:
+		case k == orderKey:
+			return json.Unmarshal(val, &order)
+		case val == nil:
+			children = append(children, k)
+		default:
+			p := Product{Weight: cfg.DefaultWeight}
+			if err := json.Unmarshal(val, &p); err != nil {
+				return err
+			}
+			p.Title = k
+			p.Path = append([]string{}, path...)
+
+			for _, o := range opts {
+				o(&p)
+			}
+			products = append(products, p)
+		}
+		return nil
+	})
+
+	if err != nil || len(order) == 0 {
+		return children, products, err
+	}
+
+	sortedChildren := make([]string, len(children))
+	for i, j := range sortByOrder(children, order) {
+		sortedChildren[i] = children[j]
+	}
+
+	titles := make([]string, len(products))
+	for i, p := range products {
+		titles[i] = p.Title
+	}
+
+	sortedProducts := make([]Product, len(products))
+	for i, j := range sortByOrder(titles, order) {
+		sortedProducts[i] = products[j]
+	}
+	return sortedChildren, sortedProducts, nil
+}
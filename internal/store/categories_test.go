@@ -0,0 +1,121 @@
+package store_test
+
+import (
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/money"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("categories", func() {
+
+	var (
+		db *mock.DB
+	)
+
+	BeforeEach(func() {
+		db = mock.NewDB(map[string][]mock.Result{
+			"products":                           []mock.Result{{Key: []byte("Cards")}, {Key: []byte("Note Pads")}},
+			"products Cards":                     []mock.Result{{Key: []byte("_price_"), Val: []byte(`{"price": {"amount": 500, "currency": "USD"}}`)}, {Key: []byte("Birthday")}},
+			"products Cards Birthday":            []mock.Result{{Key: []byte("Funny")}, {Key: []byte("Happy Cake"), Val: []byte(`{"description": "cake"}`)}},
+			"products Cards Birthday Funny":      []mock.Result{{Key: []byte("Old"), Val: []byte(`{"description": "so old"}`)}},
+			"products Note Pads":                 []mock.Result{{Key: []byte("NOSUBCATEGORIES")}},
+			"products Note Pads NOSUBCATEGORIES": []mock.Result{{Key: []byte("Lined"), Val: []byte(`{"description": "lines", "tags": ["paper"]}`)}},
+		}, make([]error, 30))
+		store.Init(config.Config{DefaultPrice: "1.00", WholesalePrice: "0.50", Currency: "USD"}, store.SetDB(db))
+	})
+
+	It("separates sub-categories from products", func() {
+		cats, err := store.GetSubCategories("Cards", "Birthday")
+		Expect(err).To(BeNil())
+		Expect(cats).To(Equal([]string{"Funny"}))
+
+		products, err := store.GetProducts([]string{"Cards", "Birthday"})
+		Expect(err).To(BeNil())
+		Expect(products).To(HaveLen(1))
+		Expect(products[0].Title).To(Equal("Happy Cake"))
+		Expect(products[0].Path).To(Equal([]string{"Cards", "Birthday"}))
+		Expect(products[0].Link()).To(Equal("/shop/Cards/Birthday/Happy Cake"))
+	})
+
+	It("builds the tree", func() {
+		tree, err := store.GetCategoryTree()
+		Expect(err).To(BeNil())
+		Expect(tree).To(HaveLen(2))
+		Expect(tree[0].Children[0].Children[0].Path).To(Equal([]string{"Cards", "Birthday", "Funny"}))
+	})
+
+	It("uses its own price", func() {
+		p, err := store.GetPrice([]string{"Cards"})
+		Expect(err).To(BeNil())
+		Expect(p.Price).To(Equal(money.New(500, "USD")))
+	})
+
+	It("uses the default price when no category has one", func() {
+		db = mock.NewDB(map[string][]mock.Result{}, []error{store.ErrNotFound})
+		store.Init(config.Config{DefaultPrice: "1.00", WholesalePrice: "0.50", Currency: "USD"}, store.SetDB(db))
+
+		p, err := store.GetPrice([]string{"Note Pads"})
+		Expect(err).To(BeNil())
+		Expect(p.Price).To(Equal(money.New(100, "USD")))
+	})
+
+	It("won't make a category that can't be in a path", func() {
+		Expect(store.AddSubcategory([]string{"Cards"}, "a/b")).To(Equal(store.ErrBadName))
+		Expect(store.AddSubcategory([]string{"Cards"}, "_price_")).To(Equal(store.ErrBadName))
+		Expect(store.AddSubcategory([]string{"Cards"}, "_order_")).To(Equal(store.ErrBadName))
+	})
+
+	It("lists sub-categories and products in their set order", func() {
+		db = mock.NewDB(map[string][]mock.Result{
+			"products": []mock.Result{
+				{Key: []byte("Apple"), Val: []byte(`{}`)},
+				{Key: []byte("Bags")},
+				{Key: []byte("Cards")},
+				{Key: []byte("Dog"), Val: []byte(`{}`)},
+				{Key: []byte("Egg"), Val: []byte(`{}`)},
+				{Key: []byte("_order_"), Val: []byte(`["Egg", "Cards", "Gone", "Apple"]`)},
+			},
+		}, make([]error, 30))
+		store.Init(config.Config{DefaultPrice: "1.00", WholesalePrice: "0.50", Currency: "USD"}, store.SetDB(db))
+
+		cats, err := store.GetCategories()
+		Expect(err).To(BeNil())
+		Expect(cats).To(Equal([]string{"Cards", "Bags"}))
+
+		products, err := store.GetProducts(nil)
+		Expect(err).To(BeNil())
+		Expect(products).To(HaveLen(3))
+		Expect(products[0].Title).To(Equal("Egg"))
+		Expect(products[1].Title).To(Equal("Apple"))
+		Expect(products[2].Title).To(Equal("Dog"))
+	})
+
+	It("stores the order", func() {
+		Expect(store.SetOrder([]string{"Cards"}, []string{"Birthday", "Anniversary"})).To(BeNil())
+		Expect(db.Rows).To(HaveLen(1))
+		Expect(db.Rows[0].Buckets).To(Equal([][]byte{[]byte("products"), []byte("Cards")}))
+		Expect(string(db.Rows[0].Key)).To(Equal("_order_"))
+		Expect(string(db.Rows[0].Val)).To(MatchJSON(`["Birthday", "Anniversary"]`))
+	})
+
+	It("moves products out of NOSUBCATEGORIES", func() {
+		Expect(store.MigrateCategories()).To(BeNil())
+
+		var moved, deleted bool
+		for _, r := range db.Rows {
+			b := string(r.Buckets[len(r.Buckets)-1])
+			switch {
+			case len(r.Buckets) == 2 && b == "Note Pads" && string(r.Key) == "Lined":
+				Expect(string(r.Val)).To(MatchJSON(`{"description": "lines", "id": "", "tags": ["paper"]}`))
+				moved = true
+			case b == "NOSUBCATEGORIES" && r.Key == nil:
+				deleted = true
+			}
+		}
+		Expect(moved).To(BeTrue())
+		Expect(deleted).To(BeTrue())
+	})
+})
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Comments are kept in a bucket for each blog, keyed by when they were
+made so they come back in order.
+
+comments
+   2018-03-01:Spring
+      2018-03-02T10:04:05.123456789Z: comment
+*/
+
+// maxComment is the longest comment body, in bytes.
+const maxComment = 5000
+
+// ErrBadComment is returned for a comment without a name or a body, or
+// with a body that is too long.
+var ErrBadComment = errors.New("a comment needs a name and a body of no more than 5000 characters")
+
+// CommentStatus is where a comment is in moderation.  Only approved
+// comments are shown.
+type CommentStatus string
+
+const (
+	CommentPending  CommentStatus = "pending"
+	CommentApproved CommentStatus = "approved"
+	CommentRejected CommentStatus = "rejected"
+	CommentSpam     CommentStatus = "spam"
+)
+
+// CommentStatuses are the statuses in the order the moderation page
+// lists them.
+var CommentStatuses = []CommentStatus{CommentPending, CommentApproved, CommentRejected, CommentSpam}
+
+// ParseCommentStatus reads a status sent by the moderation page.
+func ParseCommentStatus(s string) (CommentStatus, error) {
+	for _, st := range CommentStatuses {
+		if CommentStatus(s) == st {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("invalid comment status %q", s)
+}
+
+type Comment struct {
+	ID      string        `json:"-"`
+	Blog    string        `json:"-"`
+	Name    string        `json:"name" schema:"name"`
+	Email   string        `json:"email,omitempty" schema:"email"`
+	Body    string        `json:"body" schema:"body"`
+	IP      string        `json:"ip,omitempty" schema:"-"`
+	Status  CommentStatus `json:"status" schema:"-"`
+	Created time.Time     `json:"created" schema:"-"`
+}
+
+func commentBuckets(blog string) []string {
+	return []string{"comments", blog}
+}
+
+// AddComment saves c as a comment on c.Blog that is waiting to be
+// moderated.
+func AddComment(c *Comment) error {
+	//the name and email go in the admin's notification email, where a
+	//line break would start a header of the commenter's choosing
+	c.Name = strings.TrimSpace(strings.NewReplacer("\r", " ", "\n", " ").Replace(c.Name))
+	c.Body = strings.TrimSpace(c.Body)
+	if c.Name == "" || c.Body == "" || len(c.Body) > maxComment {
+		return ErrBadComment
+	}
+
+	if c.Email = strings.TrimSpace(c.Email); c.Email != "" {
+		a, err := mail.ParseAddress(c.Email)
+		if err != nil {
+			return ErrBadComment
+		}
+		c.Email = a.Address
+	}
+
+	c.Created = time.Now().UTC()
+	c.ID = c.Created.Format(time.RFC3339Nano)
+	c.Status = CommentPending
+	return c.save()
+}
+
+func (c *Comment) save() error {
+	d, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return db.Put([]Query{NewQuery(Buckets(commentBuckets(c.Blog)...), Key(c.ID), Val(d))})
+}
+
+// Comments returns every comment on the blog, oldest first.
+func Comments(blog string) ([]Comment, error) {
+	var comments []Comment
+	err := db.GetAll(NewQuery(Buckets(commentBuckets(blog)...)), func(key, val []byte) error {
+		c := Comment{ID: string(key), Blog: blog}
+		if err := json.Unmarshal(val, &c); err != nil {
+			return err
+		}
+		comments = append(comments, c)
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return comments, err
+}
+
+// ApprovedComments returns the comments on the blog that readers can
+// see, oldest first.
+func ApprovedComments(blog string) ([]Comment, error) {
+	comments, err := Comments(blog)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Comment
+	for _, c := range comments {
+		if c.Status == CommentApproved {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// CommentsWithStatus returns the comments on every blog that have the
+// status, oldest first.
+func CommentsWithStatus(s CommentStatus) ([]Comment, error) {
+	var blogs []string
+	err := db.GetAll(NewQuery(Buckets("comments")), func(key, _ []byte) error {
+		blogs = append(blogs, string(key))
+		return nil
+	})
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var out []Comment
+	for _, b := range blogs {
+		comments, err := Comments(b)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range comments {
+			if c.Status == s {
+				out = append(out, c)
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// GetComment returns one of the comments on the blog.
+func GetComment(blog, id string) (Comment, error) {
+	c := Comment{ID: id, Blog: blog}
+	q := []Query{NewQuery(Buckets(commentBuckets(blog)...), Key(id))}
+	err := db.Get(q, func(_, val []byte) error {
+		return json.Unmarshal(val, &c)
+	})
+
+	if err == nil && c.Status == "" {
+		err = ErrNotFound
+	}
+	return c, err
+}
+
+// ModerateComment changes the status of one of the comments on the
+// blog.
+func ModerateComment(blog, id string, s CommentStatus) (Comment, error) {
+	c, err := GetComment(blog, id)
+	if err != nil {
+		return c, err
+	}
+
+	c.Status = s
+	return c, c.save()
+}
+
+// moveComments keeps a blog's comments with it when its key changes.
+func moveComments(old, key string) error {
+	comments, err := Comments(old)
+	if err != nil || len(comments) == 0 {
+		return err
+	}
+
+	return db.RenameBucket(
+		NewQuery(Buckets("comments"), Key(old)),
+		NewQuery(Buckets("comments"), Key(key)),
+	)
+}
+
+// deleteComments deletes all the comments on a blog.
+func deleteComments(blog string) error {
+	comments, err := Comments(blog)
+	if err != nil || len(comments) == 0 {
+		return err
+	}
+	return db.Delete([]Query{NewQuery(Buckets(commentBuckets(blog)...))})
+}
@@ -0,0 +1,104 @@
+package store_test
+
+import (
+	"encoding/json"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("comments", func() {
+
+	var (
+		db *mock.DB
+	)
+
+	BeforeEach(func() {
+		db = mock.NewDB(map[string][]mock.Result{
+			"comments": []mock.Result{
+				{Key: []byte("2018-03-01:Spring")},
+				{Key: []byte("2018-04-01:Summer")},
+			},
+			"comments 2018-03-01:Spring": []mock.Result{
+				{Key: []byte("2018-03-02T10:00:00Z"), Val: []byte(`{"name": "bob", "body": "nice", "status": "approved"}`)},
+				{Key: []byte("2018-03-03T10:00:00Z"), Val: []byte(`{"name": "spam", "body": "buy", "status": "pending"}`)},
+			},
+			"comments 2018-04-01:Summer": []mock.Result{
+				{Key: []byte("2018-03-02T11:00:00Z"), Val: []byte(`{"name": "sue", "body": "hi", "status": "pending"}`)},
+			},
+		}, make([]error, 30))
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	It("holds new comments for moderation", func() {
+		c := store.Comment{Blog: "2018-03-01:Spring", Name: " bob ", Body: "lovely cards"}
+		Expect(store.AddComment(&c)).To(BeNil())
+		Expect(c.Name).To(Equal("bob"))
+		Expect(c.Status).To(Equal(store.CommentPending))
+		Expect(c.ID).ToNot(Equal(""))
+
+		r := db.Rows[len(db.Rows)-1]
+		Expect(r.Buckets).To(Equal([][]byte{[]byte("comments"), []byte("2018-03-01:Spring")}))
+		Expect(string(r.Key)).To(Equal(c.ID))
+
+		var saved store.Comment
+		Expect(json.Unmarshal(r.Val, &saved)).To(BeNil())
+		Expect(saved.Body).To(Equal("lovely cards"))
+		Expect(saved.Status).To(Equal(store.CommentPending))
+	})
+
+	It("won't save a comment without a body", func() {
+		c := store.Comment{Blog: "2018-03-01:Spring", Name: "bob", Body: "  "}
+		Expect(store.AddComment(&c)).To(Equal(store.ErrBadComment))
+	})
+
+	It("keeps line breaks out of the name", func() {
+		c := store.Comment{Blog: "2018-03-01:Spring", Name: "bob\r\nBcc: everyone@example.com", Body: "hi"}
+		Expect(store.AddComment(&c)).To(BeNil())
+		Expect(c.Name).To(Equal("bob  Bcc: everyone@example.com"))
+	})
+
+	It("won't save a comment with a bad email address", func() {
+		c := store.Comment{Blog: "2018-03-01:Spring", Name: "bob", Email: "bob@example.com\r\nBcc: everyone@example.com", Body: "hi"}
+		Expect(store.AddComment(&c)).To(Equal(store.ErrBadComment))
+
+		c = store.Comment{Blog: "2018-03-01:Spring", Name: "bob", Email: "Bob <bob@example.com>", Body: "hi"}
+		Expect(store.AddComment(&c)).To(BeNil())
+		Expect(c.Email).To(Equal("bob@example.com"))
+	})
+
+	It("only shows approved comments", func() {
+		comments, err := store.ApprovedComments("2018-03-01:Spring")
+		Expect(err).To(BeNil())
+		Expect(comments).To(HaveLen(1))
+		Expect(comments[0].Name).To(Equal("bob"))
+		Expect(comments[0].ID).To(Equal("2018-03-02T10:00:00Z"))
+	})
+
+	It("queues the pending comments from every blog, oldest first", func() {
+		comments, err := store.CommentsWithStatus(store.CommentPending)
+		Expect(err).To(BeNil())
+		Expect(comments).To(HaveLen(2))
+		Expect(comments[0].Name).To(Equal("sue"))
+		Expect(comments[0].Blog).To(Equal("2018-04-01:Summer"))
+		Expect(comments[1].Name).To(Equal("spam"))
+	})
+
+	It("moderates a comment", func() {
+		c, err := store.ModerateComment("2018-03-01:Spring", "2018-03-03T10:00:00Z", store.CommentSpam)
+		Expect(err).To(BeNil())
+		Expect(c.Status).To(Equal(store.CommentSpam))
+
+		r := db.Rows[len(db.Rows)-1]
+		Expect(string(r.Key)).To(Equal("2018-03-03T10:00:00Z"))
+		Expect(string(r.Val)).To(ContainSubstring(`"status":"spam"`))
+	})
+
+	It("rejects unknown statuses", func() {
+		_, err := store.ParseCommentStatus("deleted")
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/cswank/store/internal/money"
+)
+
+// Currency is a currency prices can be shown in.  Rate is how many units
+// of it one unit of the store's own currency (STORE_CURRENCY) buys.
+type Currency struct {
+	Code   string `json:"code" schema:"code"`
+	Symbol string `json:"symbol" schema:"symbol"`
+	Rate   string `json:"rate" schema:"rate"`
+}
+
+// BaseCurrency is the currency the store does business in.
+func BaseCurrency() Currency {
+	return Currency{
+		Code:   cfg.Currency,
+		Symbol: cfg.CurrencySymbol,
+		Rate:   "1",
+	}
+}
+
+func GetCurrencies() ([]Currency, error) {
+	var currencies []Currency
+	err := db.GetAll(NewQuery(Buckets("currencies")), func(key, val []byte) error {
+		var c Currency
+		if err := json.Unmarshal(val, &c); err != nil {
+			return err
+		}
+		currencies = append(currencies, c)
+		return nil
+	})
+
+	if err == ErrNotFound {
+		err = nil
+	}
+
+	return currencies, err
+}
+
+func GetCurrency(code string) (Currency, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" || code == strings.ToUpper(cfg.Currency) {
+		return BaseCurrency(), nil
+	}
+
+	var c Currency
+	return c, db.Get([]Query{NewQuery(Key(code), Buckets("currencies"))}, func(_, val []byte) error {
+		return json.Unmarshal(val, &c)
+	})
+}
+
+func (c *Currency) Save() error {
+	c.Code = strings.ToUpper(strings.TrimSpace(c.Code))
+	if c.Code == "" {
+		return errors.New("currency code must be set")
+	}
+
+	if c.Code == strings.ToUpper(cfg.Currency) {
+		return fmt.Errorf("%s is the store's currency", c.Code)
+	}
+
+	r, err := money.ParseRate(c.Rate)
+	if err != nil || r.Sign() <= 0 {
+		return fmt.Errorf("invalid exchange rate %q", c.Rate)
+	}
+
+	d, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return db.Put([]Query{NewQuery(Key(c.Code), Val(d), Buckets("currencies"))})
+}
+
+func DeleteCurrency(code string) error {
+	return db.Delete([]Query{NewQuery(Key(code), Buckets("currencies"))})
+}
+
+// Convert changes an amount in the store's currency into c.
+func (c Currency) Convert(amount money.Money) (money.Money, error) {
+	return amount.Convert(c.Rate, c.Code)
+}
+
+// Format converts an amount in the store's currency into c and
+// formats it for display.
+func (c Currency) Format(amount money.Money) string {
+	a, err := c.Convert(amount)
+	if err != nil {
+		return amount.Format(cfg.CurrencySymbol)
+	}
+	return a.Format(c.Symbol)
+}
+
+// ToBase converts a price entered in another currency into the store's
+// currency.
+func ToBase(amount money.Money) (money.Money, error) {
+	if amount.Currency == "" || strings.EqualFold(amount.Currency, cfg.Currency) {
+		amount.Currency = strings.ToUpper(cfg.Currency)
+		return amount, nil
+	}
+
+	c, err := GetCurrency(amount.Currency)
+	if err != nil {
+		return amount, err
+	}
+
+	r, err := money.ParseRate(c.Rate)
+	if err != nil || r.Sign() <= 0 {
+		return amount, fmt.Errorf("invalid exchange rate %q", c.Rate)
+	}
+
+	base := amount.Scale(new(big.Rat).Inv(r))
+	base.Currency = strings.ToUpper(cfg.Currency)
+	return base, nil
+}
@@ -0,0 +1,148 @@
+package store_test
+
+import (
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/money"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("currency", func() {
+
+	var (
+		db      *mock.DB
+		buckets map[string][]mock.Result
+		errs    []error
+	)
+
+	BeforeEach(func() {
+		buckets = map[string][]mock.Result{
+			"currencies": []mock.Result{
+				{Key: []byte("EUR"), Val: []byte(`{"code": "EUR", "symbol": "€", "rate": "0.85"}`)},
+				{Key: []byte("GBP"), Val: []byte(`{"code": "GBP", "symbol": "£", "rate": "0.3"}`)},
+				{Key: []byte("XXX"), Val: []byte(`{"code": "XXX", "rate": ""}`)},
+			},
+		}
+		errs = make([]error, 10)
+	})
+
+	JustBeforeEach(func() {
+		db = mock.NewDB(buckets, errs)
+		store.Init(config.Config{Currency: "USD", CurrencySymbol: "$"}, store.SetDB(db))
+	})
+
+	Context("GetCurrency", func() {
+
+		It("doesn't look up the store's currency", func() {
+			c, err := store.GetCurrency(" usd ")
+			Expect(err).To(BeNil())
+			Expect(c).To(Equal(store.Currency{Code: "USD", Symbol: "$", Rate: "1"}))
+			Expect(db.Rows).To(HaveLen(0))
+		})
+
+		It("looks up other currencies by code", func() {
+			c, err := store.GetCurrency("eur")
+			Expect(err).To(BeNil())
+			Expect(c.Rate).To(Equal("0.85"))
+			Expect(string(db.Rows[0].Key)).To(Equal("EUR"))
+		})
+	})
+
+	Context("Save", func() {
+
+		It("saves the currency under its upper case code", func() {
+			c := store.Currency{Code: " jpy ", Symbol: "¥", Rate: "110.5"}
+			Expect(c.Save()).To(BeNil())
+			Expect(db.Rows).To(HaveLen(1))
+			Expect(string(db.Rows[0].Key)).To(Equal("JPY"))
+			Expect(string(db.Rows[0].Val)).To(MatchJSON(`{"code": "JPY", "symbol": "¥", "rate": "110.5"}`))
+		})
+
+		It("won't save the store's currency", func() {
+			c := store.Currency{Code: "usd", Rate: "1"}
+			Expect(c.Save()).To(MatchError("USD is the store's currency"))
+			Expect(db.Rows).To(HaveLen(0))
+		})
+
+		It("won't save a rate that isn't positive", func() {
+			for _, r := range []string{"", "abc", "0", "-1"} {
+				c := store.Currency{Code: "JPY", Rate: r}
+				Expect(c.Save()).To(HaveOccurred())
+			}
+			Expect(db.Rows).To(HaveLen(0))
+		})
+	})
+
+	Context("Convert", func() {
+
+		It("converts from the store's currency", func() {
+			c := store.Currency{Code: "EUR", Rate: "0.85"}
+			m, err := c.Convert(money.New(1000, "USD"))
+			Expect(err).To(BeNil())
+			Expect(m).To(Equal(money.New(850, "EUR")))
+		})
+
+		It("rounds half a cent away from zero", func() {
+			c := store.Currency{Code: "EUR", Rate: "1.5"}
+			m, err := c.Convert(money.New(101, "USD"))
+			Expect(err).To(BeNil())
+			Expect(m.Amount).To(Equal(int64(152)))
+
+			m, err = c.Convert(money.New(-101, "USD"))
+			Expect(err).To(BeNil())
+			Expect(m.Amount).To(Equal(int64(-152)))
+		})
+
+		It("formats in the store's currency when the rate is bad", func() {
+			c := store.Currency{Code: "EUR", Symbol: "€", Rate: ""}
+			_, err := c.Convert(money.New(450, "USD"))
+			Expect(err).To(HaveOccurred())
+			Expect(c.Format(money.New(450, "USD"))).To(Equal("$4.50"))
+		})
+	})
+
+	Context("ToBase", func() {
+
+		It("leaves the store's currency alone", func() {
+			m, err := store.ToBase(money.New(450, ""))
+			Expect(err).To(BeNil())
+			Expect(m).To(Equal(money.New(450, "USD")))
+			Expect(db.Rows).To(HaveLen(0))
+		})
+
+		It("converts using the saved rate", func() {
+			m, err := store.ToBase(money.New(850, "EUR"))
+			Expect(err).To(BeNil())
+			Expect(m).To(Equal(money.New(1000, "USD")))
+		})
+
+		It("rounds to the nearest cent", func() {
+			m, err := store.ToBase(money.New(100, "GBP"))
+			Expect(err).To(BeNil())
+			Expect(m.Amount).To(Equal(int64(333)))
+
+			m, err = store.ToBase(money.New(200, "GBP"))
+			Expect(err).To(BeNil())
+			Expect(m.Amount).To(Equal(int64(667)))
+		})
+
+		It("won't convert with a bad saved rate", func() {
+			_, err := store.ToBase(money.New(100, "XXX"))
+			Expect(err).To(MatchError(`invalid exchange rate ""`))
+		})
+
+		Context("without a rate for the currency", func() {
+			BeforeEach(func() {
+				errs[0] = store.ErrNotFound
+			})
+
+			It("returns the error", func() {
+				m, err := store.ToBase(money.New(100, "CAD"))
+				Expect(err).To(Equal(store.ErrNotFound))
+				Expect(m).To(Equal(money.New(100, "CAD")))
+			})
+		})
+	})
+})
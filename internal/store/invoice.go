@@ -0,0 +1,114 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cswank/store/internal/money"
+)
+
+// Invoice is the record kept of every invoice sent to a wholesaler so
+// that tax collected can be reported later.
+type Invoice struct {
+	Number       int         `json:"number"`
+	Date         time.Time   `json:"date"`
+	Email        string      `json:"email"`
+	Jurisdiction string      `json:"jurisdiction"`
+	Subtotal     money.Money `json:"subtotal"`
+	Shipping     money.Money `json:"shipping"`
+	Tax          money.Money `json:"tax"`
+	Total        money.Money `json:"total"`
+	//set when the sale was exempt from tax
+	ResaleCertificate string `json:"resale_certificate,omitempty"`
+	//the currency the invoice was shown in; amounts above are always
+	//in the store's currency
+	Currency     string `json:"currency,omitempty"`
+	ExchangeRate string `json:"exchange_rate,omitempty"`
+}
+
+// Save assigns the next invoice number and stores the invoice.  Both
+// happen in one transaction so two checkouts can't get the same number.
+func (i *Invoice) Save() error {
+	return db.PutNext(NewQuery(Buckets("invoices")), func(n uint64) (Query, error) {
+		i.Number = int(n)
+		d, err := json.Marshal(i)
+		if err != nil {
+			return Query{}, err
+		}
+		return NewQuery(Key(fmt.Sprintf("%010d", i.Number)), Val(d), Buckets("invoices")), nil
+	})
+}
+
+// GetInvoices returns the invoices dated within [from, to).
+func GetInvoices(from, to time.Time) ([]Invoice, error) {
+	var invoices []Invoice
+	err := db.GetAll(NewQuery(Buckets("invoices")), func(_, val []byte) error {
+		var i Invoice
+		if err := json.Unmarshal(val, &i); err != nil {
+			return err
+		}
+		if !i.Date.Before(from) && i.Date.Before(to) {
+			invoices = append(invoices, i)
+		}
+		return nil
+	})
+
+	if err == ErrNotFound {
+		err = nil
+	}
+
+	return invoices, err
+}
+
+// TaxLine sums the invoices for one jurisdiction.
+type TaxLine struct {
+	Jurisdiction string
+	Invoices     int
+	Sales        money.Money
+	ExemptSales  money.Money
+	Tax          money.Money
+}
+
+func TaxReport(from, to time.Time) ([]TaxLine, error) {
+	invoices, err := GetInvoices(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]*TaxLine{}
+	for _, i := range invoices {
+		l, ok := m[i.Jurisdiction]
+		if !ok {
+			l = &TaxLine{Jurisdiction: i.Jurisdiction}
+			m[i.Jurisdiction] = l
+		}
+
+		l.Invoices++
+		var err error
+		if i.ResaleCertificate != "" {
+			l.ExemptSales, err = l.ExemptSales.Add(i.Subtotal)
+		} else {
+			l.Sales, err = l.Sales.Add(i.Subtotal)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if l.Tax, err = l.Tax.Add(i.Tax); err != nil {
+			return nil, err
+		}
+	}
+
+	lines := make([]TaxLine, 0, len(m))
+	for _, l := range m {
+		lines = append(lines, *l)
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Jurisdiction < lines[j].Jurisdiction
+	})
+
+	return lines, nil
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// freeLogins is how many failed logins are allowed before backing off.
+const freeLogins = 3
+
+// LoginAttempts counts the failed logins for one IP address or one
+// account.  They're kept in the db so restarting doesn't give an
+// attacker a fresh start.
+type LoginAttempts struct {
+	Key         string    `json:"key"`
+	Failures    int       `json:"failures"`
+	Last        time.Time `json:"last"`
+	LockedUntil time.Time `json:"locked_until,omitempty"`
+}
+
+// Locked is true while a lockout is in effect.
+func (a LoginAttempts) Locked() bool {
+	return time.Now().Before(a.LockedUntil)
+}
+
+// Wait is how long until another login can be tried.  Every failure
+// past the first few doubles it, starting at STORE_LOGIN_BACKOFF.
+func (a LoginAttempts) Wait() time.Duration {
+	if a.Locked() {
+		return time.Until(a.LockedUntil)
+	}
+
+	n := a.Failures - freeLogins
+	if n < 0 || a.stale() {
+		return 0
+	}
+
+	if n > 16 {
+		n = 16
+	}
+
+	w := time.Until(a.Last.Add(cfg.LoginBackoff << uint(n)))
+	if w < 0 {
+		return 0
+	}
+	return w
+}
+
+// stale attempts are forgotten once a lockout is over or nothing has
+// failed for a lockout's worth of time.
+func (a LoginAttempts) stale() bool {
+	return !a.Locked() && (!a.LockedUntil.IsZero() || time.Since(a.Last) > cfg.LoginLockout)
+}
+
+func loginKeys(ip, email string) []string {
+	return []string{
+		fmt.Sprintf("ip:%s", ip),
+		emailLoginKey(email),
+	}
+}
+
+func emailLoginKey(email string) string {
+	return fmt.Sprintf("email:%s", email)
+}
+
+func getLoginAttempts(key string) (LoginAttempts, error) {
+	a := LoginAttempts{Key: key}
+	err := db.Get([]Query{NewQuery(Key(key), Buckets("logins"))}, func(_, val []byte) error {
+		return json.Unmarshal(val, &a)
+	})
+	if err == ErrNotFound {
+		return a, nil
+	}
+	return a, err
+}
+
+// CheckLogin returns how long ip has to wait before it can try to log
+// in as email.  Zero means go ahead.
+func CheckLogin(ip, email string) (time.Duration, error) {
+	var wait time.Duration
+	for _, k := range loginKeys(ip, email) {
+		a, err := getLoginAttempts(k)
+		if err != nil {
+			return 0, err
+		}
+
+		if w := a.Wait(); w > wait {
+			wait = w
+		}
+	}
+	return wait, nil
+}
+
+// LoginFailed counts a bad password or code against both ip and email.
+// locked is true when this failure is the one that locked email out, so
+// the owner can be told about it once.
+func LoginFailed(ip, email string) (bool, error) {
+	var locked bool
+	now := time.Now()
+	keys := loginKeys(ip, email)
+	q := make([]Query, len(keys))
+	for i, k := range keys {
+		a, err := getLoginAttempts(k)
+		if err != nil {
+			return false, err
+		}
+
+		if a.stale() {
+			a = LoginAttempts{Key: k}
+		}
+
+		a.Failures++
+		a.Last = now
+		if cfg.LoginMaxAttempts > 0 && a.Failures >= cfg.LoginMaxAttempts && !a.Locked() {
+			a.LockedUntil = now.Add(cfg.LoginLockout)
+			locked = locked || i == 1
+		}
+
+		d, err := json.Marshal(a)
+		if err != nil {
+			return false, err
+		}
+		q[i] = NewQuery(Key(k), Val(d), Buckets("logins"))
+	}
+
+	return locked, db.Put(q)
+}
+
+// LoginSucceeded clears the failures for email.  The failures for the
+// ip are left to expire on their own, otherwise someone guessing
+// passwords could reset the count by logging into an account of their
+// own every few tries.
+func LoginSucceeded(email string) error {
+	k := emailLoginKey(email)
+	a, err := getLoginAttempts(k)
+	if err != nil || a.Failures == 0 {
+		return err
+	}
+
+	return db.Delete([]Query{NewQuery(Key(k), Buckets("logins"))})
+}
+
+// GetLoginAttempts returns the IP addresses and accounts that have
+// failed logins that still count, locked ones first.
+func GetLoginAttempts() ([]LoginAttempts, error) {
+	var out []LoginAttempts
+	err := db.GetAll(NewQuery(Buckets("logins")), func(_, val []byte) error {
+		var a LoginAttempts
+		if err := json.Unmarshal(val, &a); err != nil {
+			return err
+		}
+
+		if !a.stale() {
+			out = append(out, a)
+		}
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Locked() != out[j].Locked() {
+			return out[i].Locked()
+		}
+		return out[i].Last.After(out[j].Last)
+	})
+
+	return out, nil
+}
+
+// Unlock forgets the failed logins for key (ip:<address> or
+// email:<address>).
+func Unlock(key string) error {
+	return db.Delete([]Query{NewQuery(Key(key), Buckets("logins"))})
+}
@@ -0,0 +1,100 @@
+package store_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("logins", func() {
+
+	var (
+		db      *mock.DB
+		account store.LoginAttempts
+		ip      *store.LoginAttempts
+		errs    []error
+	)
+
+	BeforeEach(func() {
+		account = store.LoginAttempts{
+			Key:      "email:craig@example.com",
+			Failures: 4,
+			Last:     time.Now(),
+		}
+		ip = nil
+		errs = []error{nil, nil, nil}
+	})
+
+	JustBeforeEach(func() {
+		d, err := json.Marshal(account)
+		Expect(err).To(BeNil())
+		rows := []mock.Result{{Key: []byte(account.Key), Val: d}}
+		if ip != nil {
+			d, err := json.Marshal(ip)
+			Expect(err).To(BeNil())
+			rows = append(rows, mock.Result{Key: []byte(ip.Key), Val: d})
+		}
+		db = mock.NewDB(map[string][]mock.Result{
+			"logins": rows,
+		}, errs)
+		cfg := config.Config{
+			LoginBackoff:     time.Second,
+			LoginLockout:     time.Hour,
+			LoginMaxAttempts: 5,
+		}
+		store.Init(cfg, store.SetDB(db))
+	})
+
+	It("backs off after a few failures", func() {
+		wait, err := store.CheckLogin("1.2.3.4", "craig@example.com")
+		Expect(err).To(BeNil())
+		Expect(wait).To(BeNumerically(">", 1500*time.Millisecond))
+		Expect(wait).To(BeNumerically("<=", 2*time.Second))
+	})
+
+	It("locks the account", func() {
+		locked, err := store.LoginFailed("1.2.3.4", "craig@example.com")
+		Expect(err).To(BeNil())
+		Expect(locked).To(BeTrue())
+
+		Expect(db.Rows).To(HaveLen(4))
+		var a store.LoginAttempts
+		Expect(json.Unmarshal(db.Rows[3].Val, &a)).To(BeNil())
+		Expect(a.Failures).To(Equal(5))
+		Expect(a.Locked()).To(BeTrue())
+	})
+
+	Context("after a login", func() {
+		BeforeEach(func() {
+			ip = &store.LoginAttempts{
+				Key:      "ip:1.2.3.4",
+				Failures: 3,
+				Last:     time.Now(),
+			}
+		})
+
+		It("only clears the account's failures", func() {
+			Expect(store.LoginSucceeded("craig@example.com")).To(BeNil())
+			Expect(db.Rows).To(HaveLen(2))
+			Expect(string(db.Rows[1].Key)).To(Equal("email:craig@example.com"))
+		})
+	})
+
+	Context("after the lockout", func() {
+		BeforeEach(func() {
+			account.Failures = 5
+			account.LockedUntil = time.Now().Add(-time.Minute)
+		})
+
+		It("starts counting again", func() {
+			wait, err := store.CheckLogin("1.2.3.4", "craig@example.com")
+			Expect(err).To(BeNil())
+			Expect(wait).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+/*
+The navbar is a list of links kept in order under one key.  The links that
+depend on who is logged in (admin, account, logout and currencies) are
+added by the handlers after these.
+
+menu
+   items: [menu item, menu item, ...]
+*/
+
+// The audiences a menu item can be limited to.  Anything else in
+// MenuItem.Audiences is the name of a role.
+const (
+	AudienceGuests      = "guests"
+	AudienceCustomers   = "customers"
+	AudienceWholesalers = "wholesalers"
+	AudienceStaff       = "staff"
+)
+
+// Audiences are the built in audiences in the order the admin page shows
+// them.
+var Audiences = []string{AudienceGuests, AudienceCustomers, AudienceWholesalers, AudienceStaff}
+
+// ErrBadMenuItem is returned for a menu item without a name or with a
+// link that isn't on the site or http(s).
+var ErrBadMenuItem = errors.New("a menu item needs a name and a link that starts with / or http(s)://")
+
+type MenuItem struct {
+	Name   string `json:"name" schema:"name"`
+	Link   string `json:"link" schema:"link"`
+	Hidden bool   `json:"hidden,omitempty" schema:"hidden"`
+
+	//Shop items drop down to the categories.
+	Shop bool `json:"shop,omitempty" schema:"shop"`
+
+	//Audiences limits who sees the item.  Everyone does if it is empty.
+	Audiences []string `json:"audiences,omitempty" schema:"audiences"`
+}
+
+// External is true if the item links to another site.
+func (m MenuItem) External() bool {
+	l := strings.ToLower(m.Link)
+	return strings.HasPrefix(l, "http://") || strings.HasPrefix(l, "https://")
+}
+
+// For is true if the item is limited to audience a.
+func (m MenuItem) For(a string) bool {
+	for _, x := range m.Audiences {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultMenu is the navbar until one is saved.
+var DefaultMenu = []MenuItem{
+	{Name: "Home", Link: "/"},
+	{Name: "Shop", Link: "/shop", Shop: true},
+	{Name: "Blog", Link: "/blog", Hidden: true},
+	{Name: "About", Link: "/about", Hidden: true},
+	{Name: "Wholesale", Link: "/wholesale"},
+	{Name: "Contact", Link: "/contact"},
+	{Name: "Cart", Link: "/cart"},
+}
+
+// GetMenu returns the navbar items in order.
+func GetMenu() ([]MenuItem, error) {
+	var items []MenuItem
+	var found bool
+	err := db.Get([]Query{NewQuery(Buckets("menu"), Key("items"))}, func(_, val []byte) error {
+		found = true
+		return json.Unmarshal(val, &items)
+	})
+
+	if err == ErrNotFound || (err == nil && !found) {
+		return append([]MenuItem{}, DefaultMenu...), nil
+	}
+	return items, err
+}
+
+// SaveMenu replaces the navbar items.
+func SaveMenu(items []MenuItem) error {
+	for i, m := range items {
+		m.Name = strings.TrimSpace(m.Name)
+		m.Link = strings.TrimSpace(m.Link)
+		if m.Name == "" || !(m.External() || (strings.HasPrefix(m.Link, "/") && !strings.HasPrefix(m.Link, "//"))) {
+			return ErrBadMenuItem
+		}
+		items[i] = m
+	}
+
+	d, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return db.Put([]Query{NewQuery(Buckets("menu"), Key("items"), Val(d))})
+}
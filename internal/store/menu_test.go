@@ -0,0 +1,75 @@
+package store_test
+
+import (
+	"encoding/json"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("menu", func() {
+
+	var (
+		db      *mock.DB
+		buckets map[string][]mock.Result
+	)
+
+	BeforeEach(func() {
+		buckets = map[string][]mock.Result{}
+	})
+
+	JustBeforeEach(func() {
+		db = mock.NewDB(buckets, make([]error, 30))
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	Context("before one is saved", func() {
+		It("is the default menu", func() {
+			items, err := store.GetMenu()
+			Expect(err).To(BeNil())
+			Expect(items).To(Equal(store.DefaultMenu))
+		})
+	})
+
+	Context("once one is saved", func() {
+		BeforeEach(func() {
+			buckets["menu"] = []mock.Result{
+				{Key: []byte("items"), Val: []byte(`[{"name": "Shop", "link": "/shop", "shop": true}, {"name": "Trade", "link": "https://trade.example.com", "audiences": ["wholesalers"]}]`)},
+			}
+		})
+
+		It("gets it", func() {
+			items, err := store.GetMenu()
+			Expect(err).To(BeNil())
+			Expect(items).To(HaveLen(2))
+			Expect(items[0].Shop).To(BeTrue())
+			Expect(items[1].External()).To(BeTrue())
+			Expect(items[1].For(store.AudienceWholesalers)).To(BeTrue())
+			Expect(items[1].For(store.AudienceGuests)).To(BeFalse())
+		})
+	})
+
+	It("saves the items in order", func() {
+		Expect(store.SaveMenu([]store.MenuItem{
+			{Name: " Blog ", Link: "/blog"},
+			{Name: "Etsy", Link: "https://etsy.com/shop/x", Audiences: []string{"staff"}},
+		})).To(BeNil())
+
+		r := db.Rows[len(db.Rows)-1]
+		Expect(string(r.Key)).To(Equal("items"))
+
+		var items []store.MenuItem
+		Expect(json.Unmarshal(r.Val, &items)).To(BeNil())
+		Expect(items[0].Name).To(Equal("Blog"))
+		Expect(items[1].Audiences).To(Equal([]string{"staff"}))
+	})
+
+	It("won't save links that aren't on the site or http(s)", func() {
+		Expect(store.SaveMenu([]store.MenuItem{{Name: "x", Link: "javascript:alert(1)"}})).To(Equal(store.ErrBadMenuItem))
+		Expect(store.SaveMenu([]store.MenuItem{{Name: "x", Link: "//evil.example.com"}})).To(Equal(store.ErrBadMenuItem))
+		Expect(store.SaveMenu([]store.MenuItem{{Link: "/blog"}})).To(Equal(store.ErrBadMenuItem))
+	})
+})
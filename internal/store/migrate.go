@@ -0,0 +1,169 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cswank/store/internal/money"
+)
+
+// MigratePrices rewrites the category prices, shipping rates and invoices
+// that were stored as decimal strings and floats before amounts became
+// money.Money.  Running it more than once does no harm.
+func MigratePrices() error {
+	if err := migrateCategoryPrices(); err != nil {
+		return err
+	}
+
+	if err := migrateShippingRates(); err != nil {
+		return err
+	}
+
+	return migrateInvoices()
+}
+
+func migrateCategoryPrices() error {
+	cats, err := GetCategories()
+	if err == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, cat := range cats {
+		//old prices kept the currency next to the amounts
+		var p struct {
+			Price
+			Currency string `json:"currency"`
+		}
+
+		q := []Query{NewQuery(Buckets("products", cat), Key("_price_"))}
+		err := db.Get(q, func(_, val []byte) error {
+			return json.Unmarshal(val, &p)
+		})
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		setCurrency(&p.Price.Price, p.Currency)
+		setCurrency(&p.WholesalePrice, p.Currency)
+		if err := SetPrice([]string{cat}, p.Price); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// noSubcategories is the bucket that stood in for the subcategory of
+// products that were only in a category, back when there were exactly
+// two levels of categories.
+const noSubcategories = "NOSUBCATEGORIES"
+
+// MigrateCategories moves the products in each NOSUBCATEGORIES bucket
+// into its category, which can hold products itself now that categories
+// nest, and rebuilds the tag index, which used to address products by
+// category and subcategory.  Running it more than once does no harm.
+func MigrateCategories() error {
+	cats, err := GetCategories()
+	if err == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, cat := range cats {
+		path := []string{cat, noSubcategories}
+		products, err := GetProducts(path)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		var rows []Query
+		for _, p := range products {
+			d, err := json.Marshal(p)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, NewQuery(Key(p.Title), Val(d), Buckets("products", cat)))
+		}
+
+		if len(rows) > 0 {
+			if err := db.Put(rows); err != nil {
+				return err
+			}
+		}
+
+		if err := db.Delete([]Query{NewQuery(Buckets(categoryBuckets(path)...))}); err != nil {
+			return err
+		}
+	}
+
+	ReindexSearch()
+	return RebuildTags()
+}
+
+func migrateShippingRates() error {
+	zones, err := GetShippingZones()
+	if err != nil {
+		return err
+	}
+
+	for _, z := range zones {
+		if err := z.Save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateInvoices fills in the currency of old invoice amounts, which
+// were always in the store's currency.
+func migrateInvoices() error {
+	var rows []Query
+	err := db.GetAll(NewQuery(Buckets("invoices")), func(key, val []byte) error {
+		var i Invoice
+		if err := json.Unmarshal(val, &i); err != nil {
+			return err
+		}
+
+		for _, m := range []*money.Money{&i.Subtotal, &i.Shipping, &i.Tax, &i.Total} {
+			setCurrency(m, cfg.Currency)
+		}
+
+		d, err := json.Marshal(i)
+		if err != nil {
+			return err
+		}
+
+		rows = append(rows, NewQuery(Key(string(key)), Val(d), Buckets("invoices")))
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.Put(rows)
+}
+
+func setCurrency(m *money.Money, currency string) {
+	if m.Currency != "" {
+		return
+	}
+
+	if currency == "" {
+		currency = cfg.Currency
+	}
+	m.Currency = strings.ToUpper(currency)
+}
@@ -16,6 +16,7 @@ type DB struct {
 	i       int
 	errors  []error
 	buckets map[string][]Result
+	seqs    map[string]uint64
 	Rows    []store.Query
 }
 
@@ -23,6 +24,7 @@ func NewDB(buckets map[string][]Result, errors []error) *DB {
 	return &DB{
 		buckets: buckets,
 		errors:  errors,
+		seqs:    map[string]uint64{},
 		Rows:    []store.Query{},
 	}
 }
@@ -54,6 +56,7 @@ func (d *DB) Get(rows []store.Query, f func([]byte, []byte) error) error {
 		d.Rows = append(d.Rows, r)
 		err := d.errors[d.i]
 		if err != nil {
+			d.i++
 			return err
 		}
 		k := string(bytes.Join(r.Buckets, []byte(" ")))
@@ -84,6 +87,30 @@ func (d *DB) GetAll(row store.Query, f func(key, val []byte) error) error {
 	return err
 }
 
+// PutNext numbers the rows in a bucket after the results already in it.
+func (d *DB) PutNext(row store.Query, f func(n uint64) (store.Query, error)) error {
+	err := d.errors[d.i]
+	d.i++
+	if err != nil {
+		return err
+	}
+
+	k := string(bytes.Join(row.Buckets, []byte(" ")))
+	n, ok := d.seqs[k]
+	if !ok {
+		n = uint64(len(d.buckets[k]))
+	}
+	n++
+	d.seqs[k] = n
+
+	r, err := f(n)
+	if err != nil {
+		return err
+	}
+	d.Rows = append(d.Rows, r)
+	return nil
+}
+
 func (d *DB) Delete(rows []store.Query) error {
 	err := d.errors[d.i]
 	d.i++
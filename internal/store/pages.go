@@ -0,0 +1,200 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cswank/store/internal/markdown"
+)
+
+/*
+Pages are the parts of the site that aren't products or blogs.  The home
+and about pages are kept here too, under the slugs home and about.
+
+pages
+   about: page
+   faq: page
+*/
+
+const (
+	// HomePage is the slug of the page shown at the top of /.
+	HomePage = "home"
+	// AboutPage is the slug of the page shown at /about.
+	AboutPage = "about"
+)
+
+// PageNav is where a page is linked from.
+type PageNav string
+
+const (
+	NavNone PageNav = ""
+	// NavMain puts a link to the page in the navbar.
+	NavMain PageNav = "main"
+	// NavShop puts a link to the page at the bottom of the shop menu.
+	NavShop PageNav = "shop"
+)
+
+var (
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+	// reservedSlugs are the top level paths that the site already
+	// uses, so a page with one of them as its slug would never be seen,
+	// and the ones that /admin/pages/{page} can't be edited at.
+	reservedSlugs = map[string]bool{
+		"account": true, "admin": true, "api": true, "blog": true, "cart": true,
+		"contact": true, "css": true, "currency": true, "images": true, "js": true,
+		"login": true, "logout": true, "search": true, "shop": true, "webhooks": true,
+		"wholesale": true, "favicon": true, "robots": true, "new": true, "preview": true,
+	}
+
+	// ErrBadSlug is returned for a page slug that isn't lower case
+	// letters, numbers and dashes, or that the site already uses.
+	ErrBadSlug = errors.New("a page slug must be lower case letters, numbers and dashes and can't be one the site already uses")
+)
+
+type Page struct {
+	Slug      string    `json:"slug" schema:"slug"`
+	Title     string    `json:"title" schema:"title"`
+	Body      string    `json:"body" schema:"body"`
+	HTML      string    `json:"html,omitempty" schema:"-"`
+	Nav       PageNav   `json:"nav,omitempty" schema:"nav"`
+	Updated   time.Time `json:"updated" schema:"-"`
+	UpdatedBy string    `json:"updated_by,omitempty" schema:"-"`
+	Revision  int       `json:"-" schema:"-"`
+}
+
+// CheckSlug returns ErrBadSlug if slug can't be used for a page.
+func CheckSlug(slug string) error {
+	if !slugPattern.MatchString(slug) || reservedSlugs[slug] {
+		return ErrBadSlug
+	}
+	return nil
+}
+
+// RenderPage turns the markdown body of a page into html.  Pages can
+// show product cards and the site's own images.
+func RenderPage(body string) string {
+	return markdown.Render(body, markdown.Options{
+		Card:  productCard,
+		Image: siteImage,
+	})
+}
+
+// siteImage lets a page show the images that the site serves itself.
+func siteImage(src string) string {
+	if !strings.HasPrefix(src, "/") || strings.HasPrefix(src, "//") {
+		return ""
+	}
+	return src
+}
+
+// GetPage returns the page with the slug.
+func GetPage(slug string) (Page, error) {
+	var p Page
+	err := db.Get([]Query{NewQuery(Buckets("pages"), Key(slug))}, func(_, val []byte) error {
+		return json.Unmarshal(val, &p)
+	})
+
+	if err == nil && p.Slug == "" {
+		err = ErrNotFound
+	}
+	return p, err
+}
+
+// Pages returns every page, sorted by slug.
+func Pages() ([]Page, error) {
+	var pages []Page
+	err := db.GetAll(NewQuery(Buckets("pages")), func(_, val []byte) error {
+		var p Page
+		if err := json.Unmarshal(val, &p); err != nil {
+			return err
+		}
+		pages = append(pages, p)
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Slug < pages[j].Slug
+	})
+	return pages, err
+}
+
+// Save renders the page, keeps a copy of it in its history and saves it.
+// by is who saved it.
+func (p *Page) Save(by string) error {
+	p.Slug = strings.TrimSpace(p.Slug)
+	if err := CheckSlug(p.Slug); err != nil {
+		return err
+	}
+
+	p.Title = strings.TrimSpace(p.Title)
+	if p.Title == "" {
+		return errors.New("a page needs a title")
+	}
+
+	p.HTML = RenderPage(p.Body)
+	p.Updated = time.Now()
+	p.UpdatedBy = by
+
+	d, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	rev, err := revisionQuery("pages", p.Slug, p)
+	if err != nil {
+		return err
+	}
+	return db.Put([]Query{NewQuery(Buckets("pages"), Key(p.Slug), Val(d)), rev})
+}
+
+// DeletePage deletes the page and its history.
+func DeletePage(slug string) error {
+	if err := deleteRevisions("pages", slug); err != nil {
+		return err
+	}
+	return db.Delete([]Query{NewQuery(Buckets("pages"), Key(slug))})
+}
+
+// PageRevisions returns every saved version of the page, newest first.
+func PageRevisions(slug string) ([]Page, error) {
+	var pages []Page
+	err := eachRevision("pages", slug, func(n int, val []byte) error {
+		var p Page
+		if err := json.Unmarshal(val, &p); err != nil {
+			return err
+		}
+		p.Revision = n
+		pages = append([]Page{p}, pages...)
+		return nil
+	})
+	return pages, err
+}
+
+// GetPageRevision returns revision n of the page.
+func GetPageRevision(slug string, n int) (Page, error) {
+	var p Page
+	err := getRevision("pages", slug, n, &p)
+	p.Revision = n
+	return p, err
+}
+
+// RestorePage saves revision n of the page as its newest version, so
+// restoring can be undone too.
+func RestorePage(slug string, n int, by string) (Page, error) {
+	p, err := GetPageRevision(slug, n)
+	if err != nil {
+		return p, err
+	}
+
+	err = p.Save(by)
+	return p, err
+}
@@ -0,0 +1,112 @@
+package store_test
+
+import (
+	"encoding/json"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pages", func() {
+
+	var (
+		db *mock.DB
+	)
+
+	BeforeEach(func() {
+		db = mock.NewDB(map[string][]mock.Result{
+			"pages": []mock.Result{
+				{Key: []byte("faq"), Val: []byte(`{"slug": "faq", "title": "FAQ", "body": "ask", "nav": "main"}`)},
+				{Key: []byte("about"), Val: []byte(`{"slug": "about", "title": "About", "body": "us"}`)},
+			},
+			"revisions pages faq": []mock.Result{
+				{Key: []byte("2018-03-01T10:00:00.000000000"), Val: []byte(`{"slug": "faq", "title": "Questions", "body": "first"}`)},
+				{Key: []byte("2018-03-02T10:00:00.000000000"), Val: []byte(`{"slug": "faq", "title": "FAQ", "body": "ask"}`)},
+			},
+		}, make([]error, 30))
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	It("gets a page", func() {
+		p, err := store.GetPage("faq")
+		Expect(err).To(BeNil())
+		Expect(p.Title).To(Equal("FAQ"))
+		Expect(p.Nav).To(Equal(store.NavMain))
+	})
+
+	It("doesn't find a page that hasn't been written", func() {
+		_, err := store.GetPage("returns")
+		Expect(err).To(Equal(store.ErrNotFound))
+	})
+
+	It("lists the pages by slug", func() {
+		pages, err := store.Pages()
+		Expect(err).To(BeNil())
+		Expect(pages).To(HaveLen(2))
+		Expect(pages[0].Slug).To(Equal("about"))
+		Expect(pages[1].Slug).To(Equal("faq"))
+	})
+
+	It("keeps every version of a page it saves", func() {
+		p := store.Page{Slug: "faq", Title: " FAQ ", Body: "# Questions"}
+		Expect(p.Save("admin@example.com")).To(BeNil())
+		Expect(p.Title).To(Equal("FAQ"))
+		Expect(p.HTML).To(Equal("<h1>Questions</h1>\n"))
+
+		r := db.Rows[len(db.Rows)-2]
+		Expect(string(r.Key)).To(Equal("faq"))
+		var saved store.Page
+		Expect(json.Unmarshal(r.Val, &saved)).To(BeNil())
+		Expect(saved.UpdatedBy).To(Equal("admin@example.com"))
+
+		rev := db.Rows[len(db.Rows)-1]
+		Expect(rev.Buckets).To(Equal([][]byte{[]byte("revisions"), []byte("pages"), []byte("faq")}))
+		Expect(string(rev.Key) > "2018-03-02T10:00:00.000000000").To(BeTrue())
+		Expect(rev.Val).To(Equal(r.Val))
+	})
+
+	It("won't use a slug the site already has", func() {
+		p := store.Page{Slug: "shop", Title: "Shop"}
+		Expect(p.Save("")).To(Equal(store.ErrBadSlug))
+
+		p = store.Page{Slug: "Big Sale", Title: "Sale"}
+		Expect(p.Save("")).To(Equal(store.ErrBadSlug))
+	})
+
+	It("won't use a slug the admin pages use", func() {
+		Expect(store.CheckSlug("new")).To(Equal(store.ErrBadSlug))
+		Expect(store.CheckSlug("preview")).To(Equal(store.ErrBadSlug))
+	})
+
+	It("only shows the site's own images", func() {
+		Expect(store.RenderPage("![logo](/images/logo.png) ![x](https://evil.example.com/x.png)")).To(Equal(`<p><img src="/images/logo.png" alt="logo"/> </p>` + "\n"))
+	})
+
+	It("lists the revisions newest first", func() {
+		pages, err := store.PageRevisions("faq")
+		Expect(err).To(BeNil())
+		Expect(pages).To(HaveLen(2))
+		Expect(pages[0].Revision).To(Equal(2))
+		Expect(pages[1].Revision).To(Equal(1))
+		Expect(pages[1].Title).To(Equal("Questions"))
+	})
+
+	It("restores an old revision as a new one", func() {
+		p, err := store.RestorePage("faq", 1, "admin@example.com")
+		Expect(err).To(BeNil())
+		Expect(p.Title).To(Equal("Questions"))
+		Expect(p.Body).To(Equal("first"))
+
+		r := db.Rows[len(db.Rows)-2]
+		Expect(string(r.Key)).To(Equal("faq"))
+		Expect(string(r.Val)).To(ContainSubstring(`"body":"first"`))
+	})
+
+	It("doesn't restore a revision that doesn't exist", func() {
+		_, err := store.RestorePage("faq", 7, "")
+		Expect(err).To(Equal(store.ErrNotFound))
+	})
+})
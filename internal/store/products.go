@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"image"
 	"image/png"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/cswank/store/internal/money"
 	"github.com/cswank/store/internal/shopify"
 	"github.com/nfnt/resize"
 )
@@ -29,128 +33,19 @@ var (
 )
 
 type Price struct {
-	Price          string `json:"price"`
-	WholesalePrice string `json:"wholesale_price"`
+	Price          money.Money `json:"price"`
+	WholesalePrice money.Money `json:"wholesale_price"`
 }
 
-func GetPrice(name string) (Price, error) {
-	q := []Query{NewQuery(Buckets("products", name), Key("_price_"))}
-	var p Price
-	err := db.Get(q, func(key, val []byte) error {
-		return json.Unmarshal(val, &p)
-	})
-	if err == ErrNotFound {
-		p = Price{
-			Price:          cfg.DefaultPrice,
-			WholesalePrice: cfg.WholesalePrice,
-		}
-		err = nil
-	}
-
-	return p, err
-}
-
-func SetPrice(name string, price Price) error {
-	d, err := json.Marshal(price)
+// DefaultPrice is the price of a category that never had one set.
+func DefaultPrice() (Price, error) {
+	p, err := money.Parse(cfg.DefaultPrice, cfg.Currency)
 	if err != nil {
-		return err
+		return Price{}, err
 	}
 
-	q := []Query{NewQuery(Buckets("products", name), Key("_price_"), Val(d))}
-	return db.Put(q)
-}
-
-func AddCategory(name, p, w string) error {
-	row := NewQuery(Buckets("products"), Key(name))
-	if err := db.AddBucket(row); err != nil {
-		return err
-	}
-
-	d, err := json.Marshal(Price{Price: p, WholesalePrice: w})
-	if err != nil {
-		return err
-	}
-
-	return db.Put([]Query{
-		NewQuery(Buckets("products", name), Key("_price_"), Val(d)),
-	})
-}
-
-func RenameCategory(old, name string) error {
-	src := NewQuery(Buckets("products"), Key(old))
-	dst := NewQuery(Buckets("products"), Key(name))
-	return db.RenameBucket(src, dst)
-}
-
-func DeleteCategory(cat string) error {
-	rows := []Query{NewQuery(Buckets("products", cat))}
-	return db.Delete(rows)
-}
-
-func AddSubcategory(cat, name string) error {
-	row := NewQuery(Buckets("products", cat), Key(name))
-	return db.AddBucket(row)
-}
-
-func RenameSubcategory(cat, old, name string) error {
-	src := NewQuery(Buckets("products", cat), Key(old))
-	dst := NewQuery(Buckets("products", cat), Key(name))
-	return db.RenameBucket(src, dst)
-}
-
-func DeleteSubcategory(cat, subcat string) error {
-	rows := []Query{NewQuery(Buckets("products", cat, subcat))}
-	return db.Delete(rows)
-}
-
-func GetCategories() ([]string, error) {
-	var cats []string
-	q := NewQuery(Buckets("products"))
-	return cats, db.GetAll(q, func(key, val []byte) error {
-		cats = append(cats, string(key))
-		return nil
-	})
-}
-
-func GetSubCategories(cat string) ([]string, error) {
-	var cats []string
-	q := NewQuery(Buckets("products", cat))
-	return cats, db.GetAll(q, func(key, val []byte) error {
-		cat := string(key)
-		if cat != "_price_" {
-			cats = append(cats, cat)
-		}
-		return nil
-	})
-}
-
-func GetProductTitles(cat, subcat string) ([]string, error) {
-	var products []string
-	q := NewQuery(Buckets("products", cat, subcat))
-	return products, db.GetAll(q, func(key, val []byte) error {
-		products = append(products, string(key))
-		return nil
-	})
-}
-
-func GetProducts(cat, subcat string, opts ...func(*Product)) ([]Product, error) {
-	var products []Product
-	q := NewQuery(Buckets("products", cat, subcat))
-	return products, db.GetAll(q, func(key, val []byte) error {
-		var p Product
-		err := json.Unmarshal(val, &p)
-		if err != nil {
-			return err
-		}
-		p.Title = string(key)
-
-		for _, o := range opts {
-			o(&p)
-		}
-
-		products = append(products, p)
-		return nil
-	})
+	w, err := money.Parse(cfg.WholesalePrice, cfg.Currency)
+	return Price{Price: p, WholesalePrice: w}, err
 }
 
 func GetBackup(w http.ResponseWriter) error {
@@ -158,24 +53,33 @@ func GetBackup(w http.ResponseWriter) error {
 }
 
 type Product struct {
-	Title       string `json:"-"`
-	Cat         string `json:"-"`
-	Subcat      string `json:"-"`
-	Price       string `json:"-"`
-	Total       string `json:"-"`
-	Quantity    int    `json:"-"`
-	Description string `json:"description"`
-	ID          string `json:"id"`
+	Title       string      `json:"-"`
+	Path        []string    `json:"-"` //the category it is in
+	Price       money.Money `json:"-"`
+	Total       money.Money `json:"-"`
+	Quantity    int         `json:"-"`
+	Description string      `json:"description"`
+	ID          string      `json:"id"`
+	Weight      int         `json:"weight,omitempty"` //grams
+	Tags        []string    `json:"tags,omitempty"`
+	SoldOut     bool        `json:"sold_out,omitempty"`
+	Status      Status      `json:"status,omitempty"`
+	PublishAt   *time.Time  `json:"publish_at,omitempty"`
+
+	//EditedBy is who is saving the product, for its history.
+	EditedBy string `json:"-"`
 
 	image io.Reader
 }
 
-func NewProduct(title, cat, subcat string, opts ...func(*Product)) *Product {
+// NewProduct is the product called title in the category at path.
+func NewProduct(title string, path []string, opts ...func(*Product)) *Product {
+	price, _ := money.Parse(cfg.DefaultPrice, cfg.Currency)
 	p := &Product{
 		Title:  title,
-		Cat:    cat,
-		Subcat: subcat,
-		Price:  cfg.DefaultPrice,
+		Path:   path,
+		Price:  price,
+		Weight: cfg.DefaultWeight,
 	}
 
 	for _, o := range opts {
@@ -184,7 +88,7 @@ func NewProduct(title, cat, subcat string, opts ...func(*Product)) *Product {
 	return p
 }
 
-func ProductPrice(price string) func(*Product) {
+func ProductPrice(price money.Money) func(*Product) {
 	return func(p *Product) {
 		p.Price = price
 	}
@@ -196,12 +100,23 @@ func ProductDescription(d string) func(*Product) {
 	}
 }
 
+func ProductWeight(w int) func(*Product) {
+	return func(p *Product) {
+		p.Weight = w
+	}
+}
+
 func ProductImage(r io.Reader) func(*Product) {
 	return func(p *Product) {
 		p.image = r
 	}
 }
 
+// Link is the product's page in the shop.
+func (p *Product) Link() string {
+	return "/shop/" + strings.Join(childPath(p.Path, p.Title), "/")
+}
+
 func (p *Product) Fetch() error {
 	if p.Title == "" {
 		return errors.New("product title must be set")
@@ -212,32 +127,46 @@ func (p *Product) Fetch() error {
 	})
 }
 
-func (p *Product) Update(p2 *Product) error {
+// Merge is p with the changes in p2, which is what Update saves.  A
+// blank status in p2 leaves p's as it is, and the title stays the same
+// since products can't be renamed yet.
+func (p Product) Merge(p2 *Product) Product {
+	p.Path = p2.Path
 	p.Description = p2.Description
+	p.Weight = p2.Weight
+	p.Tags = p2.Tags
+	p.SoldOut = p2.SoldOut
+	p.EditedBy = p2.EditedBy
+	if p2.Status != "" {
+		p.Status = p2.Status
+		p.PublishAt = p2.PublishAt
+	}
+	return p
+}
 
-	if p2.Subcat != p.Subcat {
-		if err := p.move(p2.Subcat); err != nil {
+// Update saves the changes in p2 (see Merge).
+func (p *Product) Update(p2 *Product) error {
+	path := p.Path
+	tags := p.Tags
+	if !samePath(p2.Path, p.Path) {
+		if err := p.move(p2.Path); err != nil {
 			return err
 		}
 	}
 
+	*p = p.Merge(p2)
+
 	if p2.Title != p.Title {
 		//rename images
 		//delete old key
 		//save new key
 	}
 
-	buckets := []string{
-		"products",
-		p.Cat,
-		p.Subcat,
-	}
-
 	d, err := json.Marshal(p)
 	if err != nil {
 		return err
 	}
-	rows := []Query{NewQuery(Key(p.Title), Val(d), Buckets(buckets...))}
+	rows := append([]Query{NewQuery(Key(p.Title), Val(d), Buckets(categoryBuckets(p.Path)...))}, p.tagQueries()...)
 
 	if p2.image != nil {
 		var imgQueries []Query
@@ -248,10 +177,41 @@ func (p *Product) Update(p2 *Product) error {
 		rows = append(rows, imgQueries...)
 	}
 
-	return db.Put(rows)
+	rev, err := p.revisionQuery(p.EditedBy)
+	if err != nil {
+		return err
+	}
+	rows = append(rows, rev)
+
+	if err := db.Put(rows); err != nil {
+		return err
+	}
+
+	if !samePath(path, p.Path) {
+		//the history goes with the product
+		if err := moveRevisions("products", productRevisionID(path, p.Title), productRevisionID(p.Path, p.Title)); err != nil {
+			return err
+		}
+	}
+
+	removed := removedTags(tags, p.Tags)
+	if !p.Live() || !samePath(path, p.Path) {
+		removed = tags
+	}
+
+	if len(removed) > 0 {
+		if err := db.Delete(untagQueries(path, p.Title, removed)); err != nil {
+			return err
+		}
+	}
+
+	unindexProduct(path, p.Title)
+	indexProduct(p)
+	return nil
 }
 
-func (p *Product) move(dst string) error {
+// move puts the product in the category at dst.
+func (p *Product) move(dst []string) error {
 	if err := p.Fetch(); err != nil {
 		return err
 	}
@@ -261,10 +221,7 @@ func (p *Product) move(dst string) error {
 	}
 
 	q := p.query()
-	b := q[0].Buckets
-	b[2] = []byte(dst)
-	q[0].Buckets = b
-
+	q[0].Buckets = NewQuery(Buckets(categoryBuckets(dst)...)).Buckets
 	return db.Put(q)
 }
 
@@ -273,13 +230,19 @@ func (p *Product) Delete() error {
 		return err
 	}
 	q := append(p.query(), p.imageQuery()...)
-	return db.Delete(q)
+	q = append(q, untagQueries(p.Path, p.Title, p.Tags)...)
+	if err := db.Delete(q); err != nil {
+		return err
+	}
+
+	unindexProduct(p.Path, p.Title)
+	return nil
 }
 
 func (p *Product) query() []Query {
 	d, _ := json.Marshal(p)
 	return []Query{
-		NewQuery(Key(p.Title), Val(d), Buckets("products", p.Cat, p.Subcat)),
+		NewQuery(Key(p.Title), Val(d), Buckets(categoryBuckets(p.Path)...)),
 	}
 }
 
@@ -290,7 +253,11 @@ func (p *Product) imageQuery() []Query {
 }
 
 func (p *Product) Add(r io.Reader) error {
-	id, err := shopify.Create(p.Title, p.Cat, cfg.DefaultPrice)
+	if len(p.Path) == 0 {
+		return errors.New("a product has to be in a category")
+	}
+
+	id, err := shopify.Create(p.Title, p.Path[0], cfg.DefaultPrice)
 	if err != nil {
 		return err
 	}
@@ -301,20 +268,30 @@ func (p *Product) Add(r io.Reader) error {
 		return err
 	}
 
-	rows, err = p.getSubcat(rows)
+	rows, err = p.addRow(rows)
 	if err != nil {
 		return err
 	}
+	rows = append(rows, p.tagQueries()...)
+
+	rev, err := p.revisionQuery(p.EditedBy)
+	if err != nil {
+		return err
+	}
+	rows = append(rows, rev)
 
 	if err := db.Put(rows); err != nil {
 		return err
 	}
 
+	indexProduct(p)
 	return shopify.AddImage(id, img)
 }
 
-func (p *Product) getSubcat(rows []Query) ([]Query, error) {
-	q := NewQuery(Buckets("products", p.Cat, p.Subcat))
+// addRow appends the product's row to rows unless its category already
+// has a product or sub-category with its title.
+func (p *Product) addRow(rows []Query) ([]Query, error) {
+	q := NewQuery(Buckets(categoryBuckets(p.Path)...))
 	err := db.GetAll(q, func(key, val []byte) error {
 		id := string(key)
 		if id == p.Title {
@@ -327,17 +304,11 @@ func (p *Product) getSubcat(rows []Query) ([]Query, error) {
 		return []Query{}, err
 	}
 
-	buckets := []string{
-		"products",
-		p.Cat,
-		p.Subcat,
-	}
-
 	d, err := json.Marshal(p)
 	if err != nil {
 		return []Query{}, err
 	}
-	r := NewQuery(Key(p.Title), Val(d), Buckets(buckets...))
+	r := NewQuery(Key(p.Title), Val(d), Buckets(categoryBuckets(p.Path)...))
 	rows = append(rows, r)
 	return rows, nil
 }
@@ -375,18 +346,79 @@ func resizeImage(img image.Image, size uint) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-/*
-products
-   Cards
-      Happy Birthday
-        uid1: shopify product id
-        uid2: shopify product id
-      Anniversary
-        uid2: shopify product id
-        uid4: shopify product id
-
-images
-  "uid1"
-      thumb
-      image
-*/
+// ProductRevision is a saved version of a product.
+type ProductRevision struct {
+	Revision
+	Path    []string `json:"path"`
+	Product Product  `json:"product"`
+}
+
+// Text is the revision as lines that can be compared with another one.
+func (r ProductRevision) Text() string {
+	p := r.Product
+	lines := []string{
+		"Category: " + strings.Join(r.Path, "/"),
+		"Status: " + string(statusOf(p.Status)),
+		fmt.Sprintf("Weight: %d", p.Weight),
+		"Tags: " + strings.Join(p.Tags, ", "),
+		fmt.Sprintf("Sold out: %t", p.SoldOut),
+	}
+
+	if p.PublishAt != nil {
+		lines = append(lines, "Publish at: "+p.PublishAt.Format(time.RFC3339))
+	}
+	return strings.Join(append(lines, "", p.Description), "\n")
+}
+
+// productRevisionID is where the history of the product called title in
+// the category at path is kept.  Titles are only unique in a category.
+func productRevisionID(path []string, title string) string {
+	return strings.Join(childPath(path, title), "/")
+}
+
+func (p *Product) revisionQuery(by string) (Query, error) {
+	r := ProductRevision{Revision: newRevision(by), Path: p.Path, Product: *p}
+	return revisionQuery("products", productRevisionID(p.Path, p.Title), r)
+}
+
+// ProductRevisions returns every saved version of the product called
+// title in the category at path, newest first.
+func ProductRevisions(path []string, title string) ([]ProductRevision, error) {
+	var revs []ProductRevision
+	err := eachRevision("products", productRevisionID(path, title), func(n int, val []byte) error {
+		var r ProductRevision
+		if err := json.Unmarshal(val, &r); err != nil {
+			return err
+		}
+		r.N = n
+		revs = append([]ProductRevision{r}, revs...)
+		return nil
+	})
+	return revs, err
+}
+
+// Restore saves revision n of the product as its newest version.  The
+// product stays in the category it is in now and keeps its image.
+func (p *Product) Restore(n int, by string) error {
+	p2, err := p.Restoring(n, by)
+	if err != nil {
+		return err
+	}
+	return p.Update(p2)
+}
+
+// Restoring is the change that Restore passes to Update.
+func (p *Product) Restoring(n int, by string) (*Product, error) {
+	var r ProductRevision
+	if err := getRevision("products", productRevisionID(p.Path, p.Title), n, &r); err != nil {
+		return nil, err
+	}
+
+	p2 := r.Product
+	p2.Path = p.Path
+	p2.EditedBy = by
+	if p2.Status == "" {
+		p2.Status = Published
+	}
+	return &p2, nil
+}
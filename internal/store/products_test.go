@@ -120,7 +120,7 @@ var _ = Describe("products", func() {
 		)
 
 		BeforeEach(func() {
-			prod = store.NewProduct("you-are-fucked", "Cards", "Happy Birthday", store.ProductDescription("Blah blah blah!"))
+			prod = store.NewProduct("you-are-fucked", []string{"Cards", "Happy Birthday"}, store.ProductDescription("Blah blah blah!"))
 		})
 
 		Describe("Delete", func() {
@@ -172,14 +172,15 @@ var _ = Describe("products", func() {
 						nil,
 						nil,
 						nil,
+						nil,
 					}
 
 				})
 
 				It("succeeds", func() {
-					p2 := store.NewProduct(prod.Title, prod.Cat, "Anniversary", store.ProductDescription("Blah blah blah!"))
+					p2 := store.NewProduct(prod.Title, []string{"Cards", "Anniversary"}, store.ProductDescription("Blah blah blah!"))
 					Expect(prod.Update(p2)).To(BeNil())
-					Expect(db.Rows).To(HaveLen(4))
+					Expect(db.Rows).To(HaveLen(6))
 
 					r := db.Rows[0]
 					Expect(r.Buckets).To(HaveLen(3))
@@ -202,6 +203,18 @@ var _ = Describe("products", func() {
 					Expect(string(r.Buckets[2])).To(Equal("Anniversary"))
 					Expect(string(r.Key)).To(Equal("you-are-fucked"))
 					Expect(string(r.Val)).To(MatchJSON(`{"description":"blah","id":"33"}`))
+
+					r = db.Rows[3]
+					Expect(string(r.Buckets[2])).To(Equal("Anniversary"))
+					Expect(string(r.Key)).To(Equal("you-are-fucked"))
+					Expect(string(r.Val)).To(MatchJSON(`{"description":"Blah blah blah!","id":"33"}`))
+
+					r = db.Rows[4]
+					Expect(r.Buckets).To(Equal([][]byte{[]byte("revisions"), []byte("products"), []byte("Cards/Anniversary/you-are-fucked")}))
+
+					//the history is moved from the old category
+					r = db.Rows[5]
+					Expect(r.Buckets).To(Equal([][]byte{[]byte("revisions"), []byte("products"), []byte("Cards/Happy Birthday/you-are-fucked")}))
 				})
 			})
 		})
@@ -223,7 +236,7 @@ var _ = Describe("products", func() {
 
 				It("succeeds", func() {
 					Expect(prod.Add(f)).To(BeNil())
-					Expect(db.Rows).To(HaveLen(4))
+					Expect(db.Rows).To(HaveLen(5))
 
 					//query
 					r := db.Rows[0]
@@ -254,6 +267,9 @@ var _ = Describe("products", func() {
 					Expect(string(r.Buckets[1])).To(Equal("Cards"))
 					Expect(string(r.Buckets[2])).To(Equal("Happy Birthday"))
 					Expect(string(r.Val)).To(MatchJSON(`{"description":"Blah blah blah!","id":"1"}`))
+
+					r = db.Rows[4]
+					Expect(r.Buckets).To(Equal([][]byte{[]byte("revisions"), []byte("products"), []byte("Cards/Happy Birthday/you-are-fucked")}))
 				})
 			})
 
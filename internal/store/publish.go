@@ -0,0 +1,184 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Status says whether shoppers can see a product or blog.  Products and
+// blogs saved before there were statuses don't have one, and they are
+// published.
+type Status string
+
+const (
+	Draft     Status = "draft"
+	Scheduled Status = "scheduled"
+	Published Status = "published"
+	Archived  Status = "archived"
+)
+
+// Statuses are the statuses in the order the admin pages list them.
+var Statuses = []Status{Draft, Scheduled, Published, Archived}
+
+// ParseStatus reads the status from a form or the api.  Blank is
+// published.
+func ParseStatus(s string) (Status, error) {
+	if s == "" {
+		return Published, nil
+	}
+
+	for _, st := range Statuses {
+		if Status(s) == st {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("invalid status %q", s)
+}
+
+// ParsePublishAt reads the time a scheduled product or blog goes live,
+// as sent by a datetime-local input, in the server's time zone.  Blank
+// is nil.
+func ParsePublishAt(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02T15:04", s, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func isLive(s Status) bool {
+	return s == "" || s == Published
+}
+
+// Live is true if shoppers can see the product.
+func (p Product) Live() bool {
+	return isLive(p.Status)
+}
+
+// Live is true if readers can see the blog.
+func (b Blog) Live() bool {
+	return isLive(b.Status)
+}
+
+// ProductStatus sets the product's status and, for scheduled products,
+// when it will be published.
+func ProductStatus(s Status, at *time.Time) func(*Product) {
+	return func(p *Product) {
+		p.Status = s
+		p.PublishAt = at
+	}
+}
+
+// LiveProducts are the products in prods that shoppers can see.
+func LiveProducts(prods []Product) []Product {
+	out := make([]Product, 0, len(prods))
+	for _, p := range prods {
+		if p.Live() {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func due(s Status, at *time.Time, now time.Time) bool {
+	return s == Scheduled && at != nil && !at.After(now)
+}
+
+// scheduler is who the history says published a scheduled product or
+// blog.
+const scheduler = "scheduler"
+
+// PublishScheduled publishes the scheduled products and blogs whose time
+// has come and returns how many there were.  Each one is read again and
+// saved through Update, with only its status changed, so that an edit
+// made since the walk isn't lost and its history, tags and search index
+// are kept up to date.
+func PublishScheduled(now time.Time) (int, error) {
+	var products []Product
+	err := WalkCategories(nil, func(path []string, prods []Product) error {
+		for _, p := range prods {
+			if due(p.Status, p.PublishAt, now) {
+				products = append(products, Product{Title: p.Title, Path: path})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var blogs []string
+	err = db.GetAll(NewQuery(Buckets("blogs")), func(key, val []byte) error {
+		var b Blog
+		if err := json.Unmarshal(val, &b); err != nil {
+			return err
+		}
+
+		if due(b.Status, b.PublishAt, now) {
+			blogs = append(blogs, string(key))
+		}
+		return nil
+	})
+	if err != nil && err != ErrNotFound {
+		return 0, err
+	}
+
+	var n int
+	for i := range products {
+		p := &products[i]
+		if err := p.Fetch(); err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return n, err
+		}
+
+		if !due(p.Status, p.PublishAt, now) {
+			continue
+		}
+
+		p2 := *p
+		p2.Status = Published
+		p2.EditedBy = scheduler
+		if err := p.Update(&p2); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	for _, key := range blogs {
+		b, err := GetBlog(key)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return n, err
+		}
+
+		if !due(b.Status, b.PublishAt, now) {
+			continue
+		}
+
+		b2 := b
+		b2.Status = Published
+		b2.EditedBy = scheduler
+		if err := b.Update(b2, nil); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// statusOf is s with blank, which is how things saved before there were
+// statuses read, as published.
+func statusOf(s Status) Status {
+	if s == "" {
+		return Published
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package store_test
+
+import (
+	"time"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("publishing", func() {
+
+	var (
+		db  *mock.DB
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+		db = mock.NewDB(map[string][]mock.Result{
+			"products": []mock.Result{{Key: []byte("Cards")}},
+			"products Cards": []mock.Result{
+				{Key: []byte("Old"), Val: []byte(`{"description": "saved before statuses"}`)},
+				{Key: []byte("Draft"), Val: []byte(`{"status": "draft"}`)},
+				{Key: []byte("Due"), Val: []byte(`{"status": "scheduled", "publish_at": "2018-03-01T11:00:00Z"}`)},
+				{Key: []byte("Later"), Val: []byte(`{"status": "scheduled", "publish_at": "2018-03-02T11:00:00Z"}`)},
+			},
+			"blogs": []mock.Result{
+				{Key: []byte("2018-03-01:Spring"), Val: []byte(`{"title": "Spring", "date": "2018-03-01T00:00:00Z", "status": "scheduled", "publish_at": "2018-03-01T08:00:00Z"}`)},
+				{Key: []byte("2018-02-01:Winter"), Val: []byte(`{"title": "Winter", "status": "archived"}`)},
+			},
+		}, make([]error, 30))
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	It("only shows published products", func() {
+		prods, err := store.GetProducts([]string{"Cards"})
+		Expect(err).To(BeNil())
+
+		live := store.LiveProducts(prods)
+		Expect(live).To(HaveLen(1))
+		Expect(live[0].Title).To(Equal("Old"))
+	})
+
+	It("parses statuses", func() {
+		s, err := store.ParseStatus("")
+		Expect(err).To(BeNil())
+		Expect(s).To(Equal(store.Published))
+
+		_, err = store.ParseStatus("hidden")
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("publishes what is due", func() {
+		n, err := store.PublishScheduled(now)
+		Expect(err).To(BeNil())
+		Expect(n).To(Equal(2))
+
+		//the last row for each key is the one that was saved
+		saved := map[string]string{}
+		var revisions int
+		for _, r := range db.Rows {
+			saved[string(r.Key)] = string(r.Val)
+			if len(r.Buckets) > 0 && string(r.Buckets[0]) == "revisions" {
+				Expect(string(r.Val)).To(ContainSubstring(`"by":"scheduler"`))
+				revisions++
+			}
+		}
+
+		Expect(saved["Due"]).To(MatchJSON(`{"description": "", "id": "", "status": "published", "publish_at": "2018-03-01T11:00:00Z"}`))
+		Expect(saved["2018-03-01:Spring"]).To(ContainSubstring(`"status":"published"`))
+		Expect(saved).ToNot(HaveKey("Later"))
+		Expect(revisions).To(Equal(2))
+	})
+
+	It("leaves the blogs that aren't published out of the list", func() {
+		blogs, err := store.PublishedBlogs()
+		Expect(err).To(BeNil())
+		Expect(blogs).To(HaveLen(0))
+
+		blogs, err = store.Blogs()
+		Expect(err).To(BeNil())
+		Expect(blogs).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+)
+
+/*
+Every time something with a history is saved, a copy of it goes into its
+own bucket of revisions, keyed by when it was saved.  The revisions are
+numbered from 1, oldest first, when they are read.
+
+revisions
+   pages
+      faq
+         2018-03-02T10:04:05.000000000: page
+   products
+      Cards/Birthday/Happy Cake
+         2018-03-02T10:04:05.000000000: product revision
+   blogs
+      2018-03-01:Spring
+         2018-03-02T10:04:05.000000000: blog revision
+*/
+
+// revisionKeyFormat is fixed width so the keys sort in the order the
+// revisions were saved.
+const revisionKeyFormat = "2006-01-02T15:04:05.000000000"
+
+// Revision is when a version of a product or blog was saved and who by.
+type Revision struct {
+	N     int       `json:"-"`
+	Saved time.Time `json:"saved"`
+	By    string    `json:"by,omitempty"`
+}
+
+func newRevision(by string) Revision {
+	return Revision{Saved: time.Now(), By: by}
+}
+
+func revisionBuckets(kind, id string) []string {
+	return []string{"revisions", kind, id}
+}
+
+// revisionQuery is the row that keeps v as the newest revision of the
+// thing with the id.  It goes in the same Put as the thing itself.
+func revisionQuery(kind, id string, v interface{}) (Query, error) {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return Query{}, err
+	}
+
+	key := time.Now().UTC().Format(revisionKeyFormat)
+	return NewQuery(Buckets(revisionBuckets(kind, id)...), Key(key), Val(d)), nil
+}
+
+// eachRevision calls f with each revision of the thing with the id and
+// its number, oldest first.
+func eachRevision(kind, id string, f func(n int, val []byte) error) error {
+	var n int
+	err := db.GetAll(NewQuery(Buckets(revisionBuckets(kind, id)...)), func(_, val []byte) error {
+		n++
+		return f(n, val)
+	})
+
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// getRevision reads revision n of the thing with the id into v.
+func getRevision(kind, id string, n int, v interface{}) error {
+	var found bool
+	err := eachRevision(kind, id, func(i int, val []byte) error {
+		if i != n {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(val, v)
+	})
+
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+	return err
+}
+
+func countRevisions(kind, id string) (int, error) {
+	var n int
+	err := eachRevision(kind, id, func(int, []byte) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// moveRevisions keeps the history of the thing with it when its id
+// changes.  The rows are copied rather than the bucket renamed, because
+// something deleted from the new id may have left a history there.
+func moveRevisions(kind, old, id string) error {
+	var rows []Query
+	err := db.GetAll(NewQuery(Buckets(revisionBuckets(kind, old)...)), func(key, val []byte) error {
+		//bolt's values are only good until the transaction ends
+		v := append([]byte{}, val...)
+		rows = append(rows, NewQuery(Buckets(revisionBuckets(kind, id)...), Key(string(key)), Val(v)))
+		return nil
+	})
+
+	if err == ErrNotFound || (err == nil && len(rows) == 0) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := db.Put(rows); err != nil {
+		return err
+	}
+	return db.Delete([]Query{NewQuery(Buckets(revisionBuckets(kind, old)...))})
+}
+
+// deleteRevisions deletes the whole history of the thing with the id.
+func deleteRevisions(kind, id string) error {
+	if n, err := countRevisions(kind, id); err != nil || n == 0 {
+		return err
+	}
+	return db.Delete([]Query{NewQuery(Buckets(revisionBuckets(kind, id)...))})
+}
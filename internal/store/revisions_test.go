@@ -0,0 +1,126 @@
+package store_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("revisions", func() {
+
+	var (
+		db *mock.DB
+	)
+
+	BeforeEach(func() {
+		db = mock.NewDB(map[string][]mock.Result{
+			"products Cards Birthday": []mock.Result{
+				{Key: []byte("Happy Cake"), Val: []byte(`{"description": "", "status": "draft"}`)},
+			},
+			"revisions products Cards/Birthday/Happy Cake": []mock.Result{
+				{Key: []byte("2018-03-01T10:00:00.000000000"), Val: []byte(`{"saved": "2018-03-01T10:00:00Z", "by": "a@example.com", "path": ["Cards", "Birthday"], "product": {"description": "a cake", "weight": 20}}`)},
+				{Key: []byte("2018-03-02T10:00:00.000000000"), Val: []byte(`{"saved": "2018-03-02T10:00:00Z", "by": "b@example.com", "path": ["Cards", "Birthday"], "product": {"description": "", "status": "draft"}}`)},
+			},
+			"revisions blogs 2018-03-01:Spring": []mock.Result{
+				{Key: []byte("2018-03-01T10:00:00.000000000"), Val: []byte(`{"saved": "2018-03-01T10:00:00Z", "blog": {"title": "Spring", "date": "2018-03-01T00:00:00Z", "body": "first"}}`)},
+				{Key: []byte("2018-03-02T10:00:00.000000000"), Val: []byte(`{"saved": "2018-03-02T10:00:00Z", "blog": {"title": "Spring", "date": "2018-03-01T00:00:00Z", "body": "second"}}`)},
+			},
+		}, make([]error, 30))
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	It("lists a product's revisions newest first", func() {
+		revs, err := store.ProductRevisions([]string{"Cards", "Birthday"}, "Happy Cake")
+		Expect(err).To(BeNil())
+		Expect(revs).To(HaveLen(2))
+		Expect(revs[0].N).To(Equal(2))
+		Expect(revs[0].By).To(Equal("b@example.com"))
+		Expect(revs[1].N).To(Equal(1))
+		Expect(revs[1].Product.Description).To(Equal("a cake"))
+	})
+
+	It("keeps who saved a product", func() {
+		p := store.NewProduct("Happy Cake", []string{"Cards", "Birthday"})
+		Expect(p.Fetch()).To(BeNil())
+		p2 := store.NewProduct("Happy Cake", []string{"Cards", "Birthday"}, store.ProductDescription("a big cake"))
+		p2.EditedBy = "c@example.com"
+		Expect(p.Update(p2)).To(BeNil())
+
+		r := db.Rows[len(db.Rows)-1]
+		Expect(r.Buckets).To(Equal([][]byte{[]byte("revisions"), []byte("products"), []byte("Cards/Birthday/Happy Cake")}))
+		var rev store.ProductRevision
+		Expect(json.Unmarshal(r.Val, &rev)).To(BeNil())
+		Expect(rev.By).To(Equal("c@example.com"))
+		Expect(rev.Product.Description).To(Equal("a big cake"))
+		Expect(rev.Path).To(Equal([]string{"Cards", "Birthday"}))
+	})
+
+	It("saves what Merge says it will", func() {
+		p := store.NewProduct("Happy Cake", []string{"Cards", "Birthday"})
+		Expect(p.Fetch()).To(BeNil())
+		p2, err := p.Restoring(1, "admin@example.com")
+		Expect(err).To(BeNil())
+
+		merged := p.Merge(p2)
+		Expect(p.Update(p2)).To(BeNil())
+		Expect(*p).To(Equal(merged))
+		Expect(p.EditedBy).To(Equal("admin@example.com"))
+	})
+
+	It("restores a product's description", func() {
+		p := store.NewProduct("Happy Cake", []string{"Cards", "Birthday"})
+		Expect(p.Fetch()).To(BeNil())
+		Expect(p.Restore(1, "admin@example.com")).To(BeNil())
+		Expect(p.Description).To(Equal("a cake"))
+		Expect(p.Weight).To(Equal(20))
+		Expect(p.Status).To(Equal(store.Published))
+		Expect(p.Path).To(Equal([]string{"Cards", "Birthday"}))
+	})
+
+	It("doesn't restore a product revision that doesn't exist", func() {
+		p := store.NewProduct("Happy Cake", []string{"Cards", "Birthday"})
+		Expect(p.Restore(3, "")).To(Equal(store.ErrNotFound))
+	})
+
+	It("lists a blog's revisions newest first", func() {
+		revs, err := store.BlogRevisions("2018-03-01:Spring")
+		Expect(err).To(BeNil())
+		Expect(revs).To(HaveLen(2))
+		Expect(revs[0].N).To(Equal(2))
+		Expect(revs[0].Blog.Body).To(Equal("second"))
+	})
+
+	It("restores a blog's body", func() {
+		b := store.Blog{Title: "Spring", Date: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC), Body: "oops"}
+		Expect(b.Restore(1, "admin@example.com")).To(BeNil())
+		Expect(b.Body).To(Equal("first"))
+
+		r := db.Rows[len(db.Rows)-1]
+		Expect(r.Buckets).To(Equal([][]byte{[]byte("revisions"), []byte("blogs"), []byte("2018-03-01:Spring")}))
+		var rev store.BlogRevision
+		Expect(json.Unmarshal(r.Val, &rev)).To(BeNil())
+		Expect(rev.By).To(Equal("admin@example.com"))
+		Expect(rev.Blog.Body).To(Equal("first"))
+	})
+
+	It("saves the blog Merge says it will", func() {
+		b := store.Blog{Title: "Spring", Date: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC), Body: "oops"}
+		b2, err := b.Restoring(1, "admin@example.com")
+		Expect(err).To(BeNil())
+
+		merged := b.Merge(b2)
+		Expect(b.Update(b2, nil)).To(BeNil())
+		merged.HTML = b.HTML
+		Expect(b).To(Equal(merged))
+	})
+
+	It("writes a revision out as lines", func() {
+		r := store.BlogRevision{Blog: store.Blog{Title: "Spring", Date: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC), Body: "one\ntwo", Tags: []string{"cake", "cards"}}}
+		Expect(r.Text()).To(Equal("Title: Spring\nDate: 2018-03-01\nStatus: published\nTags: cake, cards\n\none\ntwo"))
+	})
+})
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Capability is one thing a role lets someone do in the admin pages.
+type Capability string
+
+const (
+	CatalogWrite       Capability = "catalog.write"
+	PricingWrite       Capability = "pricing.write"
+	BlogWrite          Capability = "blog.write"
+	PagesWrite         Capability = "pages.write"
+	WholesalersApprove Capability = "wholesalers.approve"
+	ShippingWrite      Capability = "shipping.write"
+	TaxesWrite         Capability = "taxes.write"
+	UsersManage        Capability = "users.manage"
+	DBBackup           Capability = "db.backup"
+	AuditRead          Capability = "audit.read"
+)
+
+// Capabilities lists every capability in the order the admin page shows
+// them.
+var Capabilities = []Capability{
+	CatalogWrite,
+	PricingWrite,
+	BlogWrite,
+	PagesWrite,
+	WholesalersApprove,
+	ShippingWrite,
+	TaxesWrite,
+	UsersManage,
+	DBBackup,
+	AuditRead,
+}
+
+// Role is a named set of capabilities that can be given to users that
+// need some of the admin pages but shouldn't be full admins.
+type Role struct {
+	Name         string       `json:"name"`
+	Capabilities []Capability `json:"capabilities"`
+}
+
+// Has is true if the role includes c.
+func (r Role) Has(c Capability) bool {
+	for _, x := range r.Capabilities {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Role) Save() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("a role needs a name")
+	}
+
+	for _, c := range r.Capabilities {
+		if !validCapability(c) {
+			return fmt.Errorf("unknown capability %s", c)
+		}
+	}
+
+	d, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return db.Put([]Query{NewQuery(Key(r.Name), Val(d), Buckets("roles"))})
+}
+
+func validCapability(c Capability) bool {
+	for _, x := range Capabilities {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+func GetRole(name string) (Role, error) {
+	var r Role
+	err := db.Get([]Query{NewQuery(Key(name), Buckets("roles"))}, func(_, val []byte) error {
+		return json.Unmarshal(val, &r)
+	})
+	return r, err
+}
+
+func GetRoles() ([]Role, error) {
+	var roles []Role
+	err := db.GetAll(NewQuery(Buckets("roles")), func(_, val []byte) error {
+		var r Role
+		if err := json.Unmarshal(val, &r); err != nil {
+			return err
+		}
+		roles = append(roles, r)
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return roles, err
+}
+
+// DeleteRole removes a role.  Users that had it just lose its
+// capabilities.
+func DeleteRole(name string) error {
+	return db.Delete([]Query{NewQuery(Key(name), Buckets("roles"))})
+}
+
+// HasRole is true if the user has the role with the name.
+func (u *User) HasRole(name string) bool {
+	for _, r := range u.Roles {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Can is true if one of the user's roles includes c.  It doesn't know
+// about the Admin permission, the handlers ACLs take care of that.
+func (u *User) Can(c Capability) bool {
+	for _, name := range u.Roles {
+		r, err := GetRole(name)
+		if err != nil {
+			continue
+		}
+
+		if r.Has(c) {
+			return true
+		}
+	}
+	return false
+}
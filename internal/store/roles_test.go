@@ -0,0 +1,41 @@
+package store_test
+
+import (
+	"encoding/json"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("roles", func() {
+
+	var (
+		u store.User
+	)
+
+	BeforeEach(func() {
+		d, err := json.Marshal(store.Role{Name: "blogger", Capabilities: []store.Capability{store.BlogWrite}})
+		Expect(err).To(BeNil())
+		db := mock.NewDB(map[string][]mock.Result{
+			"roles": []mock.Result{{Key: []byte("blogger"), Val: d}},
+		}, []error{nil, nil})
+		store.Init(config.Config{}, store.SetDB(db))
+		u = store.User{Email: "craig@example.com", Roles: []string{"blogger"}}
+	})
+
+	It("can do what its roles allow", func() {
+		Expect(u.Can(store.BlogWrite)).To(BeTrue())
+	})
+
+	It("can't do anything else", func() {
+		Expect(u.Can(store.CatalogWrite)).To(BeFalse())
+	})
+
+	It("won't save a made up capability", func() {
+		r := store.Role{Name: "hacker", Capabilities: []store.Capability{"everything"}}
+		Expect(r.Save()).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,365 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	titleWeight  = 3.0
+	prefixWeight = 0.5
+	snippetLen   = 160
+)
+
+var (
+	index     *searchIndex
+	indexLock sync.Mutex
+
+	stopWords = map[string]bool{
+		"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+		"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+		"it": true, "of": true, "on": true, "or": true, "the": true, "this": true,
+		"to": true, "with": true,
+	}
+)
+
+// SearchResult is a product or blog that matched a search.
+type SearchResult struct {
+	Kind    string  `json:"kind"`
+	Title   string  `json:"title"`
+	Link    string  `json:"link"`
+	Snippet string  `json:"snippet,omitempty"`
+	Score   float64 `json:"-"`
+}
+
+// searchIndex is an inverted index of the products and blogs.  It lives
+// in memory, is built the first time someone searches, and is kept up to
+// date as products and blogs are saved and deleted.
+type searchIndex struct {
+	docs     map[string]SearchResult
+	postings map[string]map[string]float64 //term -> doc id -> weight
+	terms    []string                      //sorted, for prefix matching
+}
+
+// Search returns up to limit products and blogs that match every word in
+// q, best first.  The last word also matches as a prefix so results can
+// show up while someone types.
+func Search(q string, limit int) ([]SearchResult, error) {
+	words := tokenize(q)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	var last string
+	for _, w := range splitWords(q) {
+		if !stopWords[w] {
+			last = w
+		}
+	}
+
+	indexLock.Lock()
+	defer indexLock.Unlock()
+
+	if err := loadIndex(); err != nil {
+		return nil, err
+	}
+
+	var scores map[string]float64
+	for i, w := range words {
+		var prefixes []string
+		if i == len(words)-1 {
+			prefixes = []string{w, last}
+		}
+		s := index.score(w, prefixes)
+		if scores == nil {
+			scores = s
+			continue
+		}
+
+		for id := range scores {
+			if x, ok := s[id]; ok {
+				scores[id] += x
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		r := index.docs[id]
+		r.Score = score
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Title < results[j].Title
+		}
+		return results[i].Score > results[j].Score
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// ReindexSearch throws away the search index.  It is built again the
+// next time someone searches.
+func ReindexSearch() {
+	indexLock.Lock()
+	index = nil
+	indexLock.Unlock()
+}
+
+// score is tf-idf for term w, plus a smaller score for the terms that
+// start with one of the prefixes.
+func (s *searchIndex) score(w string, prefixes []string) map[string]float64 {
+	scores := map[string]float64{}
+	add := func(term string, weight float64) {
+		docs := s.postings[term]
+		idf := math.Log(1 + float64(len(s.docs))/float64(len(docs)))
+		for id, tf := range docs {
+			if x := tf * idf * weight; x > scores[id] {
+				scores[id] = x
+			}
+		}
+	}
+
+	add(w, 1)
+	for _, p := range prefixes {
+		for i := sort.SearchStrings(s.terms, p); i < len(s.terms) && strings.HasPrefix(s.terms[i], p); i++ {
+			if s.terms[i] != w {
+				add(s.terms[i], prefixWeight)
+			}
+		}
+	}
+	return scores
+}
+
+func (s *searchIndex) add(id string, r SearchResult, title, body string) {
+	s.remove(id)
+
+	weights := map[string]float64{}
+	for _, t := range tokenize(title) {
+		weights[t] += titleWeight
+	}
+	for _, t := range tokenize(body) {
+		weights[t]++
+	}
+
+	var added bool
+	for t, w := range weights {
+		docs, ok := s.postings[t]
+		if !ok {
+			docs = map[string]float64{}
+			s.postings[t] = docs
+			s.terms = append(s.terms, t)
+			added = true
+		}
+		docs[id] = 1 + math.Log(w)
+	}
+
+	if added {
+		sort.Strings(s.terms)
+	}
+	r.Snippet = snippet(body)
+	s.docs[id] = r
+}
+
+func (s *searchIndex) remove(id string) {
+	if _, ok := s.docs[id]; !ok {
+		return
+	}
+
+	delete(s.docs, id)
+	terms := s.terms[:0]
+	for _, t := range s.terms {
+		delete(s.postings[t], id)
+		if len(s.postings[t]) == 0 {
+			delete(s.postings, t)
+		} else {
+			terms = append(terms, t)
+		}
+	}
+	s.terms = terms
+}
+
+// loadIndex builds the index from every product and blog.  indexLock
+// must be held.
+func loadIndex() error {
+	if index != nil {
+		return nil
+	}
+
+	s := &searchIndex{
+		docs:     map[string]SearchResult{},
+		postings: map[string]map[string]float64{},
+	}
+
+	err := WalkCategories(nil, func(_ []string, products []Product) error {
+		for i := range products {
+			s.addProduct(&products[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	blogs, err := Blogs()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range blogs {
+		b, err := GetBlog(k.ID)
+		if err != nil {
+			return err
+		}
+		s.addBlog(&b)
+	}
+
+	index = s
+	return nil
+}
+
+func (s *searchIndex) addProduct(p *Product) {
+	if !p.Live() {
+		return
+	}
+
+	r := SearchResult{
+		Kind:  "product",
+		Title: p.Title,
+		Link:  p.Link(),
+	}
+	s.add(productDocID(p.Path, p.Title), r, p.Title, p.Description)
+}
+
+func (s *searchIndex) addBlog(b *Blog) {
+	if !b.Live() {
+		return
+	}
+
+	r := SearchResult{
+		Kind:  "blog",
+		Title: b.Title,
+		Link:  fmt.Sprintf("/blog/%s", b.Key()),
+	}
+	s.add("blog:"+b.Key(), r, b.Title, b.Body)
+}
+
+func productDocID(path []string, title string) string {
+	return "product:" + strings.Join(childPath(path, title), "/")
+}
+
+// The functions below keep the index up to date.  They do nothing if it
+// hasn't been built yet.
+
+func indexProduct(p *Product) {
+	indexLock.Lock()
+	defer indexLock.Unlock()
+	if index != nil {
+		index.addProduct(p)
+	}
+}
+
+func unindexProduct(path []string, title string) {
+	indexLock.Lock()
+	defer indexLock.Unlock()
+	if index != nil {
+		index.remove(productDocID(path, title))
+	}
+}
+
+func indexBlog(b *Blog) {
+	indexLock.Lock()
+	defer indexLock.Unlock()
+	if index != nil {
+		index.addBlog(b)
+	}
+}
+
+func unindexBlog(key string) {
+	indexLock.Lock()
+	defer indexLock.Unlock()
+	if index != nil {
+		index.remove("blog:" + key)
+	}
+}
+
+// tokenize splits s into lower case, stemmed words without the stop
+// words.
+func tokenize(s string) []string {
+	var out []string
+	for _, w := range splitWords(s) {
+		if !stopWords[w] {
+			out = append(out, stem(w))
+		}
+	}
+	return out
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// stem strips the common english suffixes so that, for example, bake,
+// bakes and baking all become bak.  It is a small part of the porter
+// stemmer, which is plenty for product names.
+func stem(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		w = w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "ss"):
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "us"):
+		w = w[:len(w)-1]
+	}
+
+	for _, suffix := range []string{"ingly", "edly", "ing", "ed", "ly"} {
+		if strings.HasSuffix(w, suffix) && hasVowel(w[:len(w)-len(suffix)]) && len(w)-len(suffix) >= 3 {
+			w = w[:len(w)-len(suffix)]
+			if n := len(w); n > 2 && w[n-1] == w[n-2] && !strings.ContainsRune("lsz", rune(w[n-1])) {
+				w = w[:n-1]
+			}
+			break
+		}
+	}
+
+	if len(w) > 3 && strings.HasSuffix(w, "e") {
+		w = w[:len(w)-1]
+	}
+
+	return w
+}
+
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
+
+func snippet(s string) string {
+	r := []rune(strings.Join(strings.Fields(s), " "))
+	if len(r) <= snippetLen {
+		return string(r)
+	}
+
+	s = string(r[:snippetLen])
+	if i := strings.LastIndex(s, " "); i > 0 {
+		s = s[:i]
+	}
+	return s + "..."
+}
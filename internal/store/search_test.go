@@ -0,0 +1,58 @@
+package store_test
+
+import (
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("search", func() {
+
+	BeforeEach(func() {
+		errs := make([]error, 20)
+		db := mock.NewDB(map[string][]mock.Result{
+			"products":                []mock.Result{{Key: []byte("Cards")}},
+			"products Cards":          []mock.Result{{Key: []byte("_price_")}, {Key: []byte("Birthday")}},
+			"products Cards Birthday": []mock.Result{{Key: []byte("Happy Cake"), Val: []byte(`{"description": "a birthday card with cakes on it"}`)}, {Key: []byte("Balloons"), Val: []byte(`{"description": "floating balloons for a birthday"}`)}},
+			"blogs":                   []mock.Result{{Key: []byte("2018-01-02:Baking"), Val: []byte(`{"title": "Baking", "body": "We were baking a cake for the new cards."}`)}},
+		}, errs)
+		store.Init(config.Config{}, store.SetDB(db))
+		store.ReindexSearch()
+	})
+
+	AfterEach(func() {
+		store.ReindexSearch()
+	})
+
+	It("ranks title matches first", func() {
+		results, err := store.Search("cake", 10)
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Title).To(Equal("Happy Cake"))
+		Expect(results[0].Link).To(Equal("/shop/Cards/Birthday/Happy Cake"))
+		Expect(results[1].Title).To(Equal("Baking"))
+		Expect(results[1].Kind).To(Equal("blog"))
+	})
+
+	It("stems", func() {
+		results, err := store.Search("bake", 10)
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Title).To(Equal("Baking"))
+	})
+
+	It("matches a prefix of the last word", func() {
+		results, err := store.Search("birthday ball", 10)
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Title).To(Equal("Balloons"))
+	})
+
+	It("needs every word to match", func() {
+		results, err := store.Search("balloons cake", 10)
+		Expect(err).To(BeNil())
+		Expect(results).To(BeEmpty())
+	})
+})
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// touchInterval keeps every request from writing to the db just to
+// move LastSeen forward.
+const touchInterval = time.Minute
+
+// Session is a logged in browser.  The auth cookie only holds the ID so
+// a session can be expired or revoked on the server.
+type Session struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Created   time.Time `json:"created"`
+	LastSeen  time.Time `json:"last_seen"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+func NewSession(email, ip, userAgent string) (Session, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Session{}, err
+	}
+
+	now := time.Now()
+	s := Session{
+		ID:        hex.EncodeToString(b),
+		Email:     email,
+		Created:   now,
+		LastSeen:  now,
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+
+	return s, s.save()
+}
+
+func (s Session) save() error {
+	d, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return db.Put([]Query{NewQuery(Key(s.ID), Val(d), Buckets("sessions"))})
+}
+
+// Expired is true once the session has been idle for longer than
+// STORE_SESSION_IDLE_TIMEOUT or is older than STORE_SESSION_MAX_AGE.
+func (s Session) Expired() bool {
+	now := time.Now()
+	return now.Sub(s.LastSeen) > cfg.SessionIdleTimeout || now.Sub(s.Created) > cfg.SessionMaxAge
+}
+
+// Expires is when the session will expire if it stays idle.
+func (s Session) Expires() time.Time {
+	idle := s.LastSeen.Add(cfg.SessionIdleTimeout)
+	max := s.Created.Add(cfg.SessionMaxAge)
+	if idle.Before(max) {
+		return idle
+	}
+	return max
+}
+
+// GetSession returns a live session and marks it as seen.  Expired
+// sessions are deleted and reported as not found.
+func GetSession(id string) (Session, error) {
+	var s Session
+	err := db.Get([]Query{NewQuery(Key(id), Buckets("sessions"))}, func(_, val []byte) error {
+		return json.Unmarshal(val, &s)
+	})
+	if err != nil {
+		return s, err
+	}
+
+	if s.Expired() {
+		if err := DeleteSession(id); err != nil {
+			return s, err
+		}
+		return s, ErrNotFound
+	}
+
+	if time.Since(s.LastSeen) > touchInterval {
+		s.LastSeen = time.Now()
+		err = s.save()
+	}
+
+	return s, err
+}
+
+func DeleteSession(id string) error {
+	return db.Delete([]Query{NewQuery(Key(id), Buckets("sessions"))})
+}
+
+// GetSessions returns the live sessions for email, or for everyone when
+// email is empty, most recently seen first.  Expired sessions it comes
+// across are deleted.
+func GetSessions(email string) ([]Session, error) {
+	var sessions []Session
+	var expired []Query
+	err := db.GetAll(NewQuery(Buckets("sessions")), func(key, val []byte) error {
+		var s Session
+		if err := json.Unmarshal(val, &s); err != nil {
+			return err
+		}
+
+		if s.Expired() {
+			expired = append(expired, NewQuery(Key(string(key)), Buckets("sessions")))
+		} else if email == "" || s.Email == email {
+			sessions = append(sessions, s)
+		}
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(expired) > 0 {
+		if err := db.Delete(expired); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+
+	return sessions, nil
+}
+
+// DeleteSessions logs email out everywhere.
+func DeleteSessions(email string) error {
+	sessions, err := GetSessions(email)
+	if err != nil || len(sessions) == 0 {
+		return err
+	}
+
+	q := make([]Query, len(sessions))
+	for i, s := range sessions {
+		q[i] = NewQuery(Key(s.ID), Buckets("sessions"))
+	}
+	return db.Delete(q)
+}
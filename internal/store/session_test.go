@@ -0,0 +1,75 @@
+package store_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("sessions", func() {
+
+	var (
+		db      *mock.DB
+		buckets map[string][]mock.Result
+		errs    []error
+		s       store.Session
+	)
+
+	BeforeEach(func() {
+		s = store.Session{
+			ID:       "abc",
+			Email:    "craig@example.com",
+			Created:  time.Now().Add(-2 * time.Hour),
+			LastSeen: time.Now(),
+		}
+		errs = []error{nil, nil}
+	})
+
+	JustBeforeEach(func() {
+		d, err := json.Marshal(s)
+		Expect(err).To(BeNil())
+		buckets = map[string][]mock.Result{
+			"sessions": []mock.Result{{Key: []byte(s.ID), Val: d}},
+		}
+		db = mock.NewDB(buckets, errs)
+		store.Init(config.Config{SessionIdleTimeout: time.Hour, SessionMaxAge: 24 * time.Hour}, store.SetDB(db))
+	})
+
+	Context("GetSession", func() {
+		It("returns a live session without writing to the db", func() {
+			x, err := store.GetSession("abc")
+			Expect(err).To(BeNil())
+			Expect(x.Email).To(Equal("craig@example.com"))
+			Expect(db.Rows).To(HaveLen(1))
+		})
+
+		Context("idle", func() {
+			BeforeEach(func() {
+				s.LastSeen = time.Now().Add(-90 * time.Minute)
+			})
+
+			It("deletes the session", func() {
+				_, err := store.GetSession("abc")
+				Expect(err).To(Equal(store.ErrNotFound))
+				Expect(db.Rows).To(HaveLen(2))
+				Expect(string(db.Rows[1].Key)).To(Equal("abc"))
+			})
+		})
+
+		Context("too old", func() {
+			BeforeEach(func() {
+				s.Created = time.Now().Add(-25 * time.Hour)
+			})
+
+			It("deletes the session even though it was just used", func() {
+				_, err := store.GetSession("abc")
+				Expect(err).To(Equal(store.ErrNotFound))
+			})
+		})
+	})
+})
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cswank/store/internal/money"
+)
+
+var (
+	// ErrNoShipping indicates there is no shipping zone or rate that covers an address and weight
+	ErrNoShipping = errors.New("no shipping rate for address")
+)
+
+// ShippingRate is one row of a zone's rate table.  Weights are in grams and
+// a MaxWeight of 0 means there is no upper limit.
+type ShippingRate struct {
+	MaxWeight int         `json:"max_weight"`
+	Price     money.Money `json:"price"`
+}
+
+// ShippingZone is a set of countries (and optionally states) that share a
+// rate table.  A zone with no countries matches every address, and a zone
+// with a single rate that has no MaxWeight is a flat rate.
+type ShippingZone struct {
+	Name      string         `json:"name"`
+	Countries []string       `json:"countries,omitempty"`
+	States    []string       `json:"states,omitempty"`
+	Rates     []ShippingRate `json:"rates"`
+}
+
+func GetShippingZones() ([]ShippingZone, error) {
+	var zones []ShippingZone
+	err := db.GetAll(NewQuery(Buckets("shipping")), func(key, val []byte) error {
+		var z ShippingZone
+		if err := json.Unmarshal(val, &z); err != nil {
+			return err
+		}
+		zones = append(zones, z)
+		return nil
+	})
+
+	if err == ErrNotFound {
+		err = nil
+	}
+
+	return zones, err
+}
+
+func (z *ShippingZone) Save() error {
+	if z.Name == "" {
+		return errors.New("shipping zone name must be set")
+	}
+
+	for i, r := range z.Rates {
+		if r.Price.Amount < 0 {
+			return fmt.Errorf("invalid shipping price %s", r.Price)
+		}
+		if r.Price.Currency == "" {
+			z.Rates[i].Price.Currency = strings.ToUpper(cfg.Currency)
+		}
+	}
+
+	d, err := json.Marshal(z)
+	if err != nil {
+		return err
+	}
+
+	return db.Put([]Query{NewQuery(Key(z.Name), Val(d), Buckets("shipping"))})
+}
+
+func DeleteShippingZone(name string) error {
+	return db.Delete([]Query{NewQuery(Key(name), Buckets("shipping"))})
+}
+
+// Rate returns the price of shipping a package of the given weight (grams)
+// within the zone.
+func (z ShippingZone) Rate(weight int) (money.Money, error) {
+	rates := make([]ShippingRate, len(z.Rates))
+	copy(rates, z.Rates)
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].MaxWeight == 0 {
+			return false
+		}
+		return rates[j].MaxWeight == 0 || rates[i].MaxWeight < rates[j].MaxWeight
+	})
+
+	for _, r := range rates {
+		if r.MaxWeight == 0 || weight <= r.MaxWeight {
+			return r.Price, nil
+		}
+	}
+	return money.Money{}, ErrNoShipping
+}
+
+// matches scores how well the zone fits the address: 2 for a state match,
+// 1 for a country match, 0 for a catch-all zone and -1 for no match.
+func (z ShippingZone) matches(addr Address) int {
+	if len(z.Countries) == 0 {
+		return 0
+	}
+
+	if !contains(z.Countries, addr.Country) {
+		return -1
+	}
+
+	if len(z.States) == 0 {
+		return 1
+	}
+
+	if contains(z.States, addr.State) {
+		return 2
+	}
+	return -1
+}
+
+// GetShippingZone finds the most specific zone that covers the address.
+func GetShippingZone(addr Address) (ShippingZone, error) {
+	zones, err := GetShippingZones()
+	if err != nil {
+		return ShippingZone{}, err
+	}
+
+	var zone ShippingZone
+	best := -1
+	for _, z := range zones {
+		if s := z.matches(addr); s > best {
+			best = s
+			zone = z
+		}
+	}
+
+	if best == -1 {
+		return zone, ErrNoShipping
+	}
+
+	return zone, nil
+}
+
+// ShippingCost is the price of shipping weight grams to addr.
+func ShippingCost(addr Address, weight int) (money.Money, error) {
+	z, err := GetShippingZone(addr)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return z.Rate(weight)
+}
+
+func contains(vals []string, s string) bool {
+	s = strings.TrimSpace(s)
+	for _, v := range vals {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package store_test
+
+import (
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/money"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("shipping", func() {
+
+	var (
+		db      *mock.DB
+		buckets map[string][]mock.Result
+		errs    []error
+	)
+
+	BeforeEach(func() {
+		buckets = map[string][]mock.Result{
+			"shipping": []mock.Result{
+				{Key: []byte("Colorado"), Val: []byte(`{"name": "Colorado", "countries": ["US"], "states": ["CO"], "rates": [{"max_weight": 0, "price": {"amount": 300, "currency": "USD"}}]}`)},
+				{Key: []byte("Domestic"), Val: []byte(`{"name": "Domestic", "countries": ["US"], "rates": [{"max_weight": 0, "price": {"amount": 1200, "currency": "USD"}}, {"max_weight": 100, "price": {"amount": 400, "currency": "USD"}}, {"max_weight": 500, "price": {"amount": 800, "currency": "USD"}}]}`)},
+				{Key: []byte("World"), Val: []byte(`{"name": "World", "rates": [{"max_weight": 1000, "price": "20.00"}]}`)},
+			},
+		}
+		errs = []error{nil}
+	})
+
+	JustBeforeEach(func() {
+		db = mock.NewDB(buckets, errs)
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	Context("ShippingCost", func() {
+
+		It("uses the state zone", func() {
+			p, err := store.ShippingCost(store.Address{Country: "us", State: "CO"}, 800)
+			Expect(err).To(BeNil())
+			Expect(p).To(Equal(money.New(300, "USD")))
+		})
+
+		It("uses the lightest matching rate", func() {
+			p, err := store.ShippingCost(store.Address{Country: "US", State: "WY"}, 200)
+			Expect(err).To(BeNil())
+			Expect(p).To(Equal(money.New(800, "USD")))
+		})
+
+		It("falls through to the unlimited rate", func() {
+			p, err := store.ShippingCost(store.Address{Country: "US", State: "WY"}, 2000)
+			Expect(err).To(BeNil())
+			Expect(p).To(Equal(money.New(1200, "USD")))
+		})
+
+		It("uses the catch-all zone", func() {
+			p, err := store.ShippingCost(store.Address{Country: "FR"}, 200)
+			Expect(err).To(BeNil())
+			Expect(p.String()).To(Equal("20.00"))
+		})
+
+		It("bombs out when the package is too heavy", func() {
+			_, err := store.ShippingCost(store.Address{Country: "FR"}, 2000)
+			Expect(err).To(Equal(store.ErrNoShipping))
+		})
+	})
+})
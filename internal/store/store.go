@@ -58,6 +58,7 @@ type storer interface {
 	AddBucket(Query) error
 	RenameBucket(Query, Query) error
 	GetBackup(w http.ResponseWriter) error
+	PutNext(Query, func(n uint64) (Query, error)) error
 }
 
 var (
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// taggedProduct is what the tags bucket keeps for each product with a
+// tag.  The key is the product's path and title, because titles are only
+// unique in a category.
+type taggedProduct struct {
+	Path  []string `json:"path"`
+	Title string   `json:"title"`
+}
+
+func ProductTags(tags []string) func(*Product) {
+	return func(p *Product) {
+		p.Tags = tags
+	}
+}
+
+func ProductSoldOut(soldOut bool) func(*Product) {
+	return func(p *Product) {
+		p.SoldOut = soldOut
+	}
+}
+
+// ParseTags turns a comma separated list into tags, which are lower case
+// and sorted.
+func ParseTags(s string) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		if t == "" || strings.Contains(t, "/") || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (p *Product) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tagQueries adds the product to the tags bucket for each of its tags,
+// unless shoppers can't see it.
+func (p *Product) tagQueries() []Query {
+	if !p.Live() {
+		return nil
+	}
+
+	d, _ := json.Marshal(taggedProduct{Path: p.Path, Title: p.Title})
+	q := make([]Query, len(p.Tags))
+	for i, t := range p.Tags {
+		q[i] = NewQuery(Key(productRevisionID(p.Path, p.Title)), Val(d), Buckets("tags", t))
+	}
+	return q
+}
+
+func untagQueries(path []string, title string, tags []string) []Query {
+	q := make([]Query, len(tags))
+	for i, t := range tags {
+		q[i] = NewQuery(Key(productRevisionID(path, title)), Buckets("tags", t))
+	}
+	return q
+}
+
+// removedTags are the tags in old that aren't in tags.
+func removedTags(old, tags []string) []string {
+	var out []string
+	for _, t := range old {
+		p := Product{Tags: tags}
+		if !p.HasTag(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// GetTags returns every tag that at least one product has.
+func GetTags() ([]string, error) {
+	var names []string
+	err := db.GetAll(NewQuery(Buckets("tags")), func(key, _ []byte) error {
+		names = append(names, string(key))
+		return nil
+	})
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, t := range names {
+		var n int
+		if err := db.GetAll(NewQuery(Buckets("tags", t)), func(_, _ []byte) error {
+			n++
+			return nil
+		}); err != nil && err != ErrNotFound {
+			return nil, err
+		}
+
+		if n > 0 {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
+// GetTaggedProducts returns the products with the tag, from every
+// category.
+func GetTaggedProducts(tag string) ([]Product, error) {
+	var products []Product
+	err := db.GetAll(NewQuery(Buckets("tags", tag)), func(key, val []byte) error {
+		var tp taggedProduct
+		if err := json.Unmarshal(val, &tp); err != nil {
+			return err
+		}
+		title := tp.Title
+		if title == "" {
+			//rows from before the key had the path
+			title = string(key)
+		}
+		products = append(products, Product{Title: title, Path: tp.Path, Weight: cfg.DefaultWeight})
+		return nil
+	})
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for i := range products {
+		if err := products[i].Fetch(); err != nil {
+			return nil, err
+		}
+	}
+	return products, nil
+}
+
+// RebuildTags makes the tags bucket again from the products.  Renaming
+// or deleting a category moves products without going
+// through Product, so it is called after each of those.
+func RebuildTags() error {
+	var tags []string
+	err := db.GetAll(NewQuery(Buckets("tags")), func(key, _ []byte) error {
+		tags = append(tags, string(key))
+		return nil
+	})
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	var q []Query
+	for _, t := range tags {
+		q = append(q, NewQuery(Buckets("tags", t)))
+	}
+	if len(q) > 0 {
+		if err := db.Delete(q); err != nil {
+			return err
+		}
+	}
+
+	q = nil
+	err = WalkCategories(nil, func(_ []string, products []Product) error {
+		for _, p := range products {
+			q = append(q, p.tagQueries()...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(q) == 0 {
+		return nil
+	}
+	return db.Put(q)
+}
@@ -0,0 +1,74 @@
+package store_test
+
+import (
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tags", func() {
+
+	It("parses a comma separated list", func() {
+		Expect(store.ParseTags(" Red,blue , red,,Big  Cards, a/b")).To(Equal([]string{"big cards", "blue", "red"}))
+	})
+
+	It("finds tagged products in any category", func() {
+		db := mock.NewDB(map[string][]mock.Result{
+			"tags red":                []mock.Result{{Key: []byte("Happy Cake"), Val: []byte(`{"path": ["Cards", "Birthday"]}`)}},
+			"products Cards Birthday": []mock.Result{{Key: []byte("Happy Cake"), Val: []byte(`{"description": "cake", "tags": ["red"]}`)}},
+		}, []error{nil, nil})
+		store.Init(config.Config{}, store.SetDB(db))
+
+		products, err := store.GetTaggedProducts("red")
+		Expect(err).To(BeNil())
+		Expect(products).To(HaveLen(1))
+		Expect(products[0].Path).To(Equal([]string{"Cards", "Birthday"}))
+		Expect(products[0].Description).To(Equal("cake"))
+		Expect(products[0].HasTag("red")).To(BeTrue())
+	})
+
+	It("keeps products with the same title in different categories apart", func() {
+		db := mock.NewDB(map[string][]mock.Result{
+			"tags red": []mock.Result{
+				{Key: []byte("Cards/Birthday/Cake"), Val: []byte(`{"path": ["Cards", "Birthday"], "title": "Cake"}`)},
+				{Key: []byte("Cards/Wedding/Cake"), Val: []byte(`{"path": ["Cards", "Wedding"], "title": "Cake"}`)},
+			},
+			"products Cards Birthday": []mock.Result{{Key: []byte("Cake"), Val: []byte(`{"description": "birthday", "tags": ["red"]}`)}},
+			"products Cards Wedding":  []mock.Result{{Key: []byte("Cake"), Val: []byte(`{"description": "wedding", "tags": ["red"]}`)}},
+		}, []error{nil, nil, nil})
+		store.Init(config.Config{}, store.SetDB(db))
+
+		products, err := store.GetTaggedProducts("red")
+		Expect(err).To(BeNil())
+		Expect(products).To(HaveLen(2))
+		Expect(products[0].Title).To(Equal("Cake"))
+		Expect(products[0].Description).To(Equal("birthday"))
+		Expect(products[1].Title).To(Equal("Cake"))
+		Expect(products[1].Description).To(Equal("wedding"))
+	})
+
+	It("moves the tag rows with the product", func() {
+		db := mock.NewDB(map[string][]mock.Result{
+			"products Cards Birthday": []mock.Result{{Key: []byte("Cake"), Val: []byte(`{"tags": ["red"]}`)}},
+		}, make([]error, 10))
+		store.Init(config.Config{}, store.SetDB(db))
+
+		p := store.NewProduct("Cake", []string{"Cards", "Birthday"}, store.ProductTags([]string{"red"}))
+		Expect(p.Update(store.NewProduct("Cake", []string{"Cards", "Wedding"}, store.ProductTags([]string{"red"})))).To(BeNil())
+
+		var put, deleted []string
+		for _, r := range db.Rows {
+			if len(r.Buckets) == 2 && string(r.Buckets[0]) == "tags" {
+				if r.Val == nil {
+					deleted = append(deleted, string(r.Key))
+				} else {
+					put = append(put, string(r.Key))
+				}
+			}
+		}
+		Expect(put).To(Equal([]string{"Cards/Wedding/Cake"}))
+		Expect(deleted).To(Equal([]string{"Cards/Birthday/Cake"}))
+	})
+})
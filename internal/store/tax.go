@@ -0,0 +1,108 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cswank/store/internal/money"
+)
+
+// TaxRate is the sales tax for a country, or for a state within a
+// country when State is set.  Rate is a percentage, e.g. "7.25".
+type TaxRate struct {
+	Country  string `json:"country" schema:"country"`
+	State    string `json:"state,omitempty" schema:"state"`
+	Rate     string `json:"rate" schema:"rate"`
+	Shipping bool   `json:"shipping,omitempty" schema:"shipping"`
+}
+
+// Jurisdiction is the key the rate is stored under.
+func (t TaxRate) Jurisdiction() string {
+	return jurisdiction(t.Country, t.State)
+}
+
+func jurisdiction(country, state string) string {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	state = strings.ToUpper(strings.TrimSpace(state))
+	if state == "" {
+		return country
+	}
+	return fmt.Sprintf("%s:%s", country, state)
+}
+
+func GetTaxRates() ([]TaxRate, error) {
+	var rates []TaxRate
+	err := db.GetAll(NewQuery(Buckets("taxes")), func(key, val []byte) error {
+		var t TaxRate
+		if err := json.Unmarshal(val, &t); err != nil {
+			return err
+		}
+		rates = append(rates, t)
+		return nil
+	})
+
+	if err == ErrNotFound {
+		err = nil
+	}
+
+	return rates, err
+}
+
+func (t *TaxRate) Save() error {
+	if strings.TrimSpace(t.Country) == "" {
+		return errors.New("tax rate country must be set")
+	}
+
+	if _, err := money.ParseRate(t.Rate); err != nil {
+		return fmt.Errorf("invalid tax rate %q", t.Rate)
+	}
+
+	d, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	return db.Put([]Query{NewQuery(Key(t.Jurisdiction()), Val(d), Buckets("taxes"))})
+}
+
+func DeleteTaxRate(jurisdiction string) error {
+	return db.Delete([]Query{NewQuery(Key(jurisdiction), Buckets("taxes"))})
+}
+
+// GetTaxRate finds the rate for the address's state, falling back to
+// its country.  An address with no rate at all is taxed at zero.
+func GetTaxRate(addr Address) (TaxRate, error) {
+	var t TaxRate
+	keys := []string{
+		jurisdiction(addr.Country, addr.State),
+		jurisdiction(addr.Country, ""),
+	}
+
+	for _, k := range keys {
+		err := db.Get([]Query{NewQuery(Key(k), Buckets("taxes"))}, func(_, val []byte) error {
+			return json.Unmarshal(val, &t)
+		})
+		if err == nil {
+			return t, nil
+		} else if err != ErrNotFound {
+			return t, err
+		}
+	}
+
+	return TaxRate{Country: addr.Country, State: addr.State, Rate: "0"}, nil
+}
+
+// Tax is the tax owed on a sale with the given subtotal and shipping.
+func (t TaxRate) Tax(subtotal, shipping money.Money) (money.Money, error) {
+	base := subtotal
+	if t.Shipping {
+		var err error
+		if base, err = base.Add(shipping); err != nil {
+			return money.Money{}, err
+		}
+	}
+
+	return base.Percent(t.Rate)
+}
@@ -0,0 +1,138 @@
+package store_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/money"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tax", func() {
+
+	var (
+		db      *mock.DB
+		buckets map[string][]mock.Result
+		errs    []error
+	)
+
+	BeforeEach(func() {
+		buckets = map[string][]mock.Result{
+			"taxes": []mock.Result{
+				{Key: []byte("US"), Val: []byte(`{"country": "US", "rate": "0"}`)},
+				{Key: []byte("US:CO"), Val: []byte(`{"country": "US", "state": "CO", "rate": "2.9", "shipping": true}`)},
+				{Key: []byte("FR"), Val: []byte(`{"country": "FR", "rate": "20"}`)},
+			},
+			"invoices": []mock.Result{
+				{Key: []byte("0000000001"), Val: []byte(`{"number": 1, "date": "2018-02-28T23:00:00Z", "jurisdiction": "US:CO", "subtotal": {"amount": 1000, "currency": "USD"}, "tax": {"amount": 29, "currency": "USD"}}`)},
+				{Key: []byte("0000000002"), Val: []byte(`{"number": 2, "date": "2018-03-01T00:00:00Z", "jurisdiction": "US:CO", "subtotal": {"amount": 2000, "currency": "USD"}, "tax": {"amount": 58, "currency": "USD"}}`)},
+				{Key: []byte("0000000003"), Val: []byte(`{"number": 3, "date": "2018-03-15T00:00:00Z", "jurisdiction": "US:CO", "subtotal": {"amount": 500, "currency": "USD"}, "resale_certificate": "123"}`)},
+				{Key: []byte("0000000004"), Val: []byte(`{"number": 4, "date": "2018-04-01T00:00:00Z", "jurisdiction": "FR", "subtotal": {"amount": 1000, "currency": "USD"}, "tax": {"amount": 200, "currency": "USD"}}`)},
+			},
+		}
+		errs = make([]error, 10)
+	})
+
+	JustBeforeEach(func() {
+		db = mock.NewDB(buckets, errs)
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	Context("GetTaxRate", func() {
+
+		It("uses the state's rate", func() {
+			t, err := store.GetTaxRate(store.Address{Country: "us", State: "co"})
+			Expect(err).To(BeNil())
+			Expect(t.Rate).To(Equal("2.9"))
+			Expect(t.Jurisdiction()).To(Equal("US:CO"))
+		})
+
+		Context("for a state without its own rate", func() {
+			BeforeEach(func() {
+				errs[0] = store.ErrNotFound
+			})
+
+			It("falls back to the country", func() {
+				t, err := store.GetTaxRate(store.Address{Country: "FR", State: "Paris"})
+				Expect(err).To(BeNil())
+				Expect(t.Rate).To(Equal("20"))
+			})
+		})
+
+		Context("for a country without a rate", func() {
+			BeforeEach(func() {
+				errs[0] = store.ErrNotFound
+				errs[1] = store.ErrNotFound
+			})
+
+			It("is zero", func() {
+				t, err := store.GetTaxRate(store.Address{Country: "NZ"})
+				Expect(err).To(BeNil())
+				Expect(t).To(Equal(store.TaxRate{Country: "NZ", Rate: "0"}))
+			})
+		})
+	})
+
+	Context("Tax", func() {
+
+		It("rounds to the cent", func() {
+			t := store.TaxRate{Country: "US", State: "CO", Rate: "2.9"}
+			tax, err := t.Tax(money.New(1050, "USD"), money.New(500, "USD"))
+			Expect(err).To(BeNil())
+			Expect(tax).To(Equal(money.New(30, "USD")))
+		})
+
+		It("taxes shipping where the rate says to", func() {
+			t := store.TaxRate{Country: "US", State: "CO", Rate: "2.9", Shipping: true}
+			tax, err := t.Tax(money.New(1050, "USD"), money.New(500, "USD"))
+			Expect(err).To(BeNil())
+			Expect(tax).To(Equal(money.New(45, "USD")))
+		})
+
+		It("won't add shipping in another currency", func() {
+			t := store.TaxRate{Country: "US", Rate: "5", Shipping: true}
+			_, err := t.Tax(money.New(1000, "USD"), money.New(500, "EUR"))
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("invoices", func() {
+
+		It("only gets the invoices from the start of the range up to its end", func() {
+			invoices, err := store.GetInvoices(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC))
+			Expect(err).To(BeNil())
+			Expect(invoices).To(HaveLen(2))
+			Expect(invoices[0].Number).To(Equal(2))
+			Expect(invoices[1].Number).To(Equal(3))
+		})
+
+		It("adds up the tax report", func() {
+			lines, err := store.TaxReport(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+			Expect(err).To(BeNil())
+			Expect(lines).To(Equal([]store.TaxLine{
+				{Jurisdiction: "FR", Invoices: 1, Sales: money.New(1000, "USD"), Tax: money.New(200, "USD")},
+				{Jurisdiction: "US:CO", Invoices: 3, Sales: money.New(3000, "USD"), ExemptSales: money.New(500, "USD"), Tax: money.New(87, "USD")},
+			}))
+		})
+
+		It("numbers a new invoice after the last one", func() {
+			i := store.Invoice{Jurisdiction: "FR"}
+			Expect(i.Save()).To(BeNil())
+			Expect(i.Number).To(Equal(5))
+
+			i2 := store.Invoice{Jurisdiction: "FR"}
+			Expect(i2.Save()).To(BeNil())
+			Expect(i2.Number).To(Equal(6))
+
+			Expect(db.Rows).To(HaveLen(2))
+			Expect(string(db.Rows[1].Key)).To(Equal("0000000006"))
+			var saved store.Invoice
+			Expect(json.Unmarshal(db.Rows[1].Val, &saved)).To(BeNil())
+			Expect(saved.Number).To(Equal(6))
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cswank/store/internal/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RecoveryCodeCount is how many recovery codes a user gets at a time.
+const RecoveryCodeCount = 10
+
+var ErrInvalidCode = errors.New("invalid code")
+
+// TwoFactor is true once the user has confirmed an authenticator app.
+func (u *User) TwoFactor() bool {
+	return u.TOTPSecret != ""
+}
+
+// StartTwoFactor makes the secret an authenticator app is set up with and
+// returns the URI for its QR code.  The secret isn't used to log in until
+// EnableTwoFactor confirms the app is generating the right codes.
+func (u *User) StartTwoFactor() (string, error) {
+	if u.TOTPPending == "" {
+		s, err := totp.NewSecret()
+		if err != nil {
+			return "", err
+		}
+
+		u.TOTPPending = s
+		if err := u.Save(); err != nil {
+			return "", err
+		}
+	}
+
+	return totp.URI(cfg.Name, u.Email, u.TOTPPending), nil
+}
+
+// EnableTwoFactor checks code against the pending secret and, if it is
+// good, turns on two factor login.  The returned recovery codes are only
+// ever available in plain text here.
+func (u *User) EnableTwoFactor(code string) ([]string, error) {
+	step, ok := totp.Validate(u.TOTPPending, code, time.Now())
+	if u.TOTPPending == "" || !ok {
+		return nil, ErrInvalidCode
+	}
+
+	u.TOTPSecret = u.TOTPPending
+	u.TOTPPending = ""
+	u.TOTPStep = step
+	return u.NewRecoveryCodes()
+}
+
+// DisableTwoFactor goes back to logging in with just a password.
+func (u *User) DisableTwoFactor() error {
+	u.TOTPSecret = ""
+	u.TOTPPending = ""
+	u.TOTPStep = 0
+	u.RecoveryCodes = nil
+	return u.Save()
+}
+
+// NewRecoveryCodes replaces the user's recovery codes.  Only the bcrypt
+// hashes are stored.
+func (u *User) NewRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([][]byte, RecoveryCodeCount)
+	for i := range codes {
+		c, err := recoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		h, err := bcrypt.GenerateFromPassword([]byte(c), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+
+		codes[i] = c
+		hashes[i] = h
+	}
+
+	u.RecoveryCodes = hashes
+	return codes, u.Save()
+}
+
+// CheckTwoFactor accepts either a code from the authenticator app or one
+// of the recovery codes.  A recovery code can only be used once, and so
+// can an app code.
+func (u *User) CheckTwoFactor(code string) (bool, error) {
+	if step, ok := totp.Validate(u.TOTPSecret, code, time.Now()); ok {
+		if step <= u.TOTPStep {
+			return false, nil
+		}
+		u.TOTPStep = step
+		return true, u.Save()
+	}
+
+	for i, h := range u.RecoveryCodes {
+		if bcrypt.CompareHashAndPassword(h, []byte(code)) == nil {
+			u.RecoveryCodes = append(u.RecoveryCodes[:i], u.RecoveryCodes[i+1:]...)
+			return true, u.Save()
+		}
+	}
+
+	return false, nil
+}
+
+// recoveryCode looks like k7mq2-xp4rz.
+func recoveryCode() (string, error) {
+	alphabet := "abcdefghijkmnpqrstuvwxyz23456789"
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return fmt.Sprintf("%s-%s", b[:5], b[5:]), nil
+}
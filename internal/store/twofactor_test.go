@@ -0,0 +1,62 @@
+package store_test
+
+import (
+	"time"
+
+	"github.com/cswank/store/internal/config"
+	"github.com/cswank/store/internal/store"
+	"github.com/cswank/store/internal/store/mock"
+	"github.com/cswank/store/internal/totp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("two factor login", func() {
+
+	var (
+		u store.User
+	)
+
+	BeforeEach(func() {
+		secret, err := totp.NewSecret()
+		Expect(err).To(BeNil())
+		u = store.User{
+			Email:          "craig@example.com",
+			Permission:     store.Admin,
+			HashedPassword: []byte("not empty"),
+			TOTPSecret:     secret,
+		}
+		db := mock.NewDB(map[string][]mock.Result{}, []error{nil, nil, nil})
+		store.Init(config.Config{}, store.SetDB(db))
+	})
+
+	It("only accepts an app code once", func() {
+		c, err := totp.Code(u.TOTPSecret, time.Now())
+		Expect(err).To(BeNil())
+
+		ok, err := u.CheckTwoFactor(c)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeTrue())
+
+		ok, err = u.CheckTwoFactor(c)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeFalse())
+	})
+
+	It("only accepts a recovery code once", func() {
+		codes, err := u.NewRecoveryCodes()
+		Expect(err).To(BeNil())
+		Expect(codes).To(HaveLen(10))
+		Expect(u.RecoveryCodes).To(HaveLen(10))
+		Expect(string(u.RecoveryCodes[0])).ToNot(Equal(codes[0]))
+
+		ok, err := u.CheckTwoFactor(codes[3])
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeTrue())
+		Expect(u.RecoveryCodes).To(HaveLen(9))
+
+		ok, err = u.CheckTwoFactor(codes[3])
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeFalse())
+	})
+})
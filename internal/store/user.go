@@ -17,6 +17,9 @@ const (
 	Read Permission = iota
 	Wholesaler
 	Admin
+	//Customer is a retail shopper with an account.  It comes last so the
+	//permissions already stored keep their values.
+	Customer
 )
 
 var (
@@ -47,23 +50,31 @@ type Address struct {
 type User struct {
 	Email string `schema:"email" json:"email"`
 	//Wholesale stuff
-	StoreName       string     `schema:"store_name" json:"store_name,omitempty"`
-	DiscountCodeID  int        `schema:"discount_code_id" json:"discount_code_id,omitempty"`
-	DiscountCode    string     `schema:"discount_code" json:"discount_code,omitempty"`
-	Website         string     `schema:"website" json:"website,omitempty"`
-	FirstName       string     `schema:"first_name" json:"first_name,omitempty"`
-	LastName        string     `schema:"last_name" json:"last_name,omitempty"`
-	Address         Address    `schema:"address" json:"address,omitempty"`
-	ShippingAddress Address    `schema:"shipping_address" json:"shipping_address,omitempty"`
-	Permission      Permission `json:"permission"`
-	Password        string     `schema:"password" json:"-"`
-	Password2       string     `schema:"confirm-password" json:"-"`
-	HashedPassword  []byte     `json:"hashed_password,omitempty"`
+	StoreName         string     `schema:"store_name" json:"store_name,omitempty"`
+	DiscountCodeID    int        `schema:"discount_code_id" json:"discount_code_id,omitempty"`
+	DiscountCode      string     `schema:"discount_code" json:"discount_code,omitempty"`
+	Website           string     `schema:"website" json:"website,omitempty"`
+	FirstName         string     `schema:"first_name" json:"first_name,omitempty"`
+	LastName          string     `schema:"last_name" json:"last_name,omitempty"`
+	Address           Address    `schema:"address" json:"address,omitempty"`
+	ShippingAddress   Address    `schema:"shipping_address" json:"shipping_address,omitempty"`
+	ResaleCertificate string     `schema:"resale_certificate" json:"resale_certificate,omitempty"`
+	Permission        Permission `schema:"-" json:"permission"`
+	Roles             []string   `schema:"-" json:"roles,omitempty"`
+	Password          string     `schema:"password" json:"-"`
+	Password2         string     `schema:"confirm-password" json:"-"`
+	HashedPassword    []byte     `schema:"-" json:"hashed_password,omitempty"`
 
 	//They clicked on the verification email link
-	Verified bool `json:"verified"`
+	Verified bool `schema:"-" json:"verified"`
 	//Admin approval as a real wholesaler
-	Confirmed bool `json:"confirmed,omitempty"`
+	Confirmed bool `schema:"-" json:"confirmed,omitempty"`
+
+	//Two factor login, see twofactor.go
+	TOTPSecret    string   `schema:"-" json:"totp_secret,omitempty"`
+	TOTPPending   string   `schema:"-" json:"totp_pending,omitempty"`
+	TOTPStep      int64    `schema:"-" json:"totp_step,omitempty"`
+	RecoveryCodes [][]byte `schema:"-" json:"recovery_codes,omitempty"`
 }
 
 func GetUsers() ([]User, error) {
@@ -79,6 +90,11 @@ func GetUsers() ([]User, error) {
 	})
 }
 
+// TaxExempt is true for wholesalers that buy for resale.
+func (u *User) TaxExempt() bool {
+	return u.ResaleCertificate != ""
+}
+
 func (u *User) Fetch() error {
 	return db.Get([]Query{{Key: []byte(u.Email), Buckets: [][]byte{[]byte("users")}}}, func(key, val []byte) error {
 		return json.Unmarshal(val, &u)
@@ -168,7 +184,9 @@ func randStr(n int) string {
 	return string(b)
 }
 
-func VerifyWholesaler(token string) (User, error) {
+// VerifyUser marks the user a verification token was emailed to as
+// verified.
+func VerifyUser(token string) (User, error) {
 	var u User
 	var email string
 
@@ -29,6 +29,14 @@ var (
 		"getDate": func(ts time.Time) string {
 			return ts.Format("01/02/2006")
 		},
+		"dateTime": func(ts interface{}) string {
+			t, ok := ts.(*time.Time)
+			if !ok || t == nil {
+				return ""
+			}
+			return t.Format("2006-01-02T15:04")
+		},
+		"join": strings.Join,
 		"dict": func(values ...interface{}) (map[string]interface{}, error) {
 			if len(values)%2 != 0 {
 				return nil, errors.New("invalid dict call")
@@ -66,29 +74,48 @@ func Init(box *rice.Box) {
 
 	templates = map[string]tmpl{
 		"about.html":                      {files: []string{"about.html"}},
+		"page.html":                       {files: []string{"page.html"}},
+		"account/account.html":            {files: []string{"account/account.html"}, funcs: multiplexer},
+		"account/register.html":           {files: []string{"account/register.html"}},
+		"admin/2fa.html":                  {files: []string{"admin/2fa.html"}},
+		"admin/apikeys.html":              {files: []string{"admin/apikeys.html"}},
 		"admin/admin.html":                {files: []string{"admin/admin.html", "admin/links.html", "background-images.html", "admin/admin.js"}},
-		"admin/category.html":             {files: []string{"admin/category.html", "admin/links.html", "background-images.html", "admin/admin.js"}},
-		"admin/subcategory.html":          {files: []string{"admin/subcategory.html", "admin/links.html", "background-images.html", "admin/admin.js"}},
-		"admin/blogs.html":                {files: []string{"admin/blogs.html"}},
-		"admin/product.html":              {files: []string{"admin/product.html", "admin/links.html", "admin/product.js", "background-images.html"}},
+		"admin/currencies.html":           {files: []string{"admin/currencies.html"}},
+		"admin/category.html":             {files: []string{"admin/category.html", "admin/links.html", "admin/publishing.html", "background-images.html", "admin/admin.js"}, funcs: multiplexer},
+		"admin/audit.html":                {files: []string{"admin/audit.html"}},
+		"admin/blogs.html":                {files: []string{"admin/blogs.html"}, funcs: multiplexer},
+		"admin/comments.html":             {files: []string{"admin/comments.html"}, funcs: multiplexer},
+		"admin/history.html":              {files: []string{"admin/history.html"}},
+		"admin/menu.html":                 {files: []string{"admin/menu.html", "admin/menu.js"}, funcs: multiplexer},
+		"admin/pages.html":                {files: []string{"admin/pages.html"}, funcs: multiplexer},
+		"admin/page-form.html":            {files: []string{"admin/page-form.html", "admin/page.js"}, funcs: multiplexer},
+		"admin/logins.html":               {files: []string{"admin/logins.html"}},
+		"admin/product.html":              {files: []string{"admin/product.html", "admin/links.html", "admin/publishing.html", "admin/product.js", "background-images.html"}, funcs: multiplexer},
+		"admin/roles.html":                {files: []string{"admin/roles.html"}},
+		"admin/sessions.html":             {files: []string{"admin/sessions.html"}},
+		"admin/shipping.html":             {files: []string{"admin/shipping.html"}},
+		"admin/taxes.html":                {files: []string{"admin/taxes.html"}},
 		"admin/wholesaler.html":           {files: []string{"admin/wholesaler.html"}},
 		"admin/wholesalers.html":          {files: []string{"admin/wholesalers.html"}},
-		"blogs/blogs.html":                {files: []string{"blogs/blogs.html"}, funcs: multiplexer},
-		"admin/blog-form.html":            {files: []string{"admin/blog-form.html", "admin/blog.js"}, funcs: multiplexer},
+		"blogs/blogs.html":                {files: []string{"blogs/blogs.html", "blogs/archive.html", "blogs/pager.html"}, funcs: multiplexer},
+		"blogs/list.html":                 {files: []string{"blogs/list.html", "blogs/archive.html", "blogs/pager.html"}, funcs: multiplexer},
+		"admin/blog-form.html":            {files: []string{"admin/blog-form.html", "admin/publishing.html", "admin/blog.js"}, funcs: multiplexer},
 		"cart.html":                       {files: []string{"cart.html", "cart.js"}},
-		"category.html":                   {files: []string{"category.html", "thumb.html"}},
+		"category.html":                   {files: []string{"category.html", "breadcrumbs.html", "thumb.html", "facets.html"}, funcs: multiplexer},
 		"confirm.html":                    {files: []string{"confirm.html", "confirm.js"}},
 		"contact.html":                    {files: []string{"contact.html"}},
 		"index.html":                      {files: []string{"index.html"}},
 		"lineitem.html":                   {files: []string{"lineitem.html"}, bare: true},
+		"login-2fa.html":                  {files: []string{"login-2fa.html"}},
 		"login.html":                      {files: []string{"login.html"}},
 		"logout.html":                     {files: []string{"logout.html", "confirm.js"}},
 		"notfound.html":                   {files: []string{"notfound.html"}},
-		"product.html":                    {files: []string{"product.html", "product.js"}},
+		"product.html":                    {files: []string{"product.html", "breadcrumbs.html", "product.js"}, funcs: multiplexer},
 		"reset-form.html":                 {files: []string{"reset-form.html"}},
 		"reset.html":                      {files: []string{"reset.html"}},
+		"search.html":                     {files: []string{"search.html"}},
 		"shop.html":                       {files: []string{"shop.html", "thumb.html"}, funcs: multiplexer},
-		"subcategory.html":                {files: []string{"subcategory.html", "thumb.html"}, funcs: multiplexer},
+		"tag.html":                        {files: []string{"tag.html", "thumb.html"}},
 		"wholesale/application-form.html": {files: []string{"wholesale/application-form.html", "wholesale/application.js"}},
 		"wholesale/form.html":             {files: []string{"wholesale/form.html", "wholesale/thumb.html", "wholesale/wholesale.js"}, funcs: multiplexer},
 		"wholesale/invoice-sent.html":     {files: []string{"wholesale/invoice-sent.html"}},
@@ -0,0 +1,89 @@
+// Package totp generates and checks RFC 6238 time-based one time
+// passwords, the six digit codes shown by authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	digits = 6
+	period = 30 * time.Second
+	//how many periods either side of now a code is still good for, to
+	//allow for clocks that have drifted
+	skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret returns a random base32 encoded secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI is what goes in the QR code an authenticator app scans.
+func URI(issuer, account, secret string) string {
+	v := url.Values{
+		"secret": {secret},
+		"issuer": {issuer},
+	}
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, account))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, v.Encode())
+}
+
+// Code is the code for secret at time t.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	return code(key, uint64(t.Unix()/int64(period/time.Second))), nil
+}
+
+// Validate checks a code a user typed in against secret.  It also
+// returns the time step the code belongs to so the caller can refuse to
+// accept the same code twice.
+func Validate(secret, c string, t time.Time) (int64, bool) {
+	c = strings.Replace(strings.TrimSpace(c), " ", "", -1)
+	if len(c) != digits {
+		return 0, false
+	}
+
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return 0, false
+	}
+
+	counter := t.Unix() / int64(period/time.Second)
+	for i := int64(-skew); i <= skew; i++ {
+		if hmac.Equal([]byte(code(key, uint64(counter+i))), []byte(c)) {
+			return counter + i, true
+		}
+	}
+	return 0, false
+}
+
+func code(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	h := hmac.New(sha1.New, key)
+	h.Write(msg[:])
+	sum := h.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	n := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", digits, n%1000000)
+}
@@ -0,0 +1,50 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"time"
+
+	"github.com/cswank/store/internal/totp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("totp", func() {
+
+	//the SHA1 test vectors from RFC 6238, trimmed to six digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	It("matches the RFC test vectors", func() {
+		vectors := map[int64]string{
+			59:         "287082",
+			1111111109: "081804",
+			1234567890: "005924",
+			2000000000: "279037",
+		}
+
+		for ts, expected := range vectors {
+			c, err := totp.Code(secret, time.Unix(ts, 0))
+			Expect(err).To(BeNil())
+			Expect(c).To(Equal(expected))
+		}
+	})
+
+	It("accepts the previous code but not an older one", func() {
+		now := time.Unix(1111111109, 0)
+		c, _ := totp.Code(secret, now.Add(-30*time.Second))
+		step, ok := totp.Validate(secret, c, now)
+		Expect(ok).To(BeTrue())
+		Expect(step).To(Equal(int64(1111111109/30 - 1)))
+
+		c, _ = totp.Code(secret, now.Add(-90*time.Second))
+		_, ok = totp.Validate(secret, c, now)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("makes secrets an authenticator app can use", func() {
+		s, err := totp.NewSecret()
+		Expect(err).To(BeNil())
+		Expect(s).To(HaveLen(32))
+		Expect(totp.URI("my store", "craig@example.com", s)).To(Equal("otpauth://totp/my%20store:craig@example.com?issuer=my+store&secret=" + s))
+	})
+})
@@ -3,12 +3,10 @@ package utils
 import (
 	"fmt"
 	"log"
-
-	"github.com/cswank/store/internal/store"
 )
 
-func EditBlog() {
-	blogs, err := store.Blogs()
+func EditBlog(c Catalog) {
+	blogs, err := c.Blogs()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -20,29 +18,16 @@ func EditBlog() {
 
 	var i int
 	fmt.Scanf("%d\n", &i)
-	key := blogs[i-1]
-
-	blog, err := store.GetBlog(key.ID)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	blog2, err := store.GetBlog(key.ID)
-	if err != nil {
-		log.Fatal(err)
-	}
+	blog := blogs[i-1]
 
 	fmt.Println("new title:")
 	var t string
 
 	fmt.Scanf("%q\n", &t)
 
-	blog2.Title = t
-	if err := blog.Update(blog2, nil); err != nil {
+	id := blog.ID
+	blog.Title = t
+	if _, err := c.UpdateBlog(id, blog); err != nil {
 		log.Fatal("could not update blog title", err)
 	}
 }
-
-func deleteBlog(blog store.BlogKey) {
-
-}
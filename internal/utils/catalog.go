@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"github.com/cswank/store/internal/api"
+	"github.com/cswank/store/internal/store"
+)
+
+// Catalog is what the commands need from a store.  It is either the
+// local database or an api.Client for a remote store.
+type Catalog interface {
+	Categories() ([]api.Category, error)
+	Category(path ...string) (api.Category, error)
+	RenameCategory(path []string, name string) error
+	DeleteCategory(path []string) error
+	Blogs() ([]api.Blog, error)
+	UpdateBlog(id string, b api.Blog) (api.Blog, error)
+}
+
+// Local is the Catalog in the local database.  store.Init has to have
+// been called.
+type Local struct{}
+
+func (Local) Categories() ([]api.Category, error) {
+	names, err := store.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	cats := make([]api.Category, len(names))
+	for i, name := range names {
+		cats[i] = api.Category{Name: name, Path: []string{name}}
+	}
+	return cats, nil
+}
+
+func (Local) Category(path ...string) (api.Category, error) {
+	children, err := store.GetSubCategories(path...)
+	if err != nil {
+		return api.Category{}, err
+	}
+
+	return api.Category{Name: path[len(path)-1], Path: path, Children: children}, nil
+}
+
+func (Local) RenameCategory(path []string, name string) error {
+	return store.RenameCategory(path, name)
+}
+
+func (Local) DeleteCategory(path []string) error {
+	return store.DeleteCategory(path)
+}
+
+func (Local) Blogs() ([]api.Blog, error) {
+	keys, err := store.Blogs()
+	if err != nil {
+		return nil, err
+	}
+
+	blogs := make([]api.Blog, len(keys))
+	for i, k := range keys {
+		b, err := store.GetBlog(k.ID)
+		if err != nil {
+			return nil, err
+		}
+		blogs[i] = api.Blog{ID: k.ID, Title: b.Title, Date: b.Date, Body: b.Body, Tags: b.Tags}
+	}
+	return blogs, nil
+}
+
+func (Local) UpdateBlog(id string, b api.Blog) (api.Blog, error) {
+	blog, err := store.GetBlog(id)
+	if err != nil {
+		return b, err
+	}
+
+	if err := blog.Update(store.Blog{Title: b.Title, Date: b.Date, Body: b.Body, Tags: b.Tags}, nil); err != nil {
+		return b, err
+	}
+
+	b.ID = blog.Key()
+	return b, nil
+}
@@ -3,86 +3,84 @@ package utils
 import (
 	"fmt"
 	"log"
-
-	"github.com/cswank/store/internal/store"
+	"strings"
 )
 
-func EditCategory() {
-	cats, err := store.GetCategories()
+func EditCategory(c Catalog) {
+	cats, err := c.Categories()
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	fmt.Println("select a category")
 	for i, cat := range cats {
-		fmt.Printf("%d %s\n", i+1, cat)
+		fmt.Printf("%d %s\n", i+1, cat.Name)
 	}
 
 	var i int
 	fmt.Scanf("%d\n", &i)
-	cat := cats[i-1]
+	chooseAction(c, []string{cats[i-1].Name})
+}
 
-	fmt.Println("(e)dit or (d)delete?")
+func chooseAction(c Catalog, path []string) {
+	fmt.Printf("%s: (e)dit or (d)delete?\n", strings.Join(path, "/"))
 	var a string
 	fmt.Scanf("%s\n", &a)
 
 	if a == "e" {
-		editCatetory(cat)
+		editCatetory(c, path)
 	} else if a == "d" {
-		deleteCategory(cat)
+		deleteCategory(c, path)
 	}
 }
 
-func editCatetory(cat string) {
+func editCatetory(c Catalog, path []string) {
 	fmt.Println("(r)ename or (l)list subcategories?")
 	var a string
 	fmt.Scanf("%s\n", &a)
 
 	if a == "r" {
-		renameCategory(cat)
+		renameCategory(c, path)
 	} else if a == "l" {
-		editSubcategories(cat)
+		editSubcategories(c, path)
 	}
 }
 
-func deleteCategory(cat string) {
-
+func deleteCategory(c Catalog, path []string) {
+	if err := c.DeleteCategory(path); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("success")
 }
 
-func renameCategory(cat string) {
-
+func renameCategory(c Catalog, path []string) {
+	fmt.Print("New name: ")
+	var n string
+	fmt.Scanf("%q\n", &n)
+	if err := c.RenameCategory(path, n); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func editSubcategories(cat string) {
-
-	subcats, err := store.GetSubCategories(cat)
+// editSubcategories picks one of the categories in the one at path,
+// which can have sub-categories of its own.
+func editSubcategories(c Catalog, path []string) {
+	cat, err := c.Category(path...)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if len(cat.Children) == 0 {
+		fmt.Println("no subcategories")
+		return
+	}
+
 	fmt.Println("select a subcategory")
-	for i, subcat := range subcats {
-		fmt.Printf("%d %s\n", i+1, subcat)
+	for i, child := range cat.Children {
+		fmt.Printf("%d %s\n", i+1, child)
 	}
 
 	var i int
 	fmt.Scanf("%d\n", &i)
-	subcat := subcats[i-1]
-
-	fmt.Println("(r)ename or (d)delete?")
-	var a string
-	fmt.Scanf("%s\n", &a)
-
-	if a == "r" {
-		fmt.Print("New name: ")
-		var n string
-		fmt.Scanf("%q\n", &n)
-		store.RenameSubcategory(cat, subcat, n)
-	} else if a == "d" {
-		if err := store.DeleteSubcategory(cat, subcat); err != nil {
-			log.Fatal(err)
-		} else {
-			fmt.Println("success")
-		}
-	}
+	chooseAction(c, append(path, cat.Children[i-1]))
 }
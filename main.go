@@ -19,6 +19,7 @@ import (
 
 	"github.com/GeertJohan/go.rice"
 	"github.com/caarlos0/env"
+	"github.com/cswank/store/internal/api"
 	"github.com/cswank/store/internal/config"
 	"github.com/cswank/store/internal/email"
 	"github.com/cswank/store/internal/handlers"
@@ -33,6 +34,8 @@ import (
 
 var (
 	cfg      config.Config
+	remote   = kingpin.Flag("remote", "edit the store at this address through its api instead of the local database").OverrideDefaultFromEnvar("STORE_REMOTE").String()
+	apiKey   = kingpin.Flag("api-key", "an api key from the remote store's /admin/apikeys").OverrideDefaultFromEnvar("STORE_API_KEY").String()
 	serve    = kingpin.Command("serve", "Start the server.")
 	fake     = serve.Flag("fake-shopify", "start a fake shopify").Short('f').Bool()
 	items    = kingpin.Command("items", "save and delete items")
@@ -49,6 +52,8 @@ var (
 	blogs = kingpin.Command("blogs", "save, edit and delete blogs")
 	_     = blogs.Command("edit", "edit a blog")
 
+	_ = kingpin.Command("migrate", "convert stored prices, shipping rates and invoices to the money format and move products out of NOSUBCATEGORIES")
+
 	box       *rice.Box
 	staticBox *rice.Box
 
@@ -63,26 +68,56 @@ func init() {
 	if err := env.Parse(&cfg); err != nil {
 		log.Fatal("could not parse config", err)
 	}
-	store.Init(cfg)
 	email.Init(cfg)
 }
 
 func main() {
 	kingpin.UsageTemplate(kingpin.CompactUsageTemplate).Version(version).Author("Craig Swank")
-	switch kingpin.Parse() {
+	cmd := kingpin.Parse()
+	if *remote == "" {
+		store.Init(cfg)
+	} else if !remoteCommands[cmd] {
+		log.Fatalf("%s can't be used with --remote", cmd)
+	}
+
+	switch cmd {
 	case "serve":
+		go handlers.PublishScheduled(time.Minute)
 		doServe()
 	case "categories":
-		utils.EditCategory()
+		utils.EditCategory(catalog())
 	case "users add":
 		utils.AddUser()
 	case "users edit":
 		utils.EditUser()
 	case "categories edit":
-		utils.EditCategory()
+		utils.EditCategory(catalog())
 	case "blogs edit":
-		utils.EditBlog()
+		utils.EditBlog(catalog())
+	case "migrate":
+		if err := store.MigratePrices(); err != nil {
+			log.Fatal(err)
+		}
+		if err := store.MigrateCategories(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// remoteCommands are the commands that can go through the api.
+var remoteCommands = map[string]bool{
+	"categories":      true,
+	"categories edit": true,
+	"blogs edit":      true,
+}
+
+// catalog is the remote store when --remote is set so the commands don't
+// need the bolt file, which the server keeps locked.
+func catalog() utils.Catalog {
+	if *remote != "" {
+		return api.NewClient(*remote, *apiKey)
 	}
+	return utils.Local{}
 }
 
 func initServe() {
@@ -108,6 +143,8 @@ func initServe() {
 					w.WriteHeader(http.StatusCreated)
 					json.NewEncoder(w).Encode(m)
 				}
+			} else if strings.Contains(r.URL.Path, "orders") {
+				json.NewEncoder(w).Encode(map[string][]shopify.Order{"orders": {}})
 			}
 		}))
 		//cfg.Domains = []string{ts.URL}
@@ -121,11 +158,23 @@ func initServe() {
 }
 
 func getMiddleware(perm handlers.ACL, f handlers.HandlerFunc) http.Handler {
-	return alice.New(handlers.Authentication, handlers.Perm(perm)).Then(handlers.HandleErr(f))
+	return alice.New(handlers.Authentication, handlers.CSRF, handlers.Perm(perm)).Then(handlers.HandleErr(f))
 }
 
 func getImageMiddleware(perm handlers.ACL, f handlers.HandlerFunc) http.Handler {
-	return alice.New(handlers.ETag, handlers.Authentication, handlers.Perm(perm)).Then(handlers.HandleErr(f))
+	return alice.New(handlers.ETag, handlers.Authentication, handlers.CSRF, handlers.Perm(perm)).Then(handlers.HandleErr(f))
+}
+
+// getExternalMiddleware is for requests that don't come from our own
+// pages, so they can't have a CSRF token.
+func getExternalMiddleware(perm handlers.ACL, f handlers.HandlerFunc) http.Handler {
+	return alice.New(handlers.Authentication, handlers.Perm(perm)).Then(handlers.HandleErr(f))
+}
+
+// getAPIMiddleware is for /api/v1, which authenticates with api keys
+// instead of the session cookie so it doesn't need CSRF.
+func getAPIMiddleware(perm handlers.ACL, f handlers.HandlerFunc) http.Handler {
+	return alice.New(handlers.APIAuthentication, handlers.APIPerm(perm)).Then(handlers.HandleAPIErr(f))
 }
 
 func doServe() {
@@ -136,18 +185,27 @@ func doServe() {
 	r := mux.NewRouter().StrictSlash(true)
 
 	if cfg.WebhookID != "" && cfg.WebhookScript != "" && cfg.WebhookIPWhitelist != "" {
-		r.Handle("/webhooks/{id}", getMiddleware(handlers.IPWhitelist, handlers.GetWebhooks(restartChan))).Methods("POST")
+		r.Handle("/webhooks/{id}", getExternalMiddleware(handlers.IPWhitelist, handlers.GetWebhooks(restartChan))).Methods("POST")
 	}
 
 	r.Handle("/", getMiddleware(handlers.Anyone, handlers.Home)).Methods("GET")
 	r.Handle("/login", getMiddleware(handlers.Anyone, handlers.Login)).Methods("GET")
 	r.Handle("/login", getMiddleware(handlers.Human, handlers.DoLogin)).Methods("POST")
+	r.Handle("/login/2fa", getMiddleware(handlers.Anyone, handlers.TwoFactorLogin)).Methods("GET")
+	r.Handle("/login/2fa", getMiddleware(handlers.Anyone, handlers.DoTwoFactorLogin)).Methods("POST")
 	r.Handle("/login/reset", getMiddleware(handlers.Anyone, handlers.ResetPage)).Methods("GET")
 	r.Handle("/login/reset", getMiddleware(handlers.Human, handlers.SendReset)).Methods("POST")
 	r.Handle("/login/do-reset", getMiddleware(handlers.Anyone, handlers.ResetPassword)).Methods("GET")
 	r.Handle("/login/do-reset", getMiddleware(handlers.Anyone, handlers.DoResetPassword)).Methods("POST")
 	r.Handle("/logout", getMiddleware(handlers.Anyone, handlers.Logout)).Methods("GET")
 	r.Handle("/logout", getMiddleware(handlers.Anyone, handlers.DoLogout)).Methods("POST")
+	r.Handle("/logout/everywhere", getMiddleware(handlers.Read, handlers.DoLogoutEverywhere)).Methods("POST")
+
+	r.Handle("/account", getMiddleware(handlers.Anyone, handlers.Account)).Methods("GET")
+	r.Handle("/account", getMiddleware(handlers.Customer, handlers.AccountUpdate)).Methods("POST")
+	r.Handle("/account/register", getMiddleware(handlers.Anyone, handlers.AccountRegistration)).Methods("GET")
+	r.Handle("/account/register", getMiddleware(handlers.Human, handlers.AccountRegister)).Methods("POST")
+	r.Handle("/account/verify/{token}", getMiddleware(handlers.Anyone, handlers.AccountVerify)).Methods("GET")
 
 	r.Handle("/contact", getMiddleware(handlers.Anyone, handlers.Contact)).Methods("GET")
 	r.Handle("/contact", getMiddleware(handlers.Human, handlers.DoContact)).Methods("POST")
@@ -160,48 +218,119 @@ func doServe() {
 	r.Handle("/wholesale/thanks", getMiddleware(handlers.Anyone, handlers.WholesaleThanks)).Methods("GET")
 
 	r.Handle("/cart", getMiddleware(handlers.Anyone, handlers.Cart)).Methods("GET")
-	r.Handle("/cart/lineitem/{category}/{subcategory}/{title}", getMiddleware(handlers.Anyone, handlers.LineItem)).Methods("GET")
+	r.Handle("/currency/{currency}", getMiddleware(handlers.Anyone, handlers.SetCurrency)).Methods("POST")
+	r.Handle("/cart/lineitem/{path:.+}", getMiddleware(handlers.Anyone, handlers.LineItem)).Methods("GET")
+	r.Handle("/cart/shipping", getMiddleware(handlers.Anyone, handlers.CartShipping)).Methods("GET")
 
 	r.Handle("/blog", getMiddleware(handlers.Anyone, handlers.Blog)).Methods("GET")
+	r.Handle("/blog/feed.rss", getMiddleware(handlers.Anyone, handlers.BlogRSS)).Methods("GET")
+	r.Handle("/blog/feed.atom", getMiddleware(handlers.Anyone, handlers.BlogAtom)).Methods("GET")
+	r.Handle("/blog/archive/{month}", getMiddleware(handlers.Anyone, handlers.BlogMonth)).Methods("GET")
+	r.Handle("/blog/tags/{tag}", getMiddleware(handlers.Anyone, handlers.BlogTag)).Methods("GET")
 	r.Handle("/blog/{blog}", getMiddleware(handlers.Anyone, handlers.Blog)).Methods("GET")
+	r.Handle("/blog/{blog}/comments", getMiddleware(handlers.Human, handlers.AddComment)).Methods("POST")
 	r.Handle("/images/blogs/{blog}", getMiddleware(handlers.Anyone, handlers.BlogImage)).Methods("GET")
+	r.Handle("/images/blogs/{blog}/{image}", getImageMiddleware(handlers.Anyone, handlers.BlogMediaImage)).Methods("GET")
+
+	r.Handle("/search", getMiddleware(handlers.Anyone, handlers.Search)).Methods("GET")
+	r.Handle("/search/suggest", getMiddleware(handlers.Anyone, handlers.SearchSuggest)).Methods("GET")
 
 	r.Handle("/about", getMiddleware(handlers.Anyone, handlers.About)).Methods("GET")
 
 	r.Handle("/shop", getMiddleware(handlers.Anyone, handlers.Shop)).Methods("GET")
-	r.Handle("/shop/{category}", getMiddleware(handlers.Anyone, handlers.Category)).Methods("GET")
-	r.Handle("/shop/{category}/{subcategory}", getMiddleware(handlers.Anyone, handlers.SubCategory)).Methods("GET")
-	r.Handle("/shop/{category}/{subcategory}/{title}", getMiddleware(handlers.Anyone, handlers.Product)).Methods("GET")
+	r.Handle("/shop/tags/{tag}", getMiddleware(handlers.Anyone, handlers.Tag)).Methods("GET")
 	r.Handle("/shop/images/{type}/{title}/{size}", getImageMiddleware(handlers.Anyone, handlers.Image)).Methods("GET")
-
-	r.Handle("/api/{category}/{subcategory}/{title}", getMiddleware(handlers.Anyone, handlers.GetProduct)).Methods("GET")
-
-	r.Handle("/admin", getMiddleware(handlers.Admin, handlers.AdminPage)).Methods("GET")
-
-	r.Handle("/admin/blogs", getMiddleware(handlers.Admin, handlers.ManageBlogs)).Methods("GET")
-	r.Handle("/admin/blogs", getMiddleware(handlers.Admin, handlers.CreateBlog)).Methods("POST")
-	r.Handle("/admin/blogs/{blog}", getMiddleware(handlers.Admin, handlers.BlogForm)).Methods("GET")
-	r.Handle("/admin/blogs/{blog}", getMiddleware(handlers.Admin, handlers.UpdateBlog)).Methods("POST")
-	r.Handle("/admin/blogs/{blog}", getMiddleware(handlers.Admin, handlers.DeleteBlog)).Methods("DELETE")
-	r.Handle("/admin/wholesalers", getMiddleware(handlers.Admin, handlers.AdminWholesalers)).Methods("GET")
-	r.Handle("/admin/wholesalers/{wholesaler}", getMiddleware(handlers.Admin, handlers.AdminWholesaler)).Methods("GET")
-	r.Handle("/admin/wholesalers/{wholesaler}", getMiddleware(handlers.Admin, handlers.AdminWholesalerUpdate)).Methods("POST")
-	r.Handle("/admin/wholesalers/{wholesaler}", getMiddleware(handlers.Admin, handlers.AdminWholesalerDelete)).Methods("DELETE")
-	r.Handle("/admin/wholesalers/{wholesaler}/confirmation", getMiddleware(handlers.Admin, handlers.AdminWholesalerConfirm)).Methods("POST")
-	r.Handle("/admin/db/backup", getMiddleware(handlers.Admin, handlers.BackupDB)).Methods("GET")
-	r.Handle("/admin/confirm", getMiddleware(handlers.Admin, handlers.Confirm)).Methods("GET")
-	r.Handle("/admin/categories", getMiddleware(handlers.Admin, handlers.AddCategory)).Methods("POST")
-	r.Handle("/admin/categories/{category}", getMiddleware(handlers.Admin, handlers.AdminCategoryPage)).Methods("GET")
-	r.Handle("/admin/categories/{category}", getMiddleware(handlers.Admin, handlers.RenameCategory)).Methods("POST")
-	r.Handle("/admin/categories/{category}", getMiddleware(handlers.Admin, handlers.DeleteCategory)).Methods("DELETE")
-	r.Handle("/admin/categories/{category}/price", getMiddleware(handlers.Admin, handlers.UpdatePrice)).Methods("POST")
-	r.Handle("/admin/categories/{category}/subcategories", getMiddleware(handlers.Admin, handlers.AddCategory)).Methods("POST")
-	r.Handle("/admin/categories/{category}/subcategories/{subcategory}", getMiddleware(handlers.Admin, handlers.AdminAddProductPage)).Methods("GET")
-	r.Handle("/admin/categories/{category}/subcategories/{subcategory}", getMiddleware(handlers.Admin, handlers.RenameSubcategory)).Methods("POST")
-	r.Handle("/admin/categories/{category}/subcategories/{subcategory}/products", getMiddleware(handlers.Admin, handlers.AddProduct)).Methods("POST")
-	r.Handle("/admin/categories/{category}/subcategories/{subcategory}/products/{title}", getMiddleware(handlers.Admin, handlers.AdminProductPage)).Methods("GET")
-	r.Handle("/admin/categories/{category}/subcategories/{subcategory}/products/{title}", getMiddleware(handlers.Admin, handlers.UpdateProduct)).Methods("POST")
-	r.Handle("/admin/categories/{category}/subcategories/{subcategory}/products/{title}", getMiddleware(handlers.Admin, handlers.DeleteProduct)).Methods("DELETE")
+	r.Handle("/shop/{path:.+}", getMiddleware(handlers.Anyone, handlers.Category)).Methods("GET")
+
+	r.Handle("/api/openapi.json", getMiddleware(handlers.Anyone, handlers.OpenAPI(r))).Methods("GET")
+	r.Handle("/api/v1/categories", getAPIMiddleware(handlers.APIRead, handlers.APICategories)).Methods("GET").Name("listCategories")
+	r.Handle("/api/v1/categories", getAPIMiddleware(handlers.APIScope(store.CatalogWrite), handlers.APICategoryCreate)).Methods("POST").Name("createCategory")
+	r.Handle("/api/v1/categories/{path:.+}", getAPIMiddleware(handlers.APIRead, handlers.APICategory)).Methods("GET").Name("getCategory")
+	r.Handle("/api/v1/categories/{path:.+}", getAPIMiddleware(handlers.APIScope(store.CatalogWrite), handlers.APISubcategoryCreate)).Methods("POST").Name("createSubcategory")
+	r.Handle("/api/v1/categories/{path:.+}", getAPIMiddleware(handlers.APIScope(store.CatalogWrite), handlers.APICategoryUpdate)).Methods("PUT").Name("renameCategory")
+	r.Handle("/api/v1/categories/{path:.+}", getAPIMiddleware(handlers.APIScope(store.CatalogWrite), handlers.APICategoryDelete)).Methods("DELETE").Name("deleteCategory")
+	r.Handle("/api/v1/prices/{path:.+}", getAPIMiddleware(handlers.APIRead, handlers.APIPrice)).Methods("GET").Name("getPrice")
+	r.Handle("/api/v1/prices/{path:.+}", getAPIMiddleware(handlers.APIScope(store.PricingWrite), handlers.APIPriceUpdate)).Methods("PUT").Name("setPrice")
+	r.Handle("/api/v1/products", getAPIMiddleware(handlers.APIRead, handlers.APIProducts)).Methods("GET").Name("listProducts")
+	r.Handle("/api/v1/products/{path:.+}", getAPIMiddleware(handlers.APIRead, handlers.APIProduct)).Methods("GET").Name("getProduct")
+	r.Handle("/api/v1/products/{path:.+}", getAPIMiddleware(handlers.APIScope(store.CatalogWrite), handlers.APIProductCreate)).Methods("POST").Name("createProduct")
+	r.Handle("/api/v1/products/{path:.+}", getAPIMiddleware(handlers.APIScope(store.CatalogWrite), handlers.APIProductUpdate)).Methods("PUT").Name("updateProduct")
+	r.Handle("/api/v1/products/{path:.+}", getAPIMiddleware(handlers.APIScope(store.CatalogWrite), handlers.APIProductDelete)).Methods("DELETE").Name("deleteProduct")
+	r.Handle("/api/v1/blogs", getAPIMiddleware(handlers.APIRead, handlers.APIBlogs)).Methods("GET").Name("listBlogs")
+	r.Handle("/api/v1/blogs", getAPIMiddleware(handlers.APIScope(store.BlogWrite), handlers.APIBlogCreate)).Methods("POST").Name("createBlog")
+	r.Handle("/api/v1/blogs/{id}", getAPIMiddleware(handlers.APIRead, handlers.APIBlog)).Methods("GET").Name("getBlog")
+	r.Handle("/api/v1/blogs/{id}", getAPIMiddleware(handlers.APIScope(store.BlogWrite), handlers.APIBlogUpdate)).Methods("PUT").Name("updateBlog")
+	r.Handle("/api/v1/blogs/{id}", getAPIMiddleware(handlers.APIScope(store.BlogWrite), handlers.APIBlogDelete)).Methods("DELETE").Name("deleteBlog")
+
+	r.Handle("/api/{path:.+}", getMiddleware(handlers.Anyone, handlers.GetProduct)).Methods("GET")
+
+	r.Handle("/admin", getMiddleware(handlers.Staff, handlers.AdminPage)).Methods("GET")
+
+	r.Handle("/admin/blogs", getMiddleware(handlers.Can(store.BlogWrite), handlers.ManageBlogs)).Methods("GET")
+	r.Handle("/admin/blogs", getMiddleware(handlers.Can(store.BlogWrite), handlers.CreateBlog)).Methods("POST")
+	r.Handle("/admin/blogs/preview", getMiddleware(handlers.Can(store.BlogWrite), handlers.BlogPreview)).Methods("POST")
+	r.Handle("/admin/blogs/{blog}", getMiddleware(handlers.Can(store.BlogWrite), handlers.BlogForm)).Methods("GET")
+	r.Handle("/admin/blogs/{blog}", getMiddleware(handlers.Can(store.BlogWrite), handlers.UpdateBlog)).Methods("POST")
+	r.Handle("/admin/blogs/{blog}", getMiddleware(handlers.Can(store.BlogWrite), handlers.DeleteBlog)).Methods("DELETE")
+	r.Handle("/admin/blogs/{blog}/history", getMiddleware(handlers.Can(store.BlogWrite), handlers.BlogHistory)).Methods("GET")
+	r.Handle("/admin/blogs/{blog}/history", getMiddleware(handlers.Can(store.BlogWrite), handlers.RestoreBlog)).Methods("POST")
+	r.Handle("/admin/blogs/{blog}/media", getMiddleware(handlers.Can(store.BlogWrite), handlers.AddBlogMedia)).Methods("POST")
+	r.Handle("/admin/blogs/{blog}/media/{image}", getMiddleware(handlers.Can(store.BlogWrite), handlers.DeleteBlogMedia)).Methods("DELETE")
+	r.Handle("/admin/menu", getMiddleware(handlers.Can(store.PagesWrite), handlers.AdminMenu)).Methods("GET")
+	r.Handle("/admin/menu", getMiddleware(handlers.Can(store.PagesWrite), handlers.AdminMenuUpdate)).Methods("POST")
+	r.Handle("/admin/pages", getMiddleware(handlers.Can(store.PagesWrite), handlers.AdminPages)).Methods("GET")
+	r.Handle("/admin/pages", getMiddleware(handlers.Can(store.PagesWrite), handlers.SavePage)).Methods("POST")
+	r.Handle("/admin/pages/preview", getMiddleware(handlers.Can(store.PagesWrite), handlers.PagePreview)).Methods("POST")
+	r.Handle("/admin/pages/{page}", getMiddleware(handlers.Can(store.PagesWrite), handlers.PageForm)).Methods("GET")
+	r.Handle("/admin/pages/{page}", getMiddleware(handlers.Can(store.PagesWrite), handlers.SavePage)).Methods("POST")
+	r.Handle("/admin/pages/{page}", getMiddleware(handlers.Can(store.PagesWrite), handlers.DeletePage)).Methods("DELETE")
+	r.Handle("/admin/pages/{page}/revisions/{revision}", getMiddleware(handlers.Can(store.PagesWrite), handlers.RestorePage)).Methods("POST")
+	r.Handle("/admin/comments", getMiddleware(handlers.Can(store.BlogWrite), handlers.AdminComments)).Methods("GET")
+	r.Handle("/admin/comments/{blog}/{id}", getMiddleware(handlers.Can(store.BlogWrite), handlers.ModerateComment)).Methods("POST")
+	r.Handle("/admin/wholesalers", getMiddleware(handlers.Can(store.WholesalersApprove), handlers.AdminWholesalers)).Methods("GET")
+	r.Handle("/admin/wholesalers/{wholesaler}", getMiddleware(handlers.Can(store.WholesalersApprove), handlers.AdminWholesaler)).Methods("GET")
+	r.Handle("/admin/wholesalers/{wholesaler}", getMiddleware(handlers.Can(store.WholesalersApprove), handlers.AdminWholesalerUpdate)).Methods("POST")
+	r.Handle("/admin/wholesalers/{wholesaler}", getMiddleware(handlers.Can(store.UsersManage), handlers.AdminWholesalerDelete)).Methods("DELETE")
+	r.Handle("/admin/wholesalers/{wholesaler}/confirmation", getMiddleware(handlers.Can(store.WholesalersApprove), handlers.AdminWholesalerConfirm)).Methods("POST")
+	r.Handle("/admin/2fa", getMiddleware(handlers.StaffAccount, handlers.AdminTwoFactor)).Methods("GET")
+	r.Handle("/admin/2fa", getMiddleware(handlers.StaffAccount, handlers.AdminTwoFactorEnable)).Methods("POST")
+	r.Handle("/admin/2fa/recovery-codes", getMiddleware(handlers.Staff, handlers.AdminRecoveryCodes)).Methods("POST")
+	r.Handle("/admin/2fa/disable", getMiddleware(handlers.Staff, handlers.AdminTwoFactorDisable)).Methods("POST")
+	r.Handle("/admin/logins", getMiddleware(handlers.Can(store.UsersManage), handlers.AdminLogins)).Methods("GET")
+	r.Handle("/admin/logins/{key}", getMiddleware(handlers.Can(store.UsersManage), handlers.AdminUnlock)).Methods("DELETE")
+	r.Handle("/admin/apikeys", getMiddleware(handlers.Admin, handlers.AdminAPIKeys)).Methods("GET")
+	r.Handle("/admin/apikeys", getMiddleware(handlers.Admin, handlers.AdminAPIKeyCreate)).Methods("POST")
+	r.Handle("/admin/apikeys/{id}", getMiddleware(handlers.Admin, handlers.AdminAPIKeyRevoke)).Methods("DELETE")
+	r.Handle("/admin/audit", getMiddleware(handlers.Can(store.AuditRead), handlers.AdminAudit)).Methods("GET")
+	r.Handle("/admin/roles", getMiddleware(handlers.Admin, handlers.AdminRoles)).Methods("GET")
+	r.Handle("/admin/roles", getMiddleware(handlers.Admin, handlers.AdminRoleUpdate)).Methods("POST")
+	r.Handle("/admin/roles/{role}", getMiddleware(handlers.Admin, handlers.AdminRoleDelete)).Methods("DELETE")
+	r.Handle("/admin/staff", getMiddleware(handlers.Admin, handlers.AdminStaffUpdate)).Methods("POST")
+	r.Handle("/admin/sessions", getMiddleware(handlers.Can(store.UsersManage), handlers.AdminSessions)).Methods("GET")
+	r.Handle("/admin/sessions/{session}", getMiddleware(handlers.Can(store.UsersManage), handlers.AdminSessionDelete)).Methods("DELETE")
+	r.Handle("/admin/users/{email}/sessions", getMiddleware(handlers.Can(store.UsersManage), handlers.AdminUserSessionsDelete)).Methods("DELETE")
+	r.Handle("/admin/shipping", getMiddleware(handlers.Can(store.ShippingWrite), handlers.AdminShipping)).Methods("GET")
+	r.Handle("/admin/shipping", getMiddleware(handlers.Can(store.ShippingWrite), handlers.AdminShippingUpdate)).Methods("POST")
+	r.Handle("/admin/shipping/{zone}", getMiddleware(handlers.Can(store.ShippingWrite), handlers.AdminShippingDelete)).Methods("DELETE")
+	r.Handle("/admin/taxes", getMiddleware(handlers.Can(store.TaxesWrite), handlers.AdminTaxes)).Methods("GET")
+	r.Handle("/admin/taxes", getMiddleware(handlers.Can(store.TaxesWrite), handlers.AdminTaxUpdate)).Methods("POST")
+	r.Handle("/admin/taxes/{jurisdiction}", getMiddleware(handlers.Can(store.TaxesWrite), handlers.AdminTaxDelete)).Methods("DELETE")
+	r.Handle("/admin/currencies", getMiddleware(handlers.Can(store.PricingWrite), handlers.AdminCurrencies)).Methods("GET")
+	r.Handle("/admin/currencies", getMiddleware(handlers.Can(store.PricingWrite), handlers.AdminCurrencyUpdate)).Methods("POST")
+	r.Handle("/admin/currencies/{currency}", getMiddleware(handlers.Can(store.PricingWrite), handlers.AdminCurrencyDelete)).Methods("DELETE")
+	r.Handle("/admin/db/backup", getMiddleware(handlers.Can(store.DBBackup), handlers.BackupDB)).Methods("GET")
+	r.Handle("/admin/confirm", getMiddleware(handlers.Staff, handlers.Confirm)).Methods("GET")
+	r.Handle("/admin/categories", getMiddleware(handlers.Can(store.CatalogWrite), handlers.AddCategory)).Methods("POST")
+	r.Handle("/admin/categories/{path:.+}", getMiddleware(handlers.Can(store.CatalogWrite), handlers.AdminCategoryPage)).Methods("GET")
+	r.Handle("/admin/categories/{path:.+}", getMiddleware(handlers.Can(store.CatalogWrite), handlers.UpdateCategory)).Methods("POST")
+	r.Handle("/admin/categories/{path:.+}", getMiddleware(handlers.Can(store.CatalogWrite), handlers.DeleteCategory)).Methods("DELETE")
+	r.Handle("/admin/subcategories/{path:.+}", getMiddleware(handlers.Can(store.CatalogWrite), handlers.AddCategory)).Methods("POST")
+	r.Handle("/admin/products/{path:.+}", getMiddleware(handlers.Can(store.CatalogWrite), handlers.AddProduct)).Methods("POST")
+	r.Handle("/admin/history/products/{path:.+}", getMiddleware(handlers.Can(store.CatalogWrite), handlers.ProductHistory)).Methods("GET")
+	r.Handle("/admin/history/products/{path:.+}", getMiddleware(handlers.Can(store.CatalogWrite), handlers.RestoreProduct)).Methods("POST")
+	r.Handle("/admin/order", getMiddleware(handlers.Can(store.CatalogWrite), handlers.UpdateOrder)).Methods("POST")
+	r.Handle("/admin/order/{path:.+}", getMiddleware(handlers.Can(store.CatalogWrite), handlers.UpdateOrder)).Methods("POST")
+	r.Handle("/admin/prices/{path:.+}", getMiddleware(handlers.Can(store.PricingWrite), handlers.UpdatePrice)).Methods("POST")
 
 	r.NotFoundHandler = http.HandlerFunc(handlers.NotFound)
 
@@ -211,6 +340,9 @@ func doServe() {
 	r.PathPrefix("/css").Handler(handlers.HandleErr(handlers.Static()))
 	r.PathPrefix("/js").Handler(handlers.HandleErr(handlers.Static()))
 
+	//pages written in the admin, last so they can't hide anything above
+	r.Handle("/{page}", getMiddleware(handlers.Anyone, handlers.Page)).Methods("GET")
+
 	chain := alice.New(handlers.Log(cfg.LogOutput)).Then(r)
 	iface := os.Getenv("STORE_IFACE")
 	addr := fmt.Sprintf("%s:%d", iface, cfg.Port)
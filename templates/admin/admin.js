@@ -17,5 +17,53 @@ function confirmCategory() {
     };
     return false;
 }
+// Items in a .sortable list can be dragged into a new order, which is
+// saved as soon as they are dropped.
+var dragged;
+
+function saveOrder() {
+    var names = [];
+    var items = document.querySelectorAll(".sortable li");
+    for (var i = 0; i < items.length; i++) {
+        names.push("Name=" + encodeURIComponent(items[i].getAttribute("data-name")));
+    }
+
+    var xhr = new XMLHttpRequest();
+    xhr.open("POST", "{{.OrderURI}}");
+    xhr.setRequestHeader("Content-Type", "application/x-www-form-urlencoded");
+    xhr.setRequestHeader("X-CSRF-Token", "{{.CSRF}}");
+    xhr.send(names.join("&"));
+}
+
+function dropItem(e) {
+    e.preventDefault();
+    if (!dragged || dragged === this || dragged.parentNode !== this.parentNode) {
+        return;
+    }
+
+    var items = Array.prototype.slice.call(this.parentNode.children);
+    if (items.indexOf(dragged) < items.indexOf(this)) {
+        this.parentNode.insertBefore(dragged, this.nextSibling);
+    } else {
+        this.parentNode.insertBefore(dragged, this);
+    }
+    saveOrder();
+}
+
+(function() {
+    var items = document.querySelectorAll(".sortable li");
+    for (var i = 0; i < items.length; i++) {
+        items[i].draggable = true;
+        items[i].addEventListener("dragstart", function(e) {
+            dragged = this;
+            e.dataTransfer.effectAllowed = "move";
+            e.dataTransfer.setData("text/plain", this.getAttribute("data-name"));
+        });
+        items[i].addEventListener("dragover", function(e) {
+            e.preventDefault();
+        });
+        items[i].addEventListener("drop", dropItem);
+    }
+})();
 
 {{end}}
@@ -8,5 +8,36 @@ function confirm() {
     };
     return false;
 }
+// The preview is rendered by the server, the same way the blog will be,
+// a moment after the author stops typing.
+var previewTimer;
+
+function preview() {
+    var xhr = new XMLHttpRequest();
+    xhr.open("POST", "/admin/blogs/preview");
+    xhr.setRequestHeader("Content-Type", "application/x-www-form-urlencoded");
+    xhr.setRequestHeader("X-CSRF-Token", "{{.CSRF}}");
+    xhr.onload = function() {
+        if (xhr.status == 200) {
+            document.getElementById("blog-preview").innerHTML = xhr.responseText;
+        }
+    };
+    xhr.send("blog=" + encodeURIComponent({{.ID}}) + "&body=" + encodeURIComponent(document.getElementById("blog-body").value));
+}
+
+function deleteMedia(name) {
+    var xhr = new XMLHttpRequest();
+    xhr.open("DELETE", "{{.MediaURI}}/" + encodeURIComponent(name));
+    xhr.setRequestHeader("X-CSRF-Token", "{{.CSRF}}");
+    xhr.onload = function() {
+        document.location.reload();
+    };
+    xhr.send();
+}
+
+document.getElementById("blog-body").addEventListener("input", function() {
+    clearTimeout(previewTimer);
+    previewTimer = setTimeout(preview, 300);
+});
 
 {{end}}
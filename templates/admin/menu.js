@@ -0,0 +1,53 @@
+{{define "menu.js"}}
+
+// The items can be dragged into a new order.  The fields are numbered by
+// where their item ends up when the form is sent.
+var dragged;
+
+function dropItem(e) {
+    e.preventDefault();
+    if (!dragged || dragged === this) {
+        return;
+    }
+
+    var items = Array.prototype.slice.call(this.parentNode.children);
+    if (items.indexOf(dragged) < items.indexOf(this)) {
+        this.parentNode.insertBefore(dragged, this.nextSibling);
+    } else {
+        this.parentNode.insertBefore(dragged, this);
+    }
+}
+
+function numberItems() {
+    var items = document.querySelectorAll("#menu-items li");
+    for (var i = 0; i < items.length; i++) {
+        var fields = items[i].querySelectorAll("[data-field]");
+        for (var j = 0; j < fields.length; j++) {
+            var f = fields[j].getAttribute("data-field");
+            if (f == "remove") {
+                fields[j].value = i;
+            } else {
+                fields[j].name = "items." + i + "." + f;
+            }
+        }
+    }
+}
+
+(function() {
+    var items = document.querySelectorAll("#menu-items li");
+    for (var i = 0; i < items.length; i++) {
+        items[i].draggable = true;
+        items[i].addEventListener("dragstart", function(e) {
+            dragged = this;
+            e.dataTransfer.effectAllowed = "move";
+            e.dataTransfer.setData("text/plain", "");
+        });
+        items[i].addEventListener("dragover", function(e) {
+            e.preventDefault();
+        });
+        items[i].addEventListener("drop", dropItem);
+    }
+    document.getElementById("menu-form").addEventListener("submit", numberItems);
+})();
+
+{{end}}
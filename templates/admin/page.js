@@ -0,0 +1,33 @@
+{{define "page.js"}}
+
+function confirm() {
+    document.location.href = "/admin/confirm?resource=/admin/pages/{{.Page.Slug}}&name=the {{.Page.Slug}} page";
+    document.getElementById('page-form').onsubmit = function() {
+        return false;
+    };
+    return false;
+}
+
+// The preview is rendered by the server, the same way the page will be,
+// a moment after the author stops typing.
+var previewTimer;
+
+function preview() {
+    var xhr = new XMLHttpRequest();
+    xhr.open("POST", "/admin/pages/preview");
+    xhr.setRequestHeader("Content-Type", "application/x-www-form-urlencoded");
+    xhr.setRequestHeader("X-CSRF-Token", "{{.CSRF}}");
+    xhr.onload = function() {
+        if (xhr.status == 200) {
+            document.getElementById("page-preview").innerHTML = xhr.responseText;
+        }
+    };
+    xhr.send("body=" + encodeURIComponent(document.getElementById("page-body").value));
+}
+
+document.getElementById("page-body").addEventListener("input", function() {
+    clearTimeout(previewTimer);
+    previewTimer = setTimeout(preview, 300);
+});
+
+{{end}}
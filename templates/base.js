@@ -50,9 +50,9 @@ function addToCart(title) {
         item = {
             id: id,
 			price: price,
+            weight: weight,
             count: quantity,
-            cat: category,
-            subcat: subcategory
+            path: path
         };
     }
 
@@ -76,8 +76,37 @@ function doAddToCart(cart, item, title, animate) {
     doInitCart(cart, animate);
 }
 
+var suggestions = {};
+var suggestTimer;
+
+function suggest() {
+    var q = $("#search-box").val();
+    if (suggestions[q] != undefined) {
+        window.location = suggestions[q];
+        return;
+    }
+
+    clearTimeout(suggestTimer);
+    if (q.length < 2) {
+        return;
+    }
+
+    suggestTimer = setTimeout(function() {
+        $.getJSON("/search/suggest", {q: q}, function(results) {
+            var $list = $("#search-suggestions");
+            $list.empty();
+            suggestions = {};
+            $.each(results, function(i, r) {
+                suggestions[r.title] = r.link;
+                $list.append($("<option>").attr("value", r.title));
+            });
+        });
+    }, 200);
+}
+
 $(document).ready(function() {
     initCart();
+    $("#search-box").on("input", suggest);
 });
 
 {{end}}
@@ -1,6 +1,11 @@
 {{define "cart.js"}}
 
 var discountCode = {{.DiscountCode}};
+var currency = {
+    code: {{.Currency.Code}},
+    symbol: {{.Currency.Symbol}},
+    rate: parseFloat({{.Currency.Rate}})
+};
 var items = JSON.parse(localStorage.getItem("shopping-cart"));
 
 var shopClient = ShopifyBuy.buildClient({
@@ -10,6 +15,7 @@ var shopClient = ShopifyBuy.buildClient({
 });
 
 var products = {};
+var shipping = 0.0;
 
 function getProducts() {
     for (var title in items) {
@@ -29,7 +35,9 @@ function loadCart() {
     var i = 0;
     for (var title in items) {
         var item = items[title];
-        var url = "/cart/lineitem/" + item.cat + "/" + item.subcat + "/" + title;
+        //carts saved before categories nested have cat and subcat
+        var path = item.path || item.cat + "/" + item.subcat;
+        var url = "/cart/lineitem/" + path + "/" + title;
         $.get(url, {quantity: item.count}, function(html) {
             $("#items").append($(html));
         });
@@ -37,6 +45,7 @@ function loadCart() {
     }
 
     updateTotal(items);
+    updateShipping();
     
     showCart(i);
 }
@@ -61,24 +70,57 @@ function update(title, n) {
         item.count = 0;
     }
     doAddToCart(items, item, title, false);
-    updateTotal(items)
+    updateShipping();
     var sel = "#" + item.id + "-quantity";
     $(sel).val(item.count);
 
     sel = "#" + item.id + "-total";
 	//var price = parseFloat(item.price);
     var itemPrice = item.count * item.price;
-    $(sel).text("$" + itemPrice.toFixed(2));
+    $(sel).text(formatPrice(itemPrice));
     return false;
 }
 
 function updateTotal(items) {
-    var total = 0.0;
+    var total = shipping;
     for (var title in items) {
         var item = items[title];
         total += item.count * item.price;
     }
-    $("#grand-total").text("$" + total.toFixed(2));
+    $("#grand-total").text(formatPrice(total));
+}
+
+// prices in the cart are in the store's currency
+function formatPrice(amount) {
+    var s = (amount * currency.rate).toFixed(2);
+    if (currency.symbol == "") {
+        return s + " " + currency.code;
+    }
+    return currency.symbol + s;
+}
+
+function updateShipping() {
+    var weight = 0;
+    for (var title in items) {
+        var item = items[title];
+        weight += item.count * (item.weight || 0);
+    }
+
+    var args = {
+        weight: weight,
+        country: $("#shipping-country").val(),
+        state: $("#shipping-state").val()
+    };
+
+    $.getJSON("/cart/shipping", args, function(data) {
+        shipping = parseFloat(data.price);
+        $("#shipping-total").text(formatPrice(shipping));
+        updateTotal(items);
+    }).fail(function() {
+        shipping = 0.0;
+        $("#shipping-total").text("unavailable");
+        updateTotal(items);
+    });
 }
 
 function updateOnBlur(title) {
@@ -92,7 +134,7 @@ function removeItem(title) {
     delete items[title];
     localStorage.setItem("shopping-cart", JSON.stringify(items));
     $("#" + item.id).remove();
-    updateTotal(items);
+    updateShipping();
     doInitCart(items, false);
 
     var i = 0;
@@ -4,6 +4,7 @@ function doDelete() {
     $.ajax({
         url: "{{.Resource}}",
         type: "DELETE",
+        headers: {"X-CSRF-Token": "{{.CSRF}}"},
         success: function(result) {
             document.getElementById("success").style.visibility = "visible";
             document.getElementById("confirm").style.visibility = "hidden";
@@ -1,9 +1,9 @@
 {{define "shop.js"}}
 
-var category = {{.Product.Cat}};
-var subcategory = {{.Product.Subcat}};
+var path = {{join .Product.Path "/"}};
 var id = {{.Product.ID}};
-var price = {{.Product.Price}};
+var price = {{.Product.Price.String}};
+var weight = {{.Product.Weight}};
 
 function updateQuantity(n) {
     quantity += n;